@@ -0,0 +1,242 @@
+package tinyreflect
+
+import . "github.com/cdvelop/tinystring"
+
+// Clone returns a deep copy of v, as DeepCopyInto makes it. It returns nil
+// when v is nil or cannot be copied.
+func Clone(v any) any {
+	src := ValueOf(v)
+	if src.Kind() == K.Invalid {
+		return nil
+	}
+	dst, err := NewValue(src.Type()).Elem()
+	if err != nil {
+		return nil
+	}
+	if err := src.DeepCopyInto(dst); err != nil {
+		return nil
+	}
+	out, err := dst.Interface()
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// DeepCopyInto stores a deep copy of v in dst, which must be settable and
+// have v's type. Pointees, slices, maps and interface contents are
+// allocated anew, and values shared in v stay shared in the copy, so two
+// fields pointing at the same object still do and cycles are kept.
+// Slices are shared only when they have the same start and length.
+// Struct fields tagged `clone:"-"` are left zero and those tagged
+// `clone:"shallow"` are copied as they are; unexported fields are always
+// copied as they are.
+func (v Value) DeepCopyInto(dst Value) error {
+	if dst.Type() != v.Type() {
+		return newValueError("DeepCopyInto", dst.Kind(), ErrTypeMismatch)
+	}
+	c := cloner{seen: make(map[cloneRef]Value)}
+	return c.copy(dst, v)
+}
+
+// cloneRef identifies a pointer, map or slice already copied. The length
+// tells apart slices that start at the same element.
+type cloneRef struct {
+	p   uintptr
+	typ *Type
+	n   int
+}
+
+type cloner struct {
+	seen map[cloneRef]Value
+}
+
+// copy stores a shallow copy of src in dst and then replaces what src
+// references with copies.
+func (c *cloner) copy(dst, src Value) error {
+	if err := dst.Set(src); err != nil {
+		return err
+	}
+
+	switch k := src.Kind(); k {
+	case K.Pointer, K.Map, K.Slice:
+		p, _ := src.Pointer()
+		if p == 0 {
+			return nil
+		}
+		ref := cloneRef{p: p, typ: src.Type()}
+		if k == K.Slice {
+			ref.n, _ = src.Len()
+		}
+		if done, ok := c.seen[ref]; ok {
+			return dst.Set(done)
+		}
+		switch k {
+		case K.Pointer:
+			out := NewValue(src.Type().Elem())
+			c.seen[ref] = out
+			if err := dst.Set(out); err != nil {
+				return err
+			}
+			to, err := out.Elem()
+			if err != nil {
+				return err
+			}
+			from, err := src.Elem()
+			if err != nil {
+				return err
+			}
+			return c.copy(to, from)
+		case K.Slice:
+			return c.copySlice(dst, src, ref)
+		}
+		return c.copyMap(dst, src, ref)
+
+	case K.Interface:
+		if isNil, _ := src.IsNil(); isNil {
+			return nil
+		}
+		elem, err := src.Elem()
+		if err != nil {
+			return err
+		}
+		out, err := NewValue(elem.Type()).Elem()
+		if err != nil {
+			return err
+		}
+		if err := c.copy(out, elem); err != nil {
+			return err
+		}
+		return dst.Set(out)
+
+	case K.Struct:
+		t := src.Type()
+		n, _ := t.NumField()
+		for i := 0; i < n; i++ {
+			sf, err := t.Field(i)
+			if err != nil {
+				return err
+			}
+			tag := sf.Tag().Get("clone")
+			if !sf.IsExported() || tag == "shallow" || tag != "-" && !needsDeepCopy(sf.Typ) {
+				continue
+			}
+			to, err := dst.Field(i)
+			if err != nil {
+				return err
+			}
+			if tag == "-" {
+				err = to.SetZero()
+			} else {
+				from, ferr := src.Field(i)
+				if ferr != nil {
+					return ferr
+				}
+				err = c.copy(to, from)
+			}
+			if err != nil {
+				return withPath(err, sf.Name.String())
+			}
+		}
+
+	case K.Array:
+		if !needsDeepCopy(src.Type().Elem()) {
+			return nil
+		}
+		n, _ := src.Len()
+		for i := 0; i < n; i++ {
+			if err := c.copyElem(dst, src, i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *cloner) copySlice(dst, src Value, ref cloneRef) error {
+	capacity, _ := src.Cap()
+	out, err := MakeSlice(src.Type(), ref.n, capacity)
+	if err != nil {
+		return err
+	}
+	c.seen[ref] = out
+	if err := dst.Set(out); err != nil {
+		return err
+	}
+	if !needsDeepCopy(src.Type().Elem()) {
+		_, err := Copy(out, src)
+		return err
+	}
+	for i := 0; i < ref.n; i++ {
+		if err := c.copyElem(out, src, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *cloner) copyElem(dst, src Value, i int) error {
+	to, err := dst.Index(i)
+	if err != nil {
+		return err
+	}
+	from, err := src.Index(i)
+	if err != nil {
+		return err
+	}
+	return withPath(c.copy(to, from), indexSegment(i))
+}
+
+func (c *cloner) copyMap(dst, src Value, ref cloneRef) error {
+	n, _ := src.Len()
+	out, err := MakeMapWithSize(src.Type(), n)
+	if err != nil {
+		return err
+	}
+	c.seen[ref] = out
+	if err := dst.Set(out); err != nil {
+		return err
+	}
+	deep := needsDeepCopy(src.Type().Elem())
+	iter, err := src.MapRange()
+	if err != nil {
+		return err
+	}
+	for iter.Next() {
+		elem := iter.Value()
+		if deep {
+			to, err := NewValue(src.Type().Elem()).Elem()
+			if err != nil {
+				return err
+			}
+			if err := c.copy(to, elem); err != nil {
+				return err
+			}
+			elem = to
+		}
+		if err := out.SetMapIndex(iter.Key(), elem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// needsDeepCopy reports whether values of type t can reference memory
+// that a shallow copy would share, or hold fields tagged `clone:"-"`.
+func needsDeepCopy(t *Type) bool {
+	switch t.Kind() {
+	case K.Pointer, K.Slice, K.Map, K.Interface:
+		return true
+	case K.Array:
+		return needsDeepCopy(t.Elem())
+	case K.Struct:
+		n, _ := t.NumField()
+		for i := 0; i < n; i++ {
+			sf, err := t.Field(i)
+			if err == nil && (sf.Tag().Get("clone") == "-" || needsDeepCopy(sf.Typ)) {
+				return true
+			}
+		}
+	}
+	return false
+}
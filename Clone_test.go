@@ -0,0 +1,151 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+type cloneNode struct {
+	Name  string
+	Next  *cloneNode
+	Tags  []string
+	Attrs map[string][]int
+	Any   any
+	Grid  [2]*int
+	Cache map[string]int `clone:"-"`
+	Meta  *cloneMeta     `clone:"shallow"`
+	Token string         `clone:"-"`
+	data  []byte
+}
+
+type cloneMeta struct {
+	Version int
+}
+
+type cloneState struct {
+	A, B   *cloneNode
+	Items  []cloneNode
+	Window []string
+	Full   []string
+	Lookup map[int]*cloneNode
+}
+
+func TestClone(t *testing.T) {
+	n := 5
+	meta := &cloneMeta{1}
+	shared := &cloneNode{
+		Name:  "shared",
+		Tags:  []string{"a"},
+		Attrs: map[string][]int{"k": {1, 2}},
+		Any:   []any{"x", map[string]any{"y": 1}},
+		Grid:  [2]*int{&n, &n},
+		Cache: map[string]int{"c": 1},
+		Meta:  meta,
+		Token: "secret",
+		data:  []byte("raw"),
+	}
+	full := []string{"p", "q", "r"}
+	src := cloneState{
+		A:      shared,
+		B:      shared,
+		Items:  []cloneNode{{Name: "i", Next: shared}},
+		Window: full[:2],
+		Full:   full,
+		Lookup: map[int]*cloneNode{1: shared},
+	}
+
+	got, ok := tinyreflect.Clone(src).(cloneState)
+	if !ok {
+		t.Fatalf("Clone returned %T", tinyreflect.Clone(src))
+	}
+
+	// Same content, apart from the skipped fields
+	want := src
+	wantNode := *shared
+	wantNode.Cache, wantNode.Token = nil, ""
+	if !reflect.DeepEqual(*got.A, wantNode) {
+		t.Errorf("got  %+v\nwant %+v", *got.A, wantNode)
+	}
+	if !reflect.DeepEqual(got.Full, want.Full) || !reflect.DeepEqual(got.Window, want.Window) {
+		t.Errorf("slices: %q %q", got.Full, got.Window)
+	}
+
+	// Nothing reachable is shared with the source, except shallow fields
+	switch {
+	case got.A == shared:
+		t.Error("pointer not copied")
+	case &got.A.Tags[0] == &shared.Tags[0]:
+		t.Error("slice not copied")
+	case &got.A.Attrs["k"][0] == &shared.Attrs["k"][0]:
+		t.Error("map element not copied")
+	case got.A.Grid[0] == &n:
+		t.Error("array element not copied")
+	case &got.Full[0] == &full[0]:
+		t.Error("full slice not copied")
+	case got.A.Meta != meta:
+		t.Error("shallow field copied")
+	}
+	inner := got.A.Any.([]any)[1].(map[string]any)
+	inner["y"] = 2
+	if shared.Any.([]any)[1].(map[string]any)["y"] != 1 {
+		t.Error("interface contents not copied")
+	}
+
+	// Aliasing inside the value is preserved
+	switch {
+	case got.A != got.B:
+		t.Error("A and B no longer alias")
+	case got.Items[0].Next != got.A || got.Lookup[1] != got.A:
+		t.Error("references to the shared node not preserved")
+	case got.A.Grid[0] != got.A.Grid[1]:
+		t.Error("array pointers no longer alias")
+	}
+	if cap(got.Full) != cap(full) {
+		t.Errorf("cap %d, want %d", cap(got.Full), cap(full))
+	}
+
+	if tinyreflect.Clone(nil) != nil {
+		t.Error("Clone(nil) != nil")
+	}
+	if c := tinyreflect.Clone(3); c != 3 {
+		t.Errorf("Clone(3) = %v", c)
+	}
+}
+
+func TestCloneCycle(t *testing.T) {
+	a := &cloneNode{Name: "a"}
+	a.Next = &cloneNode{Name: "b", Next: a}
+	a.Any = a
+
+	got := tinyreflect.Clone(a).(*cloneNode)
+	if got == a || got.Next == a.Next {
+		t.Fatal("nodes not copied")
+	}
+	if got.Next.Next != got || got.Any.(*cloneNode) != got {
+		t.Error("cycle not preserved")
+	}
+}
+
+func TestDeepCopyInto(t *testing.T) {
+	src := cloneNode{Name: "s", Tags: []string{"t"}}
+	var dst cloneNode
+	if err := tinyreflect.ValueOf(src).DeepCopyInto(tinyreflect.Indirect(tinyreflect.ValueOf(&dst))); err != nil {
+		t.Fatalf("DeepCopyInto: %v", err)
+	}
+	if dst.Name != "s" || len(dst.Tags) != 1 || &dst.Tags[0] == &src.Tags[0] {
+		t.Errorf("got %+v", dst)
+	}
+
+	var other cloneMeta
+	err := tinyreflect.ValueOf(src).DeepCopyInto(tinyreflect.Indirect(tinyreflect.ValueOf(&other)))
+	if !errors.Is(err, tinyreflect.ErrTypeMismatch) {
+		t.Errorf("other type: got %v, want ErrTypeMismatch", err)
+	}
+	err = tinyreflect.ValueOf(src).DeepCopyInto(tinyreflect.ValueOf(dst))
+	if !errors.Is(err, tinyreflect.ErrNotAssignable) {
+		t.Errorf("unaddressable: got %v, want ErrNotAssignable", err)
+	}
+}
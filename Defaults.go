@@ -0,0 +1,115 @@
+package tinyreflect
+
+import . "github.com/cdvelop/tinystring"
+
+// ApplyDefaults fills the zero fields of the struct ptr points to with the
+// value of their `default` tag, e.g. `default:"8080"`. The text is parsed
+// into the field's kind: strings, bools, ints, uints and floats of any
+// width, and pointers to those, which are allocated. Slices take a
+// comma-separated list, as in `default:"a,b,c"`. Fields that already hold
+// a value are left alone, and nested structs and non-nil pointers to
+// structs get their own defaults. A value that does not parse is
+// ErrTypeMismatch, one that does not fit is ErrOverflow, and both carry
+// the field path.
+func ApplyDefaults(ptr any) error {
+	v := ValueOf(ptr)
+	if v.Kind() != K.Pointer || v.IsZero() {
+		return newValueError("ApplyDefaults", v.Kind(), ErrInvalidArgument)
+	}
+	elem, err := v.Elem()
+	if err != nil {
+		return err
+	}
+	if elem.Kind() != K.Struct {
+		return newValueError("ApplyDefaults", elem.Kind(), ErrNotStruct)
+	}
+	return applyDefaults(elem, 0)
+}
+
+func applyDefaults(v Value, depth int) error {
+	if depth > maxMapDepth {
+		return newValueError("ApplyDefaults", v.Kind(), ErrInvalidArgument)
+	}
+	t := v.Type()
+	n, err := t.NumField()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		sf, err := t.Field(i)
+		if err != nil {
+			return err
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		fv, err := v.Field(i)
+		if err != nil {
+			return err
+		}
+		if err := applyFieldDefault(fv, sf, depth); err != nil {
+			return withPath(err, sf.Name.String())
+		}
+	}
+	return nil
+}
+
+func applyFieldDefault(fv Value, sf StructField, depth int) error {
+	if text, ok := sf.Tag().Lookup("default"); ok && text != "" && fv.IsZero() {
+		if fv.Kind() == K.Slice && fv.Type().Elem().Kind() != K.Uint8 {
+			return setList("ApplyDefaults", fv, text)
+		}
+		return setText("ApplyDefaults", fv, text)
+	}
+
+	switch fv.Kind() {
+	case K.Struct:
+		return applyDefaults(fv, depth+1)
+	case K.Pointer:
+		if fv.IsZero() || fv.Type().Elem().Kind() != K.Struct {
+			return nil
+		}
+		elem, err := fv.Elem()
+		if err != nil {
+			return err
+		}
+		return applyDefaults(elem, depth+1)
+	}
+	return nil
+}
+
+// setList sets the slice v to the elements of the comma-separated list s,
+// each parsed as setText does.
+func setList(method string, v Value, s string) error {
+	items := splitList(s, ',')
+	out, err := MakeSlice(v.Type(), len(items), len(items))
+	if err != nil {
+		return err
+	}
+	for i, item := range items {
+		elem, err := out.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := setText(method, elem, item); err != nil {
+			return withPath(err, indexSegment(i))
+		}
+	}
+	return v.Set(out)
+}
+
+// splitList splits s at each sep. An empty s has no items.
+func splitList(s string, sep byte) []string {
+	if s == "" {
+		return nil
+	}
+	var out []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == sep {
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, s[start:])
+}
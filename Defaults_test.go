@@ -0,0 +1,127 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+type defaultsDB struct {
+	Host string `default:"localhost"`
+	Port uint16 `default:"5432"`
+}
+
+type defaultsConfig struct {
+	Port    int      `default:"8080"`
+	Small   int8     `default:"-12"`
+	Big     uint64   `default:"18446744073709551615"`
+	Ratio   float32  `default:"0.5"`
+	Debug   bool     `default:"true"`
+	Name    string   `default:"app"`
+	Hosts   []string `default:"a,b,c"`
+	Ports   []int    `default:"80,443"`
+	Timeout *int     `default:"30"`
+	Raw     []byte   `default:"xyz"`
+	DB      defaultsDB
+	Replica *defaultsDB
+	Backup  *defaultsDB
+	Plain   int
+	hidden  string `default:"h"`
+}
+
+func TestApplyDefaults(t *testing.T) {
+	cfg := defaultsConfig{Name: "set", Replica: &defaultsDB{Port: 1}}
+	if err := tinyreflect.ApplyDefaults(&cfg); err != nil {
+		t.Fatalf("ApplyDefaults: %v", err)
+	}
+	timeout := 30
+	want := defaultsConfig{
+		Port:    8080,
+		Small:   -12,
+		Big:     18446744073709551615,
+		Ratio:   0.5,
+		Debug:   true,
+		Name:    "set",
+		Hosts:   []string{"a", "b", "c"},
+		Ports:   []int{80, 443},
+		Timeout: &timeout,
+		Raw:     []byte("xyz"),
+		DB:      defaultsDB{Host: "localhost", Port: 5432},
+		Replica: &defaultsDB{Host: "localhost", Port: 1},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got  %+v\nwant %+v", cfg, want)
+	}
+
+	// Applying again changes nothing
+	before := cfg
+	if err := tinyreflect.ApplyDefaults(&cfg); err != nil || !reflect.DeepEqual(cfg, before) {
+		t.Errorf("second call changed %+v (%v)", cfg, err)
+	}
+}
+
+func TestApplyDefaultsUnexported(t *testing.T) {
+	type inner struct{ N int }
+	type S struct {
+		Pub    int    `default:"1"`
+		priv   int    `default:"2"`
+		inner         // unexported embedded type
+		Tagged string `default:"x"`
+	}
+	var s S
+	if err := tinyreflect.ApplyDefaults(&s); err != nil {
+		t.Fatalf("ApplyDefaults: %v", err)
+	}
+	if s.Pub != 1 || s.Tagged != "x" || s.priv != 0 {
+		t.Errorf("ApplyDefaults wrote %+v; the unexported field must stay zero", s)
+	}
+}
+
+func TestApplyDefaultsErrors(t *testing.T) {
+	var ve *tinyreflect.ValueError
+	tests := []struct {
+		name string
+		ptr  any
+		err  error
+		path string
+	}{
+		{"syntax", &struct {
+			N int `default:"eight"`
+		}{}, tinyreflect.ErrTypeMismatch, "N"},
+		{"overflow", &struct {
+			N uint8 `default:"300"`
+		}{}, tinyreflect.ErrOverflow, "N"},
+		{"bool", &struct {
+			B bool `default:"yes"`
+		}{}, tinyreflect.ErrTypeMismatch, "B"},
+		{"list item", &struct {
+			L []int8 `default:"1,x"`
+		}{}, tinyreflect.ErrTypeMismatch, "L[1]"},
+		{"nested", &struct {
+			Inner struct {
+				F float32 `default:"1e99"`
+			}
+		}{}, tinyreflect.ErrOverflow, "Inner.F"},
+		{"unsupported", &struct {
+			M map[string]int `default:"a"`
+		}{}, tinyreflect.ErrUnsupportedKind, "M"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tinyreflect.ApplyDefaults(tt.ptr)
+			if !errors.Is(err, tt.err) || !errors.As(err, &ve) || ve.Path != tt.path {
+				t.Errorf("got %v, want %v at %q", err, tt.err, tt.path)
+			}
+		})
+	}
+
+	if err := tinyreflect.ApplyDefaults(defaultsConfig{}); !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+		t.Errorf("non-pointer: got %v", err)
+	}
+	n := 1
+	if err := tinyreflect.ApplyDefaults(&n); !errors.Is(err, tinyreflect.ErrNotStruct) {
+		t.Errorf("non-struct: got %v", err)
+	}
+}
@@ -0,0 +1,333 @@
+package tinyreflect
+
+import (
+	"slices"
+
+	. "github.com/cdvelop/tinystring"
+)
+
+// ChangeKind tells how a value differs between the two sides of a Diff.
+type ChangeKind uint8
+
+const (
+	Modified ChangeKind = iota + 1 // the value changed
+	Added                          // the value exists only in the new side
+	Removed                        // the value exists only in the old side
+)
+
+// String returns "modified", "added" or "removed".
+func (k ChangeKind) String() string {
+	switch k {
+	case Modified:
+		return "modified"
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	}
+	return ""
+}
+
+// Change is one difference found by Diff. Old and New point into the
+// compared values; Old is the zero Value for Added and New for Removed.
+type Change struct {
+	Path string // e.g. "Items[2].Price" or "Labels.env"
+	Kind ChangeKind
+	Old  Value
+	New  Value
+}
+
+// Differ compares two values of the same type. Fields tagged `diff:"-"`
+// are always skipped.
+type Differ struct {
+	// IgnoreTags skips fields that carry any of these tag keys with a
+	// non-empty value, e.g. "readonly" skips fields tagged `readonly:"true"`.
+	IgnoreTags []string
+}
+
+// Diff compares a and b with the default Differ.
+func Diff(a, b any) ([]Change, error) {
+	return Differ{}.Diff(a, b)
+}
+
+// Diff returns the changes that turn a into b, which must have the same
+// type. Structs are compared field by field, slices and arrays element by
+// element, with extra elements reported as Added or Removed, and maps key
+// by key in key order. A nil pointer or interface on one side only is
+// Added or Removed; nil and empty slices and maps are equal. Other values,
+// including []byte, are compared as a whole.
+func (d Differ) Diff(a, b any) ([]Change, error) {
+	va, vb := ValueOf(a), ValueOf(b)
+	if va.Type() != vb.Type() {
+		return nil, newValueError("Diff", vb.Kind(), ErrTypeMismatch)
+	}
+	if va.Kind() == K.Invalid {
+		return nil, nil
+	}
+	w := differ{Differ: d}
+	if err := w.compare(va, vb, "", 0); err != nil {
+		return nil, err
+	}
+	return w.changes, nil
+}
+
+// differ holds the state of one Diff call.
+type differ struct {
+	Differ
+	changes []Change
+}
+
+func (w *differ) add(path string, kind ChangeKind, old, new Value) {
+	w.changes = append(w.changes, Change{Path: path, Kind: kind, Old: old, New: new})
+}
+
+// joinPath appends a field name or map key to path with a dot.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// compare records the differences between x and y, which have the same type.
+func (w *differ) compare(x, y Value, path string, depth int) error {
+	if depth > maxMapDepth {
+		return &ValueError{Method: "Diff", Kind: x.Kind(), Path: path, Err: ErrInvalidArgument}
+	}
+	switch k := x.Kind(); {
+	case k == K.Pointer || k == K.Interface:
+		xnil, _ := x.IsNil()
+		ynil, _ := y.IsNil()
+		switch {
+		case xnil && ynil:
+			return nil
+		case xnil:
+			w.add(path, Added, Value{}, y)
+			return nil
+		case ynil:
+			w.add(path, Removed, x, Value{})
+			return nil
+		}
+		if k == K.Pointer {
+			if px, _ := x.Pointer(); px != 0 {
+				if py, _ := y.Pointer(); px == py {
+					return nil // same memory
+				}
+			}
+		}
+		xe, err := x.Elem()
+		if err != nil {
+			return err
+		}
+		ye, err := y.Elem()
+		if err != nil {
+			return err
+		}
+		if xe.Type() != ye.Type() {
+			w.add(path, Modified, x, y)
+			return nil
+		}
+		return w.compare(xe, ye, path, depth+1)
+
+	case k == K.Struct:
+		return w.compareStruct(x, y, path, depth)
+
+	case k == K.Slice && x.Type().Elem().Kind() != K.Uint8, k == K.Array:
+		nx, _ := x.Len()
+		ny, _ := y.Len()
+		for i := 0; i < max(nx, ny); i++ {
+			seg := path + indexSegment(i)
+			switch {
+			case i >= nx:
+				ye, _ := y.Index(i)
+				w.add(seg, Added, Value{}, ye)
+			case i >= ny:
+				xe, _ := x.Index(i)
+				w.add(seg, Removed, xe, Value{})
+			default:
+				xe, err := x.Index(i)
+				if err != nil {
+					return err
+				}
+				ye, err := y.Index(i)
+				if err != nil {
+					return err
+				}
+				if err := w.compare(xe, ye, seg, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+
+	case k == K.Map:
+		return w.compareMap(x, y, path, depth)
+	}
+
+	equal, err := scalarEqual(x, y)
+	if err != nil {
+		return withPath(err, path)
+	}
+	if !equal {
+		w.add(path, Modified, x, y)
+	}
+	return nil
+}
+
+func (w *differ) compareStruct(x, y Value, path string, depth int) error {
+	t := x.Type()
+	n, err := t.NumField()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		sf, err := t.Field(i)
+		if err != nil {
+			return err
+		}
+		if !sf.IsExported() || w.ignored(sf.Tag()) {
+			continue
+		}
+		xf, err := x.Field(i)
+		if err != nil {
+			return err
+		}
+		yf, err := y.Field(i)
+		if err != nil {
+			return err
+		}
+		if err := w.compare(xf, yf, joinPath(path, sf.Name.String()), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ignored reports whether a field with this tag is left out of the diff.
+func (w *differ) ignored(tag StructTag) bool {
+	if tag.Get("diff") == "-" {
+		return true
+	}
+	for _, key := range w.IgnoreTags {
+		if tag.Get(key) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// mapKey is a map key with its path segment, for sorting.
+type mapKey struct {
+	key Value
+	seg Dynamic
+}
+
+func (w *differ) compareMap(x, y Value, path string, depth int) error {
+	keys, err := sortedKeys(x, y)
+	if err != nil {
+		return withPath(err, path)
+	}
+	for _, mk := range keys {
+		seg := keyPath(path, mk.seg)
+		xe, err := x.MapIndex(mk.key)
+		if err != nil {
+			return err
+		}
+		ye, err := y.MapIndex(mk.key)
+		if err != nil {
+			return err
+		}
+		switch {
+		case xe.Kind() == K.Invalid:
+			w.add(seg, Added, Value{}, ye)
+		case ye.Kind() == K.Invalid:
+			w.add(seg, Removed, xe, Value{})
+		default:
+			if err := w.compare(xe, ye, seg, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of both maps without duplicates, in order.
+// Only scalar keys can be ordered and written in a path.
+func sortedKeys(x, y Value) ([]mapKey, error) {
+	var keys []mapKey
+	for _, m := range []Value{x, y} {
+		mk, err := m.MapKeys()
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range mk {
+			seg := FromValue(k)
+			if seg.Kind < DynBool || seg.Kind > DynString {
+				return nil, newValueError("Diff", k.Kind(), ErrUnsupportedKind)
+			}
+			keys = append(keys, mapKey{k, seg})
+		}
+	}
+	slices.SortStableFunc(keys, func(a, b mapKey) int {
+		return compareDynamic(a.seg, b.seg)
+	})
+	return slices.CompactFunc(keys, func(a, b mapKey) bool {
+		return compareDynamic(a.seg, b.seg) == 0
+	}), nil
+}
+
+// keyPath appends a map key to path: "path.key" for string keys and
+// "path[key]" for the others.
+func keyPath(path string, key Dynamic) string {
+	switch key.Kind {
+	case DynString:
+		return joinPath(path, key.Text)
+	case DynInt:
+		return path + indexSegment(int(key.Int))
+	}
+	text, _ := formText(nil, ValueOf(key.Interface()))
+	return path + "[" + string(text) + "]"
+}
+
+// scalarEqual compares two values of the same scalar kind. NaN equals NaN,
+// so unchanged NaN fields are not reported.
+func scalarEqual(x, y Value) (bool, error) {
+	switch k := x.Kind(); {
+	case k == K.Bool:
+		a, _ := x.Bool()
+		b, _ := y.Bool()
+		return a == b, nil
+	case isIntKind(k):
+		a, _ := x.Int()
+		b, _ := y.Int()
+		return a == b, nil
+	case isUintKind(k):
+		a, _ := x.Uint()
+		b, _ := y.Uint()
+		return a == b, nil
+	case k == K.Float32 || k == K.Float64:
+		a, _ := x.Float()
+		b, _ := y.Float()
+		return a == b || a != a && b != b, nil
+	case k == K.String:
+		return x.String() == y.String(), nil
+	case k == K.Slice:
+		// []byte
+		a, _ := x.Len()
+		b, _ := y.Len()
+		if a != b {
+			return false, nil
+		}
+		for i := 0; i < a; i++ {
+			xe, _ := x.Index(i)
+			ye, _ := y.Index(i)
+			u, _ := xe.Uint()
+			v, _ := ye.Uint()
+			if u != v {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	return false, newValueError("Diff", x.Kind(), ErrUnsupportedKind)
+}
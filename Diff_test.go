@@ -0,0 +1,173 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+type diffItem struct {
+	Name  string
+	Price float64
+}
+
+type diffOrder struct {
+	ID      int
+	Note    *string
+	Items   []diffItem
+	Labels  map[string]string
+	Counts  map[int]uint8
+	Grid    [2]bool
+	Raw     []byte
+	Extra   any
+	Updated int64 `diff:"-"`
+	Version int   `readonly:"true"`
+	secret  string
+}
+
+// diffText renders changes as "kind path old->new" for comparison.
+func diffText(t *testing.T, changes []tinyreflect.Change) []string {
+	t.Helper()
+	text := func(v tinyreflect.Value) string {
+		if v.Type() == nil {
+			return "-"
+		}
+		d := tinyreflect.FromValue(v)
+		switch d.Kind {
+		case tinyreflect.DynString:
+			return d.Text
+		case tinyreflect.DynInt:
+			return string(rune('0' + d.Int))
+		}
+		return [...]string{"null", "bool", "int", "uint", "float", "string", "bytes", "list", "map"}[d.Kind]
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.Kind.String()+" "+c.Path+" "+text(c.Old)+"->"+text(c.New))
+	}
+	return got
+}
+
+func TestDiff(t *testing.T) {
+	note := "x"
+	base := func() diffOrder {
+		return diffOrder{
+			ID:     1,
+			Items:  []diffItem{{"a", 1}, {"b", 2}},
+			Labels: map[string]string{"env": "dev", "team": "core"},
+			Counts: map[int]uint8{1: 1},
+			Raw:    []byte{1, 2},
+			Extra:  "s",
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		change func(o *diffOrder)
+		want   []string
+	}{
+		{"Equal", func(o *diffOrder) {}, nil},
+		{"Scalar field", func(o *diffOrder) { o.ID = 2 }, []string{"modified ID 1->2"}},
+		{"Pointer added", func(o *diffOrder) { o.Note = &note }, []string{"added Note -->x"}},
+		{"Slice element field", func(o *diffOrder) { o.Items[1].Name = "c" }, []string{"modified Items[1].Name b->c"}},
+		{"Slice grows", func(o *diffOrder) { o.Items = append(o.Items, diffItem{Name: "d"}) }, []string{"added Items[2] -->map"}},
+		{"Slice shrinks", func(o *diffOrder) { o.Items = o.Items[:1] }, []string{"removed Items[1] map->-"}},
+		{"Map entries", func(o *diffOrder) {
+			o.Labels = map[string]string{"env": "prod", "zone": "eu"}
+		}, []string{"modified Labels.env dev->prod", "removed Labels.team core->-", "added Labels.zone -->eu"}},
+		{"Int map key", func(o *diffOrder) { o.Counts = map[int]uint8{2: 1} }, []string{"removed Counts[1] uint->-", "added Counts[2] -->uint"}},
+		{"Array element", func(o *diffOrder) { o.Grid[1] = true }, []string{"modified Grid[1] bool->bool"}},
+		{"Bytes as a whole", func(o *diffOrder) { o.Raw = []byte{1, 3} }, []string{"modified Raw bytes->bytes"}},
+		{"Interface type", func(o *diffOrder) { o.Extra = 1 }, []string{"modified Extra s->1"}},
+		{"Interface removed", func(o *diffOrder) { o.Extra = nil }, []string{"removed Extra s->-"}},
+		{"Nil and empty", func(o *diffOrder) {
+			o.Items, o.Labels, o.Counts, o.Raw = nil, nil, nil, nil
+		}, []string{
+			"removed Items[0] map->-", "removed Items[1] map->-",
+			"removed Labels.env dev->-", "removed Labels.team core->-",
+			"removed Counts[1] uint->-", "modified Raw bytes->null",
+		}},
+		{"Ignored fields", func(o *diffOrder) { o.Updated, o.Version, o.secret = 9, 9, "y" }, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, b := base(), base()
+			tc.change(&b)
+			changes, err := tinyreflect.Differ{IgnoreTags: []string{"readonly"}}.Diff(&a, &b)
+			if err != nil {
+				t.Fatalf("Diff: %v", err)
+			}
+			got := diffText(t, changes)
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("change %d: got %q, want %q", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDiffValues(t *testing.T) {
+	a := diffOrder{Version: 1, Items: []diffItem{{"a", math.NaN()}}}
+	b := diffOrder{Version: 2, Items: []diffItem{{"a", math.NaN()}}}
+	changes, err := tinyreflect.Diff(a, b)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Path != "Version" || changes[0].Kind != tinyreflect.Modified {
+		t.Fatalf("got %+v, want one Version change", changes)
+	}
+	old, _ := changes[0].Old.Int()
+	new, _ := changes[0].New.Int()
+	if old != 1 || new != 2 {
+		t.Errorf("got %d->%d, want 1->2", old, new)
+	}
+
+	empty := diffOrder{Items: []diffItem{}, Labels: map[string]string{}, Raw: []byte{}}
+	if changes, err := tinyreflect.Diff(diffOrder{}, empty); err != nil || changes != nil {
+		t.Errorf("nil against empty = %+v, %v", changes, err)
+	}
+	if changes, err := tinyreflect.Diff(3, 3); err != nil || changes != nil {
+		t.Errorf("Diff(3, 3) = %v, %v", changes, err)
+	}
+	if changes, err := tinyreflect.Diff("a", "b"); err != nil || len(changes) != 1 || changes[0].Path != "" {
+		t.Errorf("Diff(a, b) = %+v, %v", changes, err)
+	}
+}
+
+func TestDiffErrors(t *testing.T) {
+	type Funcs struct {
+		List []func()
+	}
+	type Keys struct {
+		M map[diffItem]int
+	}
+	testCases := []struct {
+		name    string
+		a, b    any
+		wantErr error
+		path    string
+	}{
+		{"Different types", 1, int8(1), tinyreflect.ErrTypeMismatch, ""},
+		{"Nil and value", nil, 1, tinyreflect.ErrTypeMismatch, ""},
+		{"Func element", Funcs{[]func(){nil}}, Funcs{[]func(){nil}}, tinyreflect.ErrUnsupportedKind, "List[0]"},
+		{"Struct key", Keys{map[diffItem]int{{}: 1}}, Keys{}, tinyreflect.ErrUnsupportedKind, "M"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tinyreflect.Diff(tc.a, tc.b)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got %v, want %v", err, tc.wantErr)
+			}
+			var ve *tinyreflect.ValueError
+			if !errors.As(err, &ve) || ve.Path != tc.path {
+				t.Errorf("got path %q, want %q", ve.Path, tc.path)
+			}
+		})
+	}
+}
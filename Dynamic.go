@@ -0,0 +1,533 @@
+package tinyreflect
+
+import (
+	"cmp"
+	"slices"
+	"unsafe"
+
+	"github.com/cdvelop/tinyreflect/internal/num"
+	. "github.com/cdvelop/tinystring"
+)
+
+// DynamicKind identifies what a Dynamic holds.
+type DynamicKind uint8
+
+const (
+	DynNull   DynamicKind = iota // no value: nil pointers, slices, maps and interfaces
+	DynBool                      // Bool
+	DynInt                       // Int
+	DynUint                      // Uint
+	DynFloat                     // Float
+	DynString                    // Text
+	DynBytes                     // Bytes
+	DynList                      // List
+	DynMap                       // Map, in insertion order
+)
+
+// Dynamic is a value tree for data whose Go type is not known at compile
+// time. Only the field that matches Kind is meaningful; the zero Dynamic
+// is null. Fields can be read and changed directly to inspect or patch a
+// document.
+type Dynamic struct {
+	Kind  DynamicKind
+	Bool  bool
+	Int   int64
+	Uint  uint64
+	Float float64
+	Text  string
+	Bytes []byte
+	List  []Dynamic
+	Map   []DynamicEntry
+}
+
+// DynamicEntry is a key and its value in a DynMap.
+type DynamicEntry struct {
+	Key   Dynamic
+	Value Dynamic
+}
+
+// Get returns the value stored under the string key of a DynMap.
+func (d Dynamic) Get(key string) (Dynamic, bool) {
+	for _, e := range d.Map {
+		if e.Key.Kind == DynString && e.Key.Text == key {
+			return e.Value, true
+		}
+	}
+	return Dynamic{}, false
+}
+
+// Set stores x under the string key, replacing an existing entry or
+// appending a new one. A null d becomes an empty DynMap first.
+func (d *Dynamic) Set(key string, x Dynamic) {
+	if d.Kind == DynNull {
+		d.Kind = DynMap
+	}
+	for i, e := range d.Map {
+		if e.Key.Kind == DynString && e.Key.Text == key {
+			d.Map[i].Value = x
+			return
+		}
+	}
+	d.Map = append(d.Map, DynamicEntry{Dynamic{Kind: DynString, Text: key}, x})
+}
+
+// CompositeKey stands in for a list, map or bytes key in the map[any]any
+// returned by Interface, since []any, map[string]any and []byte cannot be
+// map keys. It holds the key's canonical text, such as `[1,2]` or
+// `{"X":1}`, so equal keys map to the same entry.
+type CompositeKey string
+
+// Interface returns d as plain Go values: nil, bool, int64, uint64,
+// float64, string, []byte, []any, and map[string]any, or map[any]any when
+// some key is not a string. Keys that are not hashable become a
+// CompositeKey.
+func (d Dynamic) Interface() any {
+	switch d.Kind {
+	case DynBool:
+		return d.Bool
+	case DynInt:
+		return d.Int
+	case DynUint:
+		return d.Uint
+	case DynFloat:
+		return d.Float
+	case DynString:
+		return d.Text
+	case DynBytes:
+		return d.Bytes
+	case DynList:
+		list := make([]any, len(d.List))
+		for i, e := range d.List {
+			list[i] = e.Interface()
+		}
+		return list
+	case DynMap:
+		textKeys := true
+		for _, e := range d.Map {
+			textKeys = textKeys && e.Key.Kind == DynString
+		}
+		if textKeys {
+			m := make(map[string]any, len(d.Map))
+			for _, e := range d.Map {
+				m[e.Key.Text] = e.Value.Interface()
+			}
+			return m
+		}
+		m := make(map[any]any, len(d.Map))
+		for _, e := range d.Map {
+			m[e.Key.keyInterface()] = e.Value.Interface()
+		}
+		return m
+	}
+	return nil
+}
+
+// keyInterface returns d as a map key: the Interface value for scalars and
+// a CompositeKey for lists, maps and bytes.
+func (d Dynamic) keyInterface() any {
+	switch d.Kind {
+	case DynList, DynMap, DynBytes:
+		return CompositeKey(d.appendText(nil))
+	}
+	return d.Interface()
+}
+
+// appendText appends the canonical text of d: JSON-like, with strings and
+// bytes quoted and map entries in their stored order.
+func (d Dynamic) appendText(dst []byte) []byte {
+	switch d.Kind {
+	case DynBool:
+		if d.Bool {
+			return append(dst, "true"...)
+		}
+		return append(dst, "false"...)
+	case DynInt:
+		return num.AppendInt(dst, d.Int)
+	case DynUint:
+		return num.AppendUint(dst, d.Uint)
+	case DynFloat:
+		return num.AppendFloat(dst, d.Float, 64)
+	case DynString:
+		return append(dst, Convert(d.Text).Quote().String()...)
+	case DynBytes:
+		return append(dst, Convert(string(d.Bytes)).Quote().String()...)
+	case DynList:
+		dst = append(dst, '[')
+		for i, e := range d.List {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = e.appendText(dst)
+		}
+		return append(dst, ']')
+	case DynMap:
+		dst = append(dst, '{')
+		for i, e := range d.Map {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = e.Key.appendText(dst)
+			dst = append(dst, ':')
+			dst = e.Value.appendText(dst)
+		}
+		return append(dst, '}')
+	}
+	return append(dst, "null"...)
+}
+
+// FromValue converts v to a Dynamic. Signed integers become DynInt,
+// unsigned ones DynUint and floats DynFloat; []byte becomes DynBytes and
+// other slices and arrays DynList. Structs become a DynMap of their
+// exported fields by name in declaration order, and maps a DynMap sorted
+// by key. Nil pointers, slices, maps and interfaces are null, and so are
+// kinds without a data form such as channels and functions.
+func FromValue(v Value) Dynamic {
+	return fromValue(v, "", 0)
+}
+
+// fromValue converts v, keying struct fields by the tagKey tag name or,
+// when it is empty or missing, by Go name.
+func fromValue(v Value, tagKey string, depth int) Dynamic {
+	if depth > maxMapDepth {
+		return Dynamic{}
+	}
+	switch k := v.Kind(); {
+	case k == K.Bool:
+		b, _ := v.Bool()
+		return Dynamic{Kind: DynBool, Bool: b}
+	case isIntKind(k):
+		i, _ := v.Int()
+		return Dynamic{Kind: DynInt, Int: i}
+	case isUintKind(k):
+		u, _ := v.Uint()
+		return Dynamic{Kind: DynUint, Uint: u}
+	case k == K.Float32 || k == K.Float64:
+		f, _ := v.Float()
+		return Dynamic{Kind: DynFloat, Float: f}
+	case k == K.String:
+		return Dynamic{Kind: DynString, Text: v.String()}
+
+	case k == K.Pointer || k == K.Interface:
+		if v.IsZero() {
+			return Dynamic{}
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return Dynamic{}
+		}
+		return fromValue(elem, tagKey, depth+1)
+
+	case k == K.Slice || k == K.Array:
+		if k == K.Slice && v.IsZero() {
+			return Dynamic{}
+		}
+		n, _ := v.Len()
+		if k == K.Slice && v.Type().Elem().Kind() == K.Uint8 {
+			b := make([]byte, n)
+			if n > 0 {
+				p, _ := v.UnsafePointer()
+				copy(b, unsafe.Slice((*byte)(p), n))
+			}
+			return Dynamic{Kind: DynBytes, Bytes: b}
+		}
+		d := Dynamic{Kind: DynList, List: make([]Dynamic, n)}
+		for i := range d.List {
+			elem, err := v.Index(i)
+			if err == nil {
+				d.List[i] = fromValue(elem, tagKey, depth+1)
+			}
+		}
+		return d
+
+	case k == K.Map:
+		if v.IsZero() {
+			return Dynamic{}
+		}
+		n, _ := v.Len()
+		d := Dynamic{Kind: DynMap, Map: make([]DynamicEntry, 0, n)}
+		iter, err := v.MapRange()
+		if err != nil {
+			return Dynamic{}
+		}
+		for iter.Next() {
+			d.Map = append(d.Map, DynamicEntry{fromValue(iter.Key(), tagKey, depth+1), fromValue(iter.Value(), tagKey, depth+1)})
+		}
+		slices.SortFunc(d.Map, func(a, b DynamicEntry) int {
+			return compareDynamic(a.Key, b.Key)
+		})
+		return d
+
+	case k == K.Struct:
+		fields, err := mapFields(v.Type(), tagKey)
+		if err != nil {
+			return Dynamic{}
+		}
+		d := Dynamic{Kind: DynMap, Map: make([]DynamicEntry, 0, len(fields))}
+		for _, f := range fields {
+			fv, err := v.Field(f.index)
+			if err != nil {
+				continue
+			}
+			d.Map = append(d.Map, DynamicEntry{Dynamic{Kind: DynString, Text: f.key}, fromValue(fv, tagKey, depth+1)})
+		}
+		return d
+	}
+	return Dynamic{}
+}
+
+// compareDynamic orders map keys, first by kind and then by value. Bytes
+// compare bytewise, and lists and maps element by element with the shorter
+// one first when one is a prefix of the other, so any two keys have a
+// fixed order.
+func compareDynamic(a, b Dynamic) int {
+	if a.Kind != b.Kind {
+		return cmp.Compare(a.Kind, b.Kind)
+	}
+	switch a.Kind {
+	case DynBool:
+		return cmp.Compare(boolInt(a.Bool), boolInt(b.Bool))
+	case DynInt:
+		return cmp.Compare(a.Int, b.Int)
+	case DynUint:
+		return cmp.Compare(a.Uint, b.Uint)
+	case DynFloat:
+		return cmp.Compare(a.Float, b.Float)
+	case DynString:
+		return cmp.Compare(a.Text, b.Text)
+	case DynBytes:
+		return slices.Compare(a.Bytes, b.Bytes)
+	case DynList:
+		return slices.CompareFunc(a.List, b.List, compareDynamic)
+	case DynMap:
+		return slices.CompareFunc(a.Map, b.Map, func(x, y DynamicEntry) int {
+			if c := compareDynamic(x.Key, y.Key); c != 0 {
+				return c
+			}
+			return compareDynamic(x.Value, y.Value)
+		})
+	}
+	return 0
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// AssignTo stores d in v, which must be settable, converting it to v's
+// type. Numbers convert between kinds when they fit (ErrOverflow
+// otherwise, or ErrTypeMismatch for a fraction into an integer), a DynMap
+// fills structs by field name and maps by key, a DynList fills slices and
+// arrays, nil pointers are allocated and null sets v to zero. Interfaces
+// receive d.Interface(). Entries that match no struct field are ignored.
+func (d Dynamic) AssignTo(v Value) error {
+	return d.assignTo(v, "", 0)
+}
+
+// assignTo assigns d to v, matching struct fields by the tagKey tag name
+// or, when it is empty or missing, by Go name.
+func (d Dynamic) assignTo(v Value, tagKey string, depth int) error {
+	if depth > maxMapDepth {
+		return newValueError("AssignTo", v.Kind(), ErrInvalidArgument)
+	}
+	if d.Kind == DynNull {
+		return v.SetZero()
+	}
+	k := v.Kind()
+	if err := v.mustBeAssignable("AssignTo"); err != nil {
+		return err
+	}
+
+	switch {
+	case k == K.Pointer:
+		if v.IsZero() {
+			if err := v.Set(NewValue(v.Type().Elem())); err != nil {
+				return err
+			}
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return err
+		}
+		return d.assignTo(elem, tagKey, depth+1)
+
+	case k == K.Interface:
+		return v.Set(ValueOf(d.Interface()))
+
+	case k == K.Bool:
+		if d.Kind == DynBool {
+			return v.SetBool(d.Bool)
+		}
+
+	case isIntKind(k), isUintKind(k), k == K.Float32 || k == K.Float64:
+		var x Value
+		switch d.Kind {
+		case DynInt:
+			x = ValueOf(d.Int)
+		case DynUint:
+			x = ValueOf(d.Uint)
+		case DynFloat:
+			x = ValueOf(d.Float)
+		default:
+			return newValueError("AssignTo", k, ErrTypeMismatch)
+		}
+		return assignNumber(v, x)
+
+	case k == K.String:
+		if d.Kind == DynString {
+			return v.SetString(d.Text)
+		}
+
+	case k == K.Slice && v.Type().Elem().Kind() == K.Uint8 && (d.Kind == DynBytes || d.Kind == DynString):
+		b := d.Bytes
+		if d.Kind == DynString {
+			b = []byte(d.Text)
+		}
+		s, err := MakeSlice(v.Type(), len(b), len(b))
+		if err != nil {
+			return err
+		}
+		if len(b) > 0 {
+			p, _ := s.UnsafePointer()
+			copy(unsafe.Slice((*byte)(p), len(b)), b)
+		}
+		return v.Set(s)
+
+	case k == K.Slice:
+		if d.Kind != DynList {
+			break
+		}
+		s, err := MakeSlice(v.Type(), len(d.List), len(d.List))
+		if err != nil {
+			return err
+		}
+		if err := assignElems(s, d.List, tagKey, depth); err != nil {
+			return err
+		}
+		return v.Set(s)
+
+	case k == K.Array:
+		if d.Kind != DynList {
+			break
+		}
+		if n, _ := v.Len(); len(d.List) > n {
+			return newValueError("AssignTo", k, ErrOutOfRange)
+		}
+		if err := v.SetZero(); err != nil {
+			return err
+		}
+		return assignElems(v, d.List, tagKey, depth)
+
+	case k == K.Map:
+		if d.Kind != DynMap {
+			break
+		}
+		return d.assignMap(v, tagKey, depth)
+
+	case k == K.Struct:
+		if d.Kind != DynMap {
+			break
+		}
+		fields, err := mapFields(v.Type(), tagKey)
+		if err != nil {
+			return err
+		}
+		for _, e := range d.Map {
+			if e.Key.Kind != DynString {
+				continue
+			}
+			for _, f := range fields {
+				if f.key != e.Key.Text {
+					continue
+				}
+				fv, err := v.Field(f.index)
+				if err != nil {
+					return withPath(err, f.key)
+				}
+				if err := e.Value.assignTo(fv, tagKey, depth+1); err != nil {
+					return withPath(err, f.key)
+				}
+				break
+			}
+		}
+		return nil
+	}
+	return newValueError("AssignTo", k, ErrTypeMismatch)
+}
+
+// assignNumber stores the number x in the numeric value v with range checks.
+func assignNumber(v, x Value) error {
+	switch k := v.Kind(); {
+	case isIntKind(k):
+		i, err := toInt64("AssignTo", x)
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(i) {
+			return newValueError("AssignTo", k, ErrOverflow)
+		}
+		return v.SetInt(i)
+	case isUintKind(k):
+		u, err := toUint64("AssignTo", x)
+		if err != nil {
+			return err
+		}
+		if v.OverflowUint(u) {
+			return newValueError("AssignTo", k, ErrOverflow)
+		}
+		return v.SetUint(u)
+	}
+	f, _ := toFloat64(x)
+	if v.OverflowFloat(f) {
+		return newValueError("AssignTo", v.Kind(), ErrOverflow)
+	}
+	return v.SetFloat(f)
+}
+
+// assignElems assigns list to the first elements of the slice or array v.
+func assignElems(v Value, list []Dynamic, tagKey string, depth int) error {
+	for i, e := range list {
+		elem, err := v.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := e.assignTo(elem, tagKey, depth+1); err != nil {
+			return withPath(err, indexSegment(i))
+		}
+	}
+	return nil
+}
+
+// assignMap replaces the map v with the converted entries of d.
+func (d Dynamic) assignMap(v Value, tagKey string, depth int) error {
+	typ := v.Type()
+	m, err := MakeMapWithSize(typ, len(d.Map))
+	if err != nil {
+		return err
+	}
+	for i, e := range d.Map {
+		key, err := NewValue(typ.Key()).Elem()
+		if err != nil {
+			return err
+		}
+		if err := e.Key.assignTo(key, tagKey, depth+1); err != nil {
+			return withPath(err, indexSegment(i))
+		}
+		elem, err := NewValue(typ.Elem()).Elem()
+		if err != nil {
+			return err
+		}
+		if err := e.Value.assignTo(elem, tagKey, depth+1); err != nil {
+			if e.Key.Kind == DynString {
+				return withPath(err, e.Key.Text)
+			}
+			return withPath(err, indexSegment(i))
+		}
+		if err := m.SetMapIndex(key, elem); err != nil {
+			return err
+		}
+	}
+	return v.Set(m)
+}
@@ -0,0 +1,223 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+type dynPoint struct {
+	X, Y int16
+}
+
+type dynDoc struct {
+	Name   string
+	Count  uint8
+	Ratio  float32
+	Ok     bool
+	Raw    []byte
+	Tags   []string
+	Grid   [2]int
+	Home   *dynPoint
+	Path   []dynPoint
+	Scores map[string]int64
+	Codes  map[int]string
+	Any    any
+	hidden int
+}
+
+func TestFromValue(t *testing.T) {
+	doc := dynDoc{
+		Name: "a", Count: 200, Ratio: 0.5, Ok: true, Raw: []byte{1},
+		Grid:   [2]int{-1, 2},
+		Home:   &dynPoint{1, 2},
+		Scores: map[string]int64{"b": 2, "a": 1},
+		Codes:  map[int]string{10: "x", -5: "y"},
+		Any:    []any{nil, "z"},
+	}
+	got := tinyreflect.FromValue(tinyreflect.ValueOf(doc))
+
+	str := func(s string) tinyreflect.Dynamic { return tinyreflect.Dynamic{Kind: tinyreflect.DynString, Text: s} }
+	i64 := func(i int64) tinyreflect.Dynamic { return tinyreflect.Dynamic{Kind: tinyreflect.DynInt, Int: i} }
+	want := tinyreflect.Dynamic{Kind: tinyreflect.DynMap, Map: []tinyreflect.DynamicEntry{
+		{str("Name"), str("a")},
+		{str("Count"), tinyreflect.Dynamic{Kind: tinyreflect.DynUint, Uint: 200}},
+		{str("Ratio"), tinyreflect.Dynamic{Kind: tinyreflect.DynFloat, Float: 0.5}},
+		{str("Ok"), tinyreflect.Dynamic{Kind: tinyreflect.DynBool, Bool: true}},
+		{str("Raw"), tinyreflect.Dynamic{Kind: tinyreflect.DynBytes, Bytes: []byte{1}}},
+		{str("Tags"), tinyreflect.Dynamic{}},
+		{str("Grid"), tinyreflect.Dynamic{Kind: tinyreflect.DynList, List: []tinyreflect.Dynamic{i64(-1), i64(2)}}},
+		{str("Home"), tinyreflect.Dynamic{Kind: tinyreflect.DynMap, Map: []tinyreflect.DynamicEntry{{str("X"), i64(1)}, {str("Y"), i64(2)}}}},
+		{str("Path"), tinyreflect.Dynamic{}},
+		{str("Scores"), tinyreflect.Dynamic{Kind: tinyreflect.DynMap, Map: []tinyreflect.DynamicEntry{{str("a"), i64(1)}, {str("b"), i64(2)}}}},
+		{str("Codes"), tinyreflect.Dynamic{Kind: tinyreflect.DynMap, Map: []tinyreflect.DynamicEntry{{i64(-5), str("y")}, {i64(10), str("x")}}}},
+		{str("Any"), tinyreflect.Dynamic{Kind: tinyreflect.DynList, List: []tinyreflect.Dynamic{{}, str("z")}}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got  %+v\nwant %+v", got, want)
+	}
+
+	if v, ok := got.Get("Home"); !ok || v.Kind != tinyreflect.DynMap {
+		t.Errorf("Get(Home) = %+v, %v", v, ok)
+	}
+	if _, ok := got.Get("hidden"); ok {
+		t.Error("unexported field converted")
+	}
+	wantAny := map[string]any{"a": int64(1), "b": int64(2)}
+	if s, _ := got.Get("Scores"); !reflect.DeepEqual(s.Interface(), wantAny) {
+		t.Errorf("Interface() = %#v, want %#v", s.Interface(), wantAny)
+	}
+	if c, _ := got.Get("Codes"); !reflect.DeepEqual(c.Interface(), map[any]any{int64(-5): "y", int64(10): "x"}) {
+		t.Errorf("Interface() = %#v", c.Interface())
+	}
+}
+
+func TestDynamicAssignTo(t *testing.T) {
+	doc := dynDoc{
+		Name: "a", Count: 200, Ratio: 0.5, Ok: true, Raw: []byte{1},
+		Tags:   []string{"x"},
+		Grid:   [2]int{-1, 2},
+		Home:   &dynPoint{1, 2},
+		Path:   []dynPoint{{3, 4}},
+		Scores: map[string]int64{"b": 2, "a": 1},
+		Codes:  map[int]string{10: "x"},
+		Any:    []any{nil, "z"},
+	}
+	d := tinyreflect.FromValue(tinyreflect.ValueOf(&doc))
+
+	// Patch the document before assigning it back
+	d.Set("Name", tinyreflect.Dynamic{Kind: tinyreflect.DynString, Text: "patched"})
+	d.Set("Unknown", tinyreflect.Dynamic{Kind: tinyreflect.DynBool, Bool: true})
+
+	var got dynDoc
+	if err := d.AssignTo(tinyreflect.Indirect(tinyreflect.ValueOf(&got))); err != nil {
+		t.Fatalf("AssignTo: %v", err)
+	}
+	doc.Name = "patched"
+	if !reflect.DeepEqual(got, doc) {
+		t.Errorf("got  %+v\nwant %+v", got, doc)
+	}
+
+	// Numbers convert between kinds
+	type Numbers struct {
+		I8  int8
+		U16 uint16
+		F32 float32
+		F64 float64
+		Ptr *int
+	}
+	var n Numbers
+	nd := tinyreflect.Dynamic{}
+	nd.Set("I8", tinyreflect.Dynamic{Kind: tinyreflect.DynFloat, Float: -128})
+	nd.Set("U16", tinyreflect.Dynamic{Kind: tinyreflect.DynInt, Int: 65535})
+	nd.Set("F32", tinyreflect.Dynamic{Kind: tinyreflect.DynUint, Uint: 3})
+	nd.Set("F64", tinyreflect.Dynamic{Kind: tinyreflect.DynInt, Int: -1})
+	nd.Set("Ptr", tinyreflect.Dynamic{Kind: tinyreflect.DynUint, Uint: 7})
+	if err := nd.AssignTo(tinyreflect.Indirect(tinyreflect.ValueOf(&n))); err != nil {
+		t.Fatalf("AssignTo numbers: %v", err)
+	}
+	if n.I8 != -128 || n.U16 != 65535 || n.F32 != 3 || n.F64 != -1 || n.Ptr == nil || *n.Ptr != 7 {
+		t.Errorf("got %+v", n)
+	}
+}
+
+func TestDynamicAssignToErrors(t *testing.T) {
+	type Target struct {
+		I8   int8
+		U32  uint32
+		F32  float32
+		Name string
+		Grid [1]int
+		List []dynPoint
+	}
+	field := func(name string, x tinyreflect.Dynamic) tinyreflect.Dynamic {
+		var d tinyreflect.Dynamic
+		d.Set(name, x)
+		return d
+	}
+	list := func(items ...tinyreflect.Dynamic) tinyreflect.Dynamic {
+		return tinyreflect.Dynamic{Kind: tinyreflect.DynList, List: items}
+	}
+	point := field("X", tinyreflect.Dynamic{Kind: tinyreflect.DynInt, Int: math.MaxInt16 + 1})
+
+	testCases := []struct {
+		name    string
+		d       tinyreflect.Dynamic
+		wantErr error
+		path    string
+	}{
+		{"Int8 overflow", field("I8", tinyreflect.Dynamic{Kind: tinyreflect.DynInt, Int: 128}), tinyreflect.ErrOverflow, "I8"},
+		{"Negative uint", field("U32", tinyreflect.Dynamic{Kind: tinyreflect.DynInt, Int: -1}), tinyreflect.ErrOverflow, "U32"},
+		{"Fraction", field("I8", tinyreflect.Dynamic{Kind: tinyreflect.DynFloat, Float: 0.5}), tinyreflect.ErrTypeMismatch, "I8"},
+		{"Float32 overflow", field("F32", tinyreflect.Dynamic{Kind: tinyreflect.DynFloat, Float: 1e39}), tinyreflect.ErrOverflow, "F32"},
+		{"String into int", field("I8", tinyreflect.Dynamic{Kind: tinyreflect.DynString, Text: "1"}), tinyreflect.ErrTypeMismatch, "I8"},
+		{"Bool into string", field("Name", tinyreflect.Dynamic{Kind: tinyreflect.DynBool}), tinyreflect.ErrTypeMismatch, "Name"},
+		{"Array too long", field("Grid", list(tinyreflect.Dynamic{}, tinyreflect.Dynamic{})), tinyreflect.ErrOutOfRange, "Grid"},
+		{"Nested path", field("List", list(tinyreflect.Dynamic{}, point)), tinyreflect.ErrOverflow, "List[1].X"},
+		{"List into struct", list(), tinyreflect.ErrTypeMismatch, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got Target
+			err := tc.d.AssignTo(tinyreflect.Indirect(tinyreflect.ValueOf(&got)))
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got %v, want %v", err, tc.wantErr)
+			}
+			var ve *tinyreflect.ValueError
+			if !errors.As(err, &ve) || ve.Path != tc.path {
+				t.Errorf("got path %q, want %q", ve.Path, tc.path)
+			}
+		})
+	}
+
+	d := tinyreflect.Dynamic{Kind: tinyreflect.DynInt, Int: 1}
+	if err := d.AssignTo(tinyreflect.ValueOf(1)); !errors.Is(err, tinyreflect.ErrNotAssignable) {
+		t.Errorf("unaddressable: got %v, want ErrNotAssignable", err)
+	}
+}
+
+func TestDynamicCompositeKeys(t *testing.T) {
+	grid := map[[2]int]string{{2, 1}: "b", {1, 9}: "a", {1, 2}: "c"}
+	points := map[dynPoint]int{{X: 2}: 1, {X: 1, Y: 5}: 2}
+
+	d := tinyreflect.FromValue(tinyreflect.ValueOf(grid))
+	var keys []any
+	for _, e := range d.Map {
+		keys = append(keys, e.Key.Interface())
+	}
+	if want := []any{[]any{int64(1), int64(2)}, []any{int64(1), int64(9)}, []any{int64(2), int64(1)}}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("key order %v, want %v", keys, want)
+	}
+	want := map[any]any{
+		tinyreflect.CompositeKey("[1,2]"): "c",
+		tinyreflect.CompositeKey("[1,9]"): "a",
+		tinyreflect.CompositeKey("[2,1]"): "b",
+	}
+	if got := d.Interface(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Interface() = %#v, want %#v", got, want)
+	}
+
+	p := tinyreflect.FromValue(tinyreflect.ValueOf(points))
+	if k := p.Map[0].Key; !reflect.DeepEqual(k.Interface(), map[string]any{"X": int64(1), "Y": int64(5)}) {
+		t.Errorf("first key %#v", k.Interface())
+	}
+	var target any
+	if err := p.AssignTo(tinyreflect.Indirect(tinyreflect.ValueOf(&target))); err != nil {
+		t.Fatalf("AssignTo any: %v", err)
+	}
+	wantPoints := map[any]any{
+		tinyreflect.CompositeKey(`{"X":1,"Y":5}`): int64(2),
+		tinyreflect.CompositeKey(`{"X":2,"Y":0}`): int64(1),
+	}
+	if !reflect.DeepEqual(target, wantPoints) {
+		t.Errorf("got %#v, want %#v", target, wantPoints)
+	}
+
+	var back map[[2]int]string
+	if err := d.AssignTo(tinyreflect.Indirect(tinyreflect.ValueOf(&back))); err != nil || !reflect.DeepEqual(back, grid) {
+		t.Errorf("round trip = %v, %v", back, err)
+	}
+}
@@ -0,0 +1,181 @@
+package tinyreflect
+
+import . "github.com/cdvelop/tinystring"
+
+// BindEnv sets the fields of the struct ptr points to from environment
+// variables, read through lookup: os.LookupEnv for the process
+// environment, or a fake in tests. Taking lookup as an argument keeps os
+// out of programs that never read the environment.
+//
+// A field reads the variable named in its env tag, as in `env:"DB_PORT"`,
+// or else its Go name in UPPER_SNAKE case, so MaxConns reads MAX_CONNS; a
+// non-empty prefix is joined with "_", giving APP_DB_PORT for prefix
+// "APP". `env:"-"` leaves a field out. Fields of a nested struct add the
+// struct field's name to the prefix, and those of an embedded struct do
+// not. A nil pointer to a struct is allocated only when one of its
+// variables is set, and its required variables are checked only then.
+//
+// Values are parsed into the field's kind as ApplyDefaults does, slices
+// taking a comma-separated list. Fields whose variable is not set keep
+// their value, so defaults can be applied first. A value that does not
+// parse or fit is a *ValueError with the field path. Variables tagged
+// `env:"NAME,required"` that are not set are all reported together in
+// ValidationErrors, each Violation wrapping ErrRequired with the variable
+// name as its path.
+func BindEnv(ptr any, prefix string, lookup func(key string) (string, bool)) error {
+	v := ValueOf(ptr)
+	if v.Kind() != K.Pointer || v.IsZero() || lookup == nil {
+		return newValueError("BindEnv", v.Kind(), ErrInvalidArgument)
+	}
+	elem, err := v.Elem()
+	if err != nil {
+		return err
+	}
+	if elem.Kind() != K.Struct {
+		return newValueError("BindEnv", elem.Kind(), ErrNotStruct)
+	}
+	e := envBinding{lookup: lookup}
+	if _, err := e.bind(elem, prefix, 0); err != nil {
+		return err
+	}
+	if len(e.missing) > 0 {
+		return e.missing
+	}
+	return nil
+}
+
+type envBinding struct {
+	lookup  func(string) (string, bool)
+	missing ValidationErrors
+}
+
+// bind sets the fields of the struct v and reports whether any variable
+// was found.
+func (e *envBinding) bind(v Value, prefix string, depth int) (bool, error) {
+	if depth > maxMapDepth {
+		return false, newValueError("BindEnv", v.Kind(), ErrInvalidArgument)
+	}
+	t := v.Type()
+	n, err := t.NumField()
+	if err != nil {
+		return false, err
+	}
+	found := false
+	for i := 0; i < n; i++ {
+		sf, err := t.Field(i)
+		if err != nil {
+			return false, err
+		}
+		fv, err := v.Field(i)
+		if err != nil {
+			return false, err
+		}
+		// The fields of an unexported embedded struct are still promoted
+		if !sf.IsExported() && !(sf.Embedded() && fv.Kind() == K.Struct) {
+			continue
+		}
+		name, opts := ParseTag(sf.Tag().Get("env"))
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = caseWords(sf.Name.String(), '_', true)
+		}
+		key := joinName(prefix, name, '_')
+
+		ok, err := e.bindField(fv, sf, key, prefix, opts, depth)
+		if err != nil {
+			return false, withPath(err, sf.Name.String())
+		}
+		found = found || ok
+	}
+	return found, nil
+}
+
+func (e *envBinding) bindField(fv Value, sf StructField, key, prefix string, opts TagOptions, depth int) (bool, error) {
+	switch {
+	case fv.Kind() == K.Struct:
+		if sf.Embedded() {
+			key = prefix
+		}
+		return e.bind(fv, key, depth+1)
+
+	case fv.Kind() == K.Pointer && fv.Type().Elem().Kind() == K.Struct:
+		if sf.Embedded() {
+			key = prefix
+		}
+		if !fv.IsZero() {
+			elem, err := fv.Elem()
+			if err != nil {
+				return false, err
+			}
+			return e.bind(elem, key, depth+1)
+		}
+		// A nil struct is optional: its required variables only count
+		// once one of its variables is set
+		p := NewValue(fv.Type().Elem())
+		elem, err := p.Elem()
+		if err != nil {
+			return false, err
+		}
+		missing := len(e.missing)
+		found, err := e.bind(elem, key, depth+1)
+		if err != nil {
+			return false, err
+		}
+		if !found {
+			e.missing = e.missing[:missing]
+			return false, nil
+		}
+		return true, fv.Set(p)
+	}
+
+	s, ok := e.lookup(key)
+	if !ok {
+		if opts.Has("required") {
+			e.missing = append(e.missing, &Violation{Path: key, Rule: "required", Err: ErrRequired})
+		}
+		return false, nil
+	}
+	if fv.Kind() == K.Slice && fv.Type().Elem().Kind() != K.Uint8 {
+		return true, setList("BindEnv", fv, s)
+	}
+	return true, setText("BindEnv", fv, s)
+}
+
+// joinName joins prefix and name with sep; an empty prefix adds nothing.
+func joinName(prefix, name string, sep byte) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + string(sep) + name
+}
+
+// caseWords splits the Go name into words at case changes, as in
+// "HTTPServer" to "HTTP" and "Server", and joins them with sep in upper or
+// lower case.
+func caseWords(name string, sep byte, upper bool) string {
+	out := make([]byte, 0, len(name)+4)
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if isUpperByte(c) && i > 0 {
+			prev := name[i-1]
+			next := i+1 < len(name) && name[i+1] >= 'a' && name[i+1] <= 'z'
+			if prev != sep && (!isUpperByte(prev) || next) {
+				out = append(out, sep)
+			}
+		}
+		switch {
+		case upper && c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		case !upper && isUpperByte(c):
+			c += 'a' - 'A'
+		}
+		out = append(out, c)
+	}
+	return string(out)
+}
+
+func isUpperByte(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
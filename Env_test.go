@@ -0,0 +1,146 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+type envDB struct {
+	Host     string `env:"HOST,required"`
+	Port     uint16
+	Password string `env:"PASSWORD,required"`
+}
+
+type envCommon struct {
+	LogLevel string
+}
+
+type envConfig struct {
+	envCommon
+	Name     string
+	MaxConns int8
+	HTTPAddr string
+	Ratio    float64
+	Debug    bool
+	Hosts    []string
+	Ports    []int
+	Timeout  *int
+	DB       envDB
+	Cache    *envDB `env:"REDIS"`
+	Mirror   *envDB
+	Token    string `env:"API_TOKEN,required"`
+	Skip     string `env:"-"`
+	Kept     string
+	internal string
+}
+
+// fakeEnv is a lookup function over a map.
+func fakeEnv(m map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		s, ok := m[key]
+		return s, ok
+	}
+}
+
+func TestBindEnv(t *testing.T) {
+	env := fakeEnv(map[string]string{
+		"APP_LOG_LEVEL":      "debug",
+		"APP_NAME":           "svc",
+		"APP_MAX_CONNS":      "-8",
+		"APP_HTTP_ADDR":      ":80",
+		"APP_RATIO":          "0.25",
+		"APP_DEBUG":          "true",
+		"APP_HOSTS":          "a,b",
+		"APP_PORTS":          "80,443",
+		"APP_TIMEOUT":        "30",
+		"APP_DB_HOST":        "db",
+		"APP_DB_PORT":        "5432",
+		"APP_DB_PASSWORD":    "pw",
+		"APP_REDIS_HOST":     "cache",
+		"APP_REDIS_PASSWORD": "",
+		"APP_API_TOKEN":      "t",
+		"APP_SKIP":           "x",
+	})
+	cfg := envConfig{Kept: "default"}
+	if err := tinyreflect.BindEnv(&cfg, "APP", env); err != nil {
+		t.Fatalf("BindEnv: %v", err)
+	}
+	timeout := 30
+	want := envConfig{
+		envCommon: envCommon{LogLevel: "debug"},
+		Name:      "svc",
+		MaxConns:  -8,
+		HTTPAddr:  ":80",
+		Ratio:     0.25,
+		Debug:     true,
+		Hosts:     []string{"a", "b"},
+		Ports:     []int{80, 443},
+		Timeout:   &timeout,
+		DB:        envDB{Host: "db", Port: 5432, Password: "pw"},
+		Cache:     &envDB{Host: "cache"},
+		Token:     "t",
+		Kept:      "default",
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got  %+v\nwant %+v", cfg, want)
+	}
+}
+
+func TestBindEnvRequired(t *testing.T) {
+	env := fakeEnv(map[string]string{"DB_HOST": "db"})
+	var cfg envConfig
+	err := tinyreflect.BindEnv(&cfg, "", env)
+	var errs tinyreflect.ValidationErrors
+	if !errors.As(err, &errs) || !errors.Is(err, tinyreflect.ErrRequired) {
+		t.Fatalf("got %v, want ValidationErrors", err)
+	}
+	var got []string
+	for _, v := range errs {
+		got = append(got, v.Path)
+	}
+	if want := []string{"DB_PASSWORD", "API_TOKEN"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missing %q, want %q", got, want)
+	}
+	if cfg.DB.Host != "db" || cfg.Cache != nil {
+		t.Errorf("fields not bound: %+v", cfg)
+	}
+}
+
+func TestBindEnvErrors(t *testing.T) {
+	var ve *tinyreflect.ValueError
+	tests := []struct {
+		name string
+		env  map[string]string
+		err  error
+		path string
+	}{
+		{"overflow", map[string]string{"MAX_CONNS": "200"}, tinyreflect.ErrOverflow, "MaxConns"},
+		{"syntax", map[string]string{"RATIO": "half"}, tinyreflect.ErrTypeMismatch, "Ratio"},
+		{"list item", map[string]string{"PORTS": "80,x"}, tinyreflect.ErrTypeMismatch, "Ports[1]"},
+		{"nested", map[string]string{"DB_PORT": "-1"}, tinyreflect.ErrTypeMismatch, "DB.Port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg envConfig
+			err := tinyreflect.BindEnv(&cfg, "", fakeEnv(tt.env))
+			if !errors.Is(err, tt.err) || !errors.As(err, &ve) || ve.Path != tt.path {
+				t.Errorf("got %v, want %v at %q", err, tt.err, tt.path)
+			}
+		})
+	}
+
+	none := fakeEnv(nil)
+	if err := tinyreflect.BindEnv(envConfig{}, "", none); !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+		t.Errorf("non-pointer: got %v", err)
+	}
+	if err := tinyreflect.BindEnv(&envConfig{}, "", nil); !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+		t.Errorf("nil lookup: got %v", err)
+	}
+	n := 1
+	if err := tinyreflect.BindEnv(&n, "", none); !errors.Is(err, tinyreflect.ErrNotStruct) {
+		t.Errorf("non-struct: got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package tinyreflect
+
+import (
+	"github.com/cdvelop/tinyreflect/internal/vpath"
+	. "github.com/cdvelop/tinystring"
+)
+
+// Error is a sentinel error reported by tinyreflect.
+// Its message is built from tinystring dictionary words each time it is
+// printed, so it follows the current output language.
+// Compare against the exported values with errors.Is.
+type Error struct {
+	words []any
+}
+
+// NewError returns a sentinel error whose message is the translation of
+// words, which are tinystring dictionary entries or plain strings.
+// Packages built on tinyreflect use it to declare their own sentinels.
+func NewError(words ...any) *Error {
+	return &Error{words}
+}
+
+// Error returns the translated message.
+func (e *Error) Error() string {
+	return Translate(e.words...).String()
+}
+
+// Sentinel errors wrapped by ValueError.
+var (
+	// ErrNilValue is returned when a Value or Type is nil or invalid.
+	ErrNilValue = &Error{[]any{D.Value, D.Nil}}
+	// ErrNotStruct is returned when a struct operation is used on another kind.
+	ErrNotStruct = &Error{[]any{D.Type, D.NotOfType, "Struct"}}
+	// ErrOutOfRange is returned when an index or slice bound is out of range.
+	ErrOutOfRange = &Error{[]any{D.Index, D.Out, D.Of, D.Range}}
+	// ErrNotAssignable is returned when setting a value that is not addressable or is read-only.
+	ErrNotAssignable = &Error{[]any{D.Value, D.Not, D.Assignable}}
+	// ErrNotAddressable is returned when taking the address of a value that has none.
+	ErrNotAddressable = &Error{[]any{D.Value, D.Not, "addressable"}}
+	// ErrTypeMismatch is returned when a value of one type is assigned to another.
+	ErrTypeMismatch = &Error{[]any{D.Type, D.Mismatch}}
+	// ErrUnsupportedKind is returned when a method is called on a kind it does not handle.
+	ErrUnsupportedKind = &Error{[]any{D.Type, D.Not, D.Supported}}
+	// ErrInvalidArgument is returned when an argument such as a length or capacity is invalid.
+	ErrInvalidArgument = &Error{[]any{D.Invalid, D.Argument}}
+	// ErrOverflow is returned when a number does not fit in the destination kind.
+	ErrOverflow = &Error{[]any{D.Number, D.Overflow}}
+)
+
+// ValueError describes a failed operation: the method that was called,
+// the kind of the value it was called on and, when known, the field path
+// leading to that value (e.g. "Items[2].Price").
+// Err holds one of the sentinel errors and is reachable through errors.Is.
+type ValueError struct {
+	Method string
+	Kind   Kind
+	Path   string
+	Err    error
+}
+
+// Error returns "path: reflect Method kind: reason" with the reason translated.
+func (e *ValueError) Error() string {
+	msg := ref + " " + e.Method + " " + e.Kind.String()
+	if e.Path != "" {
+		msg = e.Path + ": " + msg
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying sentinel error.
+func (e *ValueError) Unwrap() error {
+	return e.Err
+}
+
+// newValueError returns a ValueError for method called on a value of kind k.
+func newValueError(method string, k Kind, err error) *ValueError {
+	return &ValueError{Method: method, Kind: k, Err: err}
+}
+
+// withPath prefixes the path of a *ValueError with seg, which is a field
+// name or an "[i]" index. Other errors are returned unchanged.
+func withPath(err error, seg string) error {
+	ve, ok := err.(*ValueError)
+	if !ok {
+		return err
+	}
+	ve.Path = vpath.Prefix(seg, ve.Path)
+	return ve
+}
+
+// indexSegment returns the path segment "[i]".
+func indexSegment(i int) string {
+	return vpath.Index(i)
+}
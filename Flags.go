@@ -0,0 +1,302 @@
+package tinyreflect
+
+import . "github.com/cdvelop/tinystring"
+
+var (
+	// ErrUnknownFlag is returned by BindFlags for a flag no field defines.
+	ErrUnknownFlag = &Error{[]any{D.Unknown, "flag"}}
+	// ErrHelp is returned by BindFlags for -h or --help when no field
+	// defines them; print FlagUsage in response.
+	ErrHelp = &Error{[]any{"help", "requested"}}
+)
+
+// BindFlags sets the fields of the struct ptr points to from the
+// command-line arguments args, which exclude the program name, and
+// returns the arguments after the flags. Each field of a supported kind
+// defines a flag: the tag `flag:"port,p"` names it --port with the short
+// form -p, and untagged fields use their Go name in kebab case, so
+// MaxConns is --max-conns. `flag:"-"` leaves a field out and `usage`
+// describes it in FlagUsage. Flags of a nested struct are prefixed with
+// the struct field's flag name and "-", as in --db-port; those of an
+// embedded struct are not, and nil pointers to structs are skipped.
+//
+// Flags take their value as --port=8080 or --port 8080, with one or two
+// dashes. Bool flags need no value, but accept --debug=false. Strings,
+// bools, ints, uints and floats of any width are supported, as are
+// pointers to them, which are allocated, and slices of them, which a
+// repeated flag appends to after the first occurrence replaces the
+// field's value. Parsing stops at the first argument that is not a flag
+// or after "--". Fields whose flag is not given keep their value.
+//
+// A value that does not parse or fit is a *ValueError with the field
+// path, and a missing value ErrInvalidArgument, as are two fields with
+// the same flag name. A flag no field defines is ErrUnknownFlag, except
+// -h and --help, which are ErrHelp.
+func BindFlags(ptr any, args []string) (rest []string, err error) {
+	s, err := defineFlags("BindFlags", ptr)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return args[i+1:], nil
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			return args[i:], nil
+		}
+		name := arg[1:]
+		if name[0] == '-' {
+			name = name[1:]
+		}
+		value, hasValue := "", false
+		for j := 0; j < len(name); j++ {
+			if name[j] == '=' {
+				name, value, hasValue = name[:j], name[j+1:], true
+				break
+			}
+		}
+
+		f := s.byName[name]
+		if f == nil {
+			if name == "h" || name == "help" {
+				return nil, ErrHelp
+			}
+			return nil, &ValueError{Method: "BindFlags", Kind: K.Struct, Path: "-" + name, Err: ErrUnknownFlag}
+		}
+		if !hasValue {
+			if flagKind(f.value.Type()) == K.Bool {
+				value = "true"
+			} else if i+1 < len(args) {
+				i++
+				value = args[i]
+			} else {
+				return nil, &ValueError{Method: "BindFlags", Kind: f.value.Kind(), Path: f.path, Err: ErrInvalidArgument}
+			}
+		}
+		if err := f.set(value); err != nil {
+			return nil, withPath(err, f.path)
+		}
+	}
+	return nil, nil
+}
+
+// FlagUsage returns the help text for the flags BindFlags defines for the
+// struct ptr points to: one line per flag with its names, value type,
+// usage and, when the field is not zero, its current value as the default.
+func FlagUsage(ptr any) (string, error) {
+	s, err := defineFlags("FlagUsage", ptr)
+	if err != nil {
+		return "", err
+	}
+	lefts := make([]string, len(s.flags))
+	width := 0
+	for i, f := range s.flags {
+		left := "      "
+		if f.short != "" {
+			left = "  -" + f.short + ", "
+		}
+		left += "--" + f.long
+		if t := flagKind(f.value.Type()); t != K.Bool {
+			left += " " + t.String()
+		}
+		lefts[i] = left
+		width = max(width, len(left))
+	}
+
+	var out []byte
+	for i, f := range s.flags {
+		out = append(out, lefts[i]...)
+		if f.usage != "" || !f.value.IsZero() {
+			for n := len(lefts[i]); n < width+2; n++ {
+				out = append(out, ' ')
+			}
+		}
+		out = append(out, f.usage...)
+		if !f.value.IsZero() {
+			if f.usage != "" {
+				out = append(out, ' ')
+			}
+			out = append(out, "(default "...)
+			out = appendFlagValue(out, f.value)
+			out = append(out, ')')
+		}
+		out = append(out, '\n')
+	}
+	return string(out), nil
+}
+
+// flagField is a flag defined by a struct field.
+type flagField struct {
+	long, short string
+	usage       string
+	path        string // field path, for errors
+	value       Value
+	given       bool // set once, so a repeated slice flag appends
+}
+
+func (f *flagField) set(s string) error {
+	v := f.value
+	if v.Kind() != K.Slice || v.Type().Elem().Kind() == K.Uint8 {
+		return setText("BindFlags", v, s)
+	}
+	if !f.given {
+		if err := v.SetZero(); err != nil {
+			return err
+		}
+		f.given = true
+	}
+	n, _ := v.Len()
+	elem, err := appendElem(v)
+	if err != nil {
+		return err
+	}
+	return withPath(setText("BindFlags", elem, s), indexSegment(n))
+}
+
+type flagSet struct {
+	method string
+	flags  []*flagField
+	byName map[string]*flagField
+}
+
+// defineFlags lists the flags of the struct ptr points to.
+func defineFlags(method string, ptr any) (*flagSet, error) {
+	v := ValueOf(ptr)
+	if v.Kind() != K.Pointer || v.IsZero() {
+		return nil, newValueError(method, v.Kind(), ErrInvalidArgument)
+	}
+	elem, err := v.Elem()
+	if err != nil {
+		return nil, err
+	}
+	if elem.Kind() != K.Struct {
+		return nil, newValueError(method, elem.Kind(), ErrNotStruct)
+	}
+	s := &flagSet{method: method, byName: make(map[string]*flagField)}
+	return s, s.define(elem, "", "", 0)
+}
+
+func (s *flagSet) define(v Value, prefix, path string, depth int) error {
+	if depth > maxMapDepth {
+		return newValueError(s.method, v.Kind(), ErrInvalidArgument)
+	}
+	t := v.Type()
+	n, err := t.NumField()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		sf, err := t.Field(i)
+		if err != nil {
+			return err
+		}
+		fv, err := v.Field(i)
+		if err != nil {
+			return err
+		}
+		// The fields of an unexported embedded struct are still promoted
+		if !sf.IsExported() && !(sf.Embedded() && fv.Kind() == K.Struct) {
+			continue
+		}
+		tag, ok := sf.Tag().Lookup("flag")
+		long, short := ParseTag(tag)
+		if long == "-" {
+			continue
+		}
+		if long == "" {
+			long = caseWords(sf.Name.String(), '-', false)
+		}
+		long = prefix + long
+		fieldPath := joinPath(path, sf.Name.String())
+
+		if fv.Kind() == K.Pointer && fv.Type().Elem().Kind() == K.Struct {
+			if fv.IsZero() {
+				continue
+			}
+			if fv, err = fv.Elem(); err != nil {
+				return err
+			}
+		}
+		if fv.Kind() == K.Struct {
+			next := long + "-"
+			if sf.Embedded() {
+				next = prefix
+			}
+			if err := s.define(fv, next, fieldPath, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !isFlagKind(flagKind(fv.Type())) {
+			if ok {
+				return &ValueError{Method: s.method, Kind: fv.Kind(), Path: fieldPath, Err: ErrUnsupportedKind}
+			}
+			continue
+		}
+		f := &flagField{long: long, short: string(short), usage: sf.Tag().Get("usage"), path: fieldPath, value: fv}
+		for _, name := range []string{f.long, f.short} {
+			if name == "" {
+				continue
+			}
+			if s.byName[name] != nil {
+				return &ValueError{Method: s.method, Kind: fv.Kind(), Path: fieldPath, Err: ErrInvalidArgument}
+			}
+			s.byName[name] = f
+		}
+		s.flags = append(s.flags, f)
+	}
+	return nil
+}
+
+// flagKind returns the kind a flag of type t parses: that of t, of its
+// pointee or of its elements. []byte is a string.
+func flagKind(t *Type) Kind {
+	switch k := t.Kind(); k {
+	case K.Pointer:
+		return t.Elem().Kind()
+	case K.Slice:
+		if t.Elem().Kind() == K.Uint8 {
+			return K.String
+		}
+		return t.Elem().Kind()
+	default:
+		return k
+	}
+}
+
+func isFlagKind(k Kind) bool {
+	return k == K.String || k == K.Bool || isNumberKind(k)
+}
+
+// appendFlagValue appends the text of v for FlagUsage; slice elements are
+// separated by commas.
+func appendFlagValue(dst []byte, v Value) []byte {
+	switch v.Kind() {
+	case K.Pointer:
+		elem, err := v.Elem()
+		if err != nil {
+			return dst
+		}
+		return appendFlagValue(dst, elem)
+	case K.Slice:
+		if v.Type().Elem().Kind() == K.Uint8 {
+			break
+		}
+		n, _ := v.Len()
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			elem, err := v.Index(i)
+			if err != nil {
+				return dst
+			}
+			dst, _ = formText(dst, elem)
+		}
+		return dst
+	}
+	dst, _ = formText(dst, v)
+	return dst
+}
@@ -0,0 +1,154 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+type flagsDB struct {
+	Host string `usage:"database host"`
+	Port uint16 `flag:"port,P"`
+}
+
+type flagsReplica struct {
+	Host string
+}
+
+type flagsLog struct {
+	Verbose bool `flag:"verbose,v" usage:"log more"`
+}
+
+type flagsConfig struct {
+	flagsLog
+	Port     int      `flag:"port,p" usage:"port to listen on"`
+	MaxConns int8     `usage:"connection limit"`
+	Ratio    float32  `flag:"ratio"`
+	Debug    bool     `flag:"debug,d"`
+	Name     string   `usage:"service name"`
+	Tags     []string `flag:"tag,t" usage:"repeatable"`
+	Timeout  *uint    `usage:"seconds"`
+	Raw      []byte
+	DB       flagsDB `flag:"db"`
+	Replica  *flagsReplica
+	Hidden   string `flag:"-"`
+	Meta     map[string]string
+	internal int
+}
+
+func TestBindFlags(t *testing.T) {
+	cfg := flagsConfig{Tags: []string{"default"}, Name: "app"}
+	rest, err := tinyreflect.BindFlags(&cfg, []string{
+		"-p", "8080",
+		"--max-conns=-3",
+		"-ratio", "0.5",
+		"-d",
+		"-v=false",
+		"--tag", "a", "-t=b",
+		"--timeout", "30",
+		"--raw=xyz",
+		"--db-host", "localhost",
+		"-P", "5432",
+		"serve", "--port", "1",
+	})
+	if err != nil {
+		t.Fatalf("BindFlags: %v", err)
+	}
+	timeout := uint(30)
+	want := flagsConfig{
+		Port:     8080,
+		MaxConns: -3,
+		Ratio:    0.5,
+		Debug:    true,
+		Name:     "app",
+		Tags:     []string{"a", "b"},
+		Timeout:  &timeout,
+		Raw:      []byte("xyz"),
+		DB:       flagsDB{Host: "localhost", Port: 5432},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got  %+v\nwant %+v", cfg, want)
+	}
+	if w := []string{"serve", "--port", "1"}; !reflect.DeepEqual(rest, w) {
+		t.Errorf("rest %q, want %q", rest, w)
+	}
+
+	// "--" ends the flags, and nested pointers are bound when set
+	cfg = flagsConfig{Replica: &flagsReplica{}}
+	rest, err = tinyreflect.BindFlags(&cfg, []string{"--replica-host", "r", "--verbose", "--", "-p"})
+	if err != nil || cfg.Replica.Host != "r" || !cfg.Verbose || !reflect.DeepEqual(rest, []string{"-p"}) {
+		t.Errorf("got %+v %q %v", cfg, rest, err)
+	}
+}
+
+func TestBindFlagsErrors(t *testing.T) {
+	var ve *tinyreflect.ValueError
+	tests := []struct {
+		name string
+		args []string
+		err  error
+		path string
+	}{
+		{"unknown", []string{"--nope"}, tinyreflect.ErrUnknownFlag, "-nope"},
+		{"missing value", []string{"--port"}, tinyreflect.ErrInvalidArgument, "Port"},
+		{"syntax", []string{"-p", "x"}, tinyreflect.ErrTypeMismatch, "Port"},
+		{"overflow", []string{"--max-conns", "300"}, tinyreflect.ErrOverflow, "MaxConns"},
+		{"bool", []string{"--debug=maybe"}, tinyreflect.ErrTypeMismatch, "Debug"},
+		{"repeated", []string{"-t", "a", "--db-port", "-1"}, tinyreflect.ErrTypeMismatch, "DB.Port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg flagsConfig
+			_, err := tinyreflect.BindFlags(&cfg, tt.args)
+			if !errors.Is(err, tt.err) || !errors.As(err, &ve) || ve.Path != tt.path {
+				t.Errorf("got %v, want %v at %q", err, tt.err, tt.path)
+			}
+		})
+	}
+
+	var cfg flagsConfig
+	if _, err := tinyreflect.BindFlags(&cfg, []string{"-h"}); err != tinyreflect.ErrHelp {
+		t.Errorf("-h: got %v, want ErrHelp", err)
+	}
+	dup := struct {
+		A int `flag:"x"`
+		B int `flag:"y,x"`
+	}{}
+	if _, err := tinyreflect.BindFlags(&dup, nil); !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+		t.Errorf("duplicate name: got %v", err)
+	}
+	bad := struct {
+		M map[string]int `flag:"m"`
+	}{}
+	if _, err := tinyreflect.BindFlags(&bad, nil); !errors.Is(err, tinyreflect.ErrUnsupportedKind) {
+		t.Errorf("tagged map: got %v", err)
+	}
+	if _, err := tinyreflect.BindFlags(cfg, nil); !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+		t.Errorf("non-pointer: got %v", err)
+	}
+}
+
+func TestFlagUsage(t *testing.T) {
+	cfg := flagsConfig{Port: 8080, Tags: []string{"a", "b"}, DB: flagsDB{Host: "db"}}
+	got, err := tinyreflect.FlagUsage(&cfg)
+	if err != nil {
+		t.Fatalf("FlagUsage: %v", err)
+	}
+	want := "" +
+		"  -v, --verbose         log more\n" +
+		"  -p, --port int        port to listen on (default 8080)\n" +
+		"      --max-conns int8  connection limit\n" +
+		"      --ratio float32\n" +
+		"  -d, --debug\n" +
+		"      --name string     service name\n" +
+		"  -t, --tag string      repeatable (default a,b)\n" +
+		"      --timeout uint    seconds\n" +
+		"      --raw string\n" +
+		"      --db-host string  database host (default db)\n" +
+		"  -P, --db-port uint16\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
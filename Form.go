@@ -0,0 +1,454 @@
+package tinyreflect
+
+import (
+	"unsafe"
+
+	"github.com/cdvelop/tinyreflect/internal/num"
+	. "github.com/cdvelop/tinystring"
+)
+
+// ErrInvalidEscape is returned by DecodeForm for a malformed %XX escape.
+var ErrInvalidEscape = &Error{[]any{D.Invalid, D.Character}}
+
+// EncodeForm returns the struct v, or the struct v points to, as an
+// application/x-www-form-urlencoded string ("name=Ana&tags=a&tags=b").
+//
+// Keys are the names in `form:"name"` tags, or the field names when the
+// tag is absent. Fields tagged "-" are left out, and so are zero fields
+// tagged omitempty. Nested structs add their keys after a dot ("home.city"),
+// each element of a slice or array of scalars is written as a repeated key,
+// and nil pointers are skipped. Maps, slices of structs and other kinds
+// without a text form are left out.
+func EncodeForm(v any) string {
+	rv := ValueOf(v)
+	if rv.Kind() == K.Pointer {
+		if rv.IsZero() {
+			return ""
+		}
+		rv, _ = rv.Elem()
+	}
+	if rv.Kind() != K.Struct {
+		return ""
+	}
+	return string(appendForm(nil, rv, "", 0))
+}
+
+// appendForm appends the fields of the struct v with their keys prefixed.
+func appendForm(dst []byte, v Value, prefix string, depth int) []byte {
+	if depth > maxMapDepth {
+		return dst
+	}
+	fields, err := mapFields(v.Type(), "form")
+	if err != nil {
+		return dst
+	}
+	for _, f := range fields {
+		fv, err := v.Field(f.index)
+		if err != nil || f.omitEmpty && isEmptyField(fv) {
+			continue
+		}
+		dst = appendFormValue(dst, fv, prefix+f.key, depth+1)
+	}
+	return dst
+}
+
+// appendFormValue appends v under key: structs as nested keys, slices and
+// arrays as repeated keys and scalars as a single pair.
+func appendFormValue(dst []byte, v Value, key string, depth int) []byte {
+	for v.Kind() == K.Pointer || v.Kind() == K.Interface {
+		if v.IsZero() {
+			return dst
+		}
+		v, _ = v.Elem()
+	}
+	switch v.Kind() {
+	case K.Struct:
+		return appendForm(dst, v, key+".", depth)
+
+	case K.Slice, K.Array:
+		if v.Type().Elem().Kind() == K.Uint8 && v.Kind() == K.Slice {
+			break
+		}
+		n, _ := v.Len()
+		for i := 0; i < n; i++ {
+			elem, err := v.Index(i)
+			if err != nil {
+				return dst
+			}
+			for elem.Kind() == K.Pointer && !elem.IsZero() {
+				elem, _ = elem.Elem()
+			}
+			if text, ok := formText(nil, elem); ok {
+				dst = appendPair(dst, key, text)
+			}
+		}
+		return dst
+	}
+	if text, ok := formText(nil, v); ok {
+		dst = appendPair(dst, key, text)
+	}
+	return dst
+}
+
+// formText appends the text form of the scalar v: decimal numbers,
+// "true" or "false", and strings and []byte as they are.
+func formText(dst []byte, v Value) ([]byte, bool) {
+	switch k := v.Kind(); {
+	case k == K.String:
+		return append(dst, v.String()...), true
+	case k == K.Bool:
+		b, _ := v.Bool()
+		if b {
+			return append(dst, "true"...), true
+		}
+		return append(dst, "false"...), true
+	case isIntKind(k):
+		i, _ := v.Int()
+		return num.AppendInt(dst, i), true
+	case isUintKind(k):
+		u, _ := v.Uint()
+		return num.AppendUint(dst, u), true
+	case k == K.Float32:
+		f, _ := v.Float()
+		return num.AppendFloat(dst, f, 32), true
+	case k == K.Float64:
+		f, _ := v.Float()
+		return num.AppendFloat(dst, f, 64), true
+	case k == K.Slice && v.Type().Elem().Kind() == K.Uint8:
+		n, _ := v.Len()
+		if n == 0 {
+			return dst, true
+		}
+		p, _ := v.UnsafePointer()
+		return append(dst, unsafe.Slice((*byte)(p), n)...), true
+	}
+	return dst, false
+}
+
+// appendPair appends "&key=value", without the '&' for the first pair.
+func appendPair(dst []byte, key string, value []byte) []byte {
+	if len(dst) > 0 {
+		dst = append(dst, '&')
+	}
+	dst = appendEscaped(dst, key)
+	dst = append(dst, '=')
+	return appendEscaped(dst, string(value))
+}
+
+const upperHex = "0123456789ABCDEF"
+
+// appendEscaped appends s with spaces as '+' and every byte other than
+// letters, digits and "-_.~" as %XX, like url.QueryEscape.
+func appendEscaped(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			dst = append(dst, c)
+		case c == ' ':
+			dst = append(dst, '+')
+		default:
+			dst = append(dst, '%', upperHex[c>>4], upperHex[c&15])
+		}
+	}
+	return dst
+}
+
+// unescape reverses appendEscaped, accepting lower-case hex digits too.
+func unescape(s string) (string, bool) {
+	plain := true
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' || s[i] == '+' {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return s, true
+	}
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '+':
+			buf = append(buf, ' ')
+		case '%':
+			if i+2 >= len(s) {
+				return "", false
+			}
+			hi, ok1 := unhex(s[i+1])
+			lo, ok2 := unhex(s[i+2])
+			if !ok1 || !ok2 {
+				return "", false
+			}
+			buf = append(buf, hi<<4|lo)
+			i += 2
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return string(buf), true
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// DecodeForm sets the fields of the struct ptr points to from the
+// application/x-www-form-urlencoded data, such as a request body or a
+// query string without its '?'. Keys follow the same rules as EncodeForm;
+// unknown keys are ignored and nil pointers on the way to a key are
+// allocated.
+//
+// A repeated key fills a slice, replacing what it held, or an array
+// element by element, zeroing the rest and returning ErrOutOfRange past
+// its length; for other fields the last value wins. Numbers are parsed for the field's width, so "300"
+// into an int8 returns ErrOverflow and "abc" returns ErrTypeMismatch. An
+// empty value sets a number to zero, and bools accept "true", "false",
+// "on", "1", "0" and "". Errors are *ValueError with the form key as Path.
+func DecodeForm(data string, ptr any) error {
+	rv := ValueOf(ptr)
+	if rv.Kind() != K.Pointer || rv.IsZero() {
+		return newValueError("DecodeForm", rv.Kind(), ErrInvalidArgument)
+	}
+	elem, err := rv.Elem()
+	if err != nil {
+		return err
+	}
+	if elem.Kind() != K.Struct {
+		return newValueError("DecodeForm", elem.Kind(), ErrNotStruct)
+	}
+
+	var seen map[string]int // occurrences of the keys of slices and arrays so far
+	for data != "" {
+		pair := data
+		data = ""
+		for i := 0; i < len(pair); i++ {
+			if pair[i] == '&' {
+				pair, data = pair[:i], pair[i+1:]
+				break
+			}
+		}
+		if pair == "" {
+			continue
+		}
+		rawKey, rawValue := pair, ""
+		for i := 0; i < len(pair); i++ {
+			if pair[i] == '=' {
+				rawKey, rawValue = pair[:i], pair[i+1:]
+				break
+			}
+		}
+		key, ok := unescape(rawKey)
+		if !ok {
+			return &ValueError{Method: "DecodeForm", Kind: K.String, Path: rawKey, Err: ErrInvalidEscape}
+		}
+		value, ok := unescape(rawValue)
+		if !ok {
+			return &ValueError{Method: "DecodeForm", Kind: K.String, Path: key, Err: ErrInvalidEscape}
+		}
+
+		fv, found, err := formField(elem, key)
+		if err != nil {
+			return withPath(err, key)
+		}
+		if !found {
+			continue
+		}
+		if k := fv.Kind(); k == K.Array || k == K.Slice && fv.Type().Elem().Kind() != K.Uint8 {
+			if seen == nil {
+				seen = make(map[string]int)
+			}
+			i := seen[key]
+			seen[key]++
+			if i == 0 {
+				// The first occurrence replaces what the field held
+				if err := fv.SetZero(); err != nil {
+					return withPath(err, key)
+				}
+			}
+			if k == K.Slice {
+				fv, err = appendElem(fv)
+			} else if n, _ := fv.Len(); i < n {
+				fv, err = fv.Index(i)
+			} else {
+				err = newValueError("DecodeForm", k, ErrOutOfRange)
+			}
+			if err != nil {
+				return withPath(err, key)
+			}
+		}
+		if err := setText("DecodeForm", fv, value); err != nil {
+			return withPath(err, key)
+		}
+	}
+	return nil
+}
+
+// formField finds the field named by the dotted key inside the struct v,
+// allocating nil pointers to structs on the way.
+func formField(v Value, key string) (Value, bool, error) {
+	for {
+		seg, rest := key, ""
+		for i := 0; i < len(key); i++ {
+			if key[i] == '.' {
+				seg, rest = key[:i], key[i+1:]
+				break
+			}
+		}
+		fields, err := mapFields(v.Type(), "form")
+		if err != nil {
+			return Value{}, false, err
+		}
+		index := -1
+		for _, f := range fields {
+			if f.key == seg {
+				index = f.index
+				break
+			}
+		}
+		if index < 0 {
+			return Value{}, false, nil
+		}
+		if v, err = v.Field(index); err != nil {
+			return Value{}, false, err
+		}
+		if seg == key {
+			return v, true, nil
+		}
+
+		for v.Kind() == K.Pointer {
+			if v.IsZero() {
+				if v.Type().Elem().Kind() != K.Struct {
+					return Value{}, false, nil
+				}
+				if err := v.Set(NewValue(v.Type().Elem())); err != nil {
+					return Value{}, false, err
+				}
+			}
+			if v, err = v.Elem(); err != nil {
+				return Value{}, false, err
+			}
+		}
+		if v.Kind() != K.Struct {
+			return Value{}, false, nil
+		}
+		key = rest
+	}
+}
+
+// appendElem grows the slice v by one zero element and returns it.
+func appendElem(v Value) (Value, error) {
+	n, _ := v.Len()
+	if c, _ := v.Cap(); n >= c {
+		grown, err := MakeSlice(v.Type(), n, max(4, c*2))
+		if err != nil {
+			return Value{}, err
+		}
+		if _, err := Copy(grown, v); err != nil {
+			return Value{}, err
+		}
+		if err := v.Set(grown); err != nil {
+			return Value{}, err
+		}
+	}
+	if err := v.SetLen(n + 1); err != nil {
+		return Value{}, err
+	}
+	elem, err := v.Index(n)
+	if err != nil {
+		return Value{}, err
+	}
+	return elem, elem.SetZero()
+}
+
+// setText parses s into the scalar v, allocating v first when it is a
+// nil pointer. Errors are reported for method.
+func setText(method string, v Value, s string) error {
+	if v.Kind() == K.Pointer {
+		if v.IsZero() {
+			if err := v.Set(NewValue(v.Type().Elem())); err != nil {
+				return err
+			}
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return err
+		}
+		v = elem
+	}
+
+	switch k := v.Kind(); {
+	case k == K.String:
+		return v.SetString(s)
+
+	case k == K.Bool:
+		switch s {
+		case "true", "on", "1":
+			return v.SetBool(true)
+		case "false", "0", "":
+			return v.SetBool(false)
+		}
+		return newValueError(method, k, ErrTypeMismatch)
+
+	case isIntKind(k):
+		if s == "" {
+			return v.SetInt(0)
+		}
+		i, st := num.ParseInt(s, int(v.Type().Size()*8))
+		if err := parseStatus(method, k, st); err != nil {
+			return err
+		}
+		return v.SetInt(i)
+
+	case isUintKind(k):
+		if s == "" {
+			return v.SetUint(0)
+		}
+		u, st := num.ParseUint(s, int(v.Type().Size()*8))
+		if err := parseStatus(method, k, st); err != nil {
+			return err
+		}
+		return v.SetUint(u)
+
+	case k == K.Float32 || k == K.Float64:
+		if s == "" {
+			return v.SetFloat(0)
+		}
+		f, st := num.ParseFloat(s, int(v.Type().Size()*8))
+		if err := parseStatus(method, k, st); err != nil {
+			return err
+		}
+		return v.SetFloat(f)
+
+	case k == K.Slice && v.Type().Elem().Kind() == K.Uint8:
+		b, err := MakeSlice(v.Type(), len(s), len(s))
+		if err != nil {
+			return err
+		}
+		if len(s) > 0 {
+			p, _ := b.UnsafePointer()
+			copy(unsafe.Slice((*byte)(p), len(s)), s)
+		}
+		return v.Set(b)
+	}
+	return newValueError(method, v.Kind(), ErrUnsupportedKind)
+}
+
+// parseStatus turns a failed num parse into ErrTypeMismatch or ErrOverflow.
+func parseStatus(method string, k Kind, st num.Status) error {
+	switch st {
+	case num.Syntax:
+		return newValueError(method, k, ErrTypeMismatch)
+	case num.Range:
+		return newValueError(method, k, ErrOverflow)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"math"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+type formAddress struct {
+	City string `form:"city"`
+	Zip  uint32 `form:"zip,omitempty"`
+}
+
+type formSignup struct {
+	Name     string       `form:"name"`
+	Age      int8         `form:"age"`
+	Ratio    float32      `form:"ratio"`
+	Agree    bool         `form:"agree"`
+	Tags     []string     `form:"tag"`
+	Scores   []uint16     `form:"score"`
+	Grid     [2]int8      `form:"grid"`
+	Home     formAddress  `form:"home"`
+	Work     *formAddress `form:"work"`
+	Nick     *string      `form:"nick"`
+	Note     string       `form:"note,omitempty"`
+	Secret   string       `form:"-"`
+	Raw      []byte       `form:"raw"`
+	Untagged int64
+}
+
+func TestEncodeForm(t *testing.T) {
+	nick := "a&b=c"
+	s := formSignup{
+		Name:     "Ana María",
+		Age:      -30,
+		Ratio:    0.1,
+		Agree:    true,
+		Tags:     []string{"go", "tiny go"},
+		Scores:   []uint16{1, 65535},
+		Grid:     [2]int8{3, -4},
+		Home:     formAddress{City: "Lima"},
+		Work:     &formAddress{City: "Cusco", Zip: 8000},
+		Nick:     &nick,
+		Secret:   "x",
+		Raw:      []byte("~/?"),
+		Untagged: math.MinInt64,
+	}
+	got := tinyreflect.EncodeForm(&s)
+	want := "name=Ana+Mar%C3%ADa&age=-30&ratio=0.1&agree=true&tag=go&tag=tiny+go&score=1&score=65535&grid=3&grid=-4" +
+		"&home.city=Lima&work.city=Cusco&work.zip=8000&nick=a%26b%3Dc&raw=~%2F%3F&Untagged=-9223372036854775808"
+	if got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+
+	// net/url reads the same pairs
+	values, err := url.ParseQuery(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values.Get("name") != s.Name || values.Get("nick") != nick || len(values["tag"]) != 2 || values.Get("raw") != "~/?" {
+		t.Errorf("net/url parsed %v", values)
+	}
+
+	var back formSignup
+	if err := tinyreflect.DecodeForm(got, &back); err != nil {
+		t.Fatalf("DecodeForm: %v", err)
+	}
+	s.Secret = ""
+	if !reflect.DeepEqual(back, s) {
+		t.Errorf("round trip:\ngot  %+v\nwant %+v", back, s)
+	}
+}
+
+func TestDecodeForm(t *testing.T) {
+	nick := ""
+	testCases := []struct {
+		name  string
+		input string
+		want  formSignup
+	}{
+		{"Escapes", "name=a%2bb+c%C3%a1&nick=", formSignup{Name: "a+b cá", Nick: &nick}},
+		{"Repeated keys replace slice", "tag=x&name=n&tag=y", formSignup{Name: "n", Tags: []string{"x", "y"}}},
+		{"Last scalar wins", "age=1&age=2", formSignup{Age: 2}},
+		{"Nested and allocated", "work.city=Quito&home.zip=42", formSignup{Home: formAddress{Zip: 42}, Work: &formAddress{City: "Quito"}}},
+		{"Checkbox", "agree=on", formSignup{Agree: true}},
+		{"Array by position", "grid=5", formSignup{Grid: [2]int8{5}}},
+		{"Empty number", "age=&ratio=", formSignup{}},
+		{"Unknown and empty pairs", "&&x=1&home.x=2&name&-=3&Secret=4", formSignup{}},
+		{"Extremes", "age=-128&score=65535&Untagged=9223372036854775807&ratio=3.4e38",
+			formSignup{Age: -128, Scores: []uint16{65535}, Untagged: math.MaxInt64, Ratio: 3.4e38}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := formSignup{Tags: []string{"old"}, Grid: [2]int8{8, 9}}
+			if tc.want.Tags == nil {
+				tc.want.Tags = []string{"old"}
+			}
+			if tc.want.Grid == [2]int8{} {
+				tc.want.Grid = [2]int8{8, 9}
+			}
+			if err := tinyreflect.DecodeForm(tc.input, &got); err != nil {
+				t.Fatalf("DecodeForm: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecodeFormNumbers(t *testing.T) {
+	type Numbers struct {
+		I   int
+		I8  int8
+		I16 int16
+		I32 int32
+		I64 int64
+		U   uint
+		U8  uint8
+		U16 uint16
+		U32 uint32
+		U64 uint64
+		F32 float32
+		F64 float64
+	}
+
+	testCases := []struct {
+		key, max, over string
+	}{
+		{"I8", "127", "128"},
+		{"I16", "-32768", "-32769"},
+		{"I32", "2147483647", "2147483648"},
+		{"I64", "-9223372036854775808", "-9223372036854775809"},
+		{"U8", "255", "256"},
+		{"U16", "65535", "65536"},
+		{"U32", "4294967295", "4294967296"},
+		{"U64", "18446744073709551615", "18446744073709551616"},
+		{"F32", "3.4028234e38", "3.5e38"},
+		{"F64", "1.7976931348623157e308", "1e309"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			var n Numbers
+			if err := tinyreflect.DecodeForm(tc.key+"="+tc.max, &n); err != nil {
+				t.Fatalf("%s: %v", tc.max, err)
+			}
+			if back := tinyreflect.EncodeForm(n); !strings.Contains(back, tc.key+"=") {
+				t.Errorf("EncodeForm lost %s: %s", tc.key, back)
+			}
+			var ve *tinyreflect.ValueError
+			err := tinyreflect.DecodeForm(tc.key+"="+tc.over, &n)
+			if !errors.Is(err, tinyreflect.ErrOverflow) || !errors.As(err, &ve) || ve.Path != tc.key {
+				t.Errorf("%s: got %v, want ErrOverflow at %s", tc.over, err, tc.key)
+			}
+		})
+	}
+
+	var n Numbers
+	if err := tinyreflect.DecodeForm("U=-1", &n); !errors.Is(err, tinyreflect.ErrTypeMismatch) {
+		t.Errorf("negative uint: got %v, want ErrTypeMismatch", err)
+	}
+	if err := tinyreflect.DecodeForm("I=1.5", &n); !errors.Is(err, tinyreflect.ErrTypeMismatch) {
+		t.Errorf("fraction: got %v, want ErrTypeMismatch", err)
+	}
+}
+
+func TestDecodeFormErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		wantErr error
+		path    string
+	}{
+		{"Bad escape in value", "name=%zz", tinyreflect.ErrInvalidEscape, "name"},
+		{"Short escape", "name=%4", tinyreflect.ErrInvalidEscape, "name"},
+		{"Bad escape in key", "na%me=x", tinyreflect.ErrInvalidEscape, "na%me"},
+		{"Bad bool", "agree=yes", tinyreflect.ErrTypeMismatch, "agree"},
+		{"Bad number in slice", "score=1&score=x", tinyreflect.ErrTypeMismatch, "score"},
+		{"Array too long", "grid=1&grid=2&grid=3", tinyreflect.ErrOutOfRange, "grid"},
+		{"Nested negative uint", "work.zip=-1", tinyreflect.ErrTypeMismatch, "work.zip"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var s formSignup
+			err := tinyreflect.DecodeForm(tc.input, &s)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got %v, want %v", err, tc.wantErr)
+			}
+			var ve *tinyreflect.ValueError
+			if !errors.As(err, &ve) || ve.Path != tc.path {
+				t.Errorf("got path %q, want %q", ve.Path, tc.path)
+			}
+		})
+	}
+
+	if err := tinyreflect.DecodeForm("", formSignup{}); !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+		t.Errorf("non-pointer: got %v, want ErrInvalidArgument", err)
+	}
+	if err := tinyreflect.DecodeForm("", new(int)); !errors.Is(err, tinyreflect.ErrNotStruct) {
+		t.Errorf("pointer to int: got %v, want ErrNotStruct", err)
+	}
+	if got := tinyreflect.EncodeForm(42); got != "" {
+		t.Errorf("EncodeForm(42) = %q, want empty", got)
+	}
+}
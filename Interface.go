@@ -14,11 +14,16 @@ import (
 // For a Value created from a nil interface value, Interface returns nil.
 func (v Value) Interface() (i any, err error) {
 	if v.typ_ == nil {
-		return nil, Err(ref, D.Value, D.Nil)
+		return nil, newValueError("Interface", K.Invalid, ErrNilValue)
 	}
 
 	if v.kind() == K.Interface {
-		return nil, Err(ref, D.Type, D.Not, D.Supported)
+		// Return the dynamic value held by the interface
+		elem, _ := v.Elem()
+		if elem.typ_ == nil {
+			return nil, nil
+		}
+		return packEface(elem), nil
 	}
 
 	i = packEface(v)
@@ -32,6 +37,12 @@ func packEface(v Value) any {
 	var i any
 	e := (*EmptyInterface)(unsafe.Pointer(&i))
 	e.Type = t
-	e.Data = v.ptr
+	if !t.IfaceIndir() && v.flag&flagIndir != 0 {
+		// Pointer-shaped values live directly in the data word,
+		// so load the pointer instead of pointing at its slot
+		e.Data = *(*unsafe.Pointer)(v.ptr)
+	} else {
+		e.Data = v.ptr
+	}
 	return i
 }
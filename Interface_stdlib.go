@@ -0,0 +1,46 @@
+//go:build !tinygo
+
+package tinyreflect
+
+import "unsafe"
+
+// InterfaceType represents an interface type.
+// Layout matches stdlib's abi.InterfaceType for compatibility.
+type InterfaceType struct {
+	Type
+	PkgPath Name      // import path
+	Methods []imethod // sorted by hash
+}
+
+// imethod represents a method on an interface type.
+type imethod struct {
+	Name NameOff // name of method
+	Typ  TypeOff // .(*FuncType) underneath
+}
+
+// itab is the first word of a non-empty interface value.
+// Layout matches the prefix of stdlib's abi.ITab.
+type itab struct {
+	Inter *InterfaceType
+	Type  *Type
+}
+
+// ifaceElem returns the dynamic type and data word of the interface value
+// stored at p, whose static interface type is t.
+func ifaceElem(t *Type, p unsafe.Pointer) (*Type, unsafe.Pointer) {
+	e := (*EmptyInterface)(p)
+	if acceptsAny(t) {
+		return e.Type, e.Data
+	}
+	// Non-empty interfaces keep an itab pointer in their first word
+	if e.Type == nil {
+		return nil, nil
+	}
+	return (*itab)(unsafe.Pointer(e.Type)).Type, e.Data
+}
+
+// acceptsAny reports whether any value can be stored in the interface type t,
+// which is the case for empty interfaces.
+func acceptsAny(t *Type) bool {
+	return len((*InterfaceType)(unsafe.Pointer(t.underlying())).Methods) == 0
+}
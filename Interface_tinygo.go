@@ -0,0 +1,19 @@
+//go:build tinygo
+
+package tinyreflect
+
+import "unsafe"
+
+// ifaceElem returns the dynamic type and data word of the interface value
+// stored at p. TinyGo stores every interface as a (type, value) pair.
+func ifaceElem(t *Type, p unsafe.Pointer) (*Type, unsafe.Pointer) {
+	e := (*EmptyInterface)(p)
+	return e.Type, e.Data
+}
+
+// acceptsAny reports whether any value can be stored in the interface type t.
+// TinyGo stores every interface as a (type, value) pair and its method sets
+// are not available here, so the check is left to the caller.
+func acceptsAny(t *Type) bool {
+	return true
+}
@@ -0,0 +1,189 @@
+package tinyreflect
+
+import (
+	"unsafe"
+
+	. "github.com/cdvelop/tinystring"
+)
+
+// ErrLayoutMismatch is wrapped by LayoutError when the runtime type layout
+// no longer matches the structures mirrored by this package.
+var ErrLayoutMismatch = &Error{[]any{D.Type, D.Mismatch}}
+
+// LayoutError reports a single layout check that failed in VerifyLayout.
+type LayoutError struct {
+	Type  string // probe type that failed, e.g. "layoutProbe"
+	Field string // field name, empty when the check is on the type itself
+	Check string // what was checked: "size", "kind", "offset", "name", "len", "elem", ...
+	Want  string // value computed by the compiler
+	Got   string // value read through tinyreflect
+}
+
+// Error returns a message naming the check, the expected and the observed value.
+func (e *LayoutError) Error() string {
+	target := e.Type
+	if e.Field != "" {
+		target += "." + e.Field
+	}
+	return Fmt("%s layout: %s %s = %s, want %s (%s)", ref, target, e.Check, e.Got, e.Want, ErrLayoutMismatch.Error())
+}
+
+// Unwrap returns ErrLayoutMismatch.
+func (e *LayoutError) Unwrap() error {
+	return ErrLayoutMismatch
+}
+
+// layoutProbe covers every field shape the mirrors have to decode.
+type layoutProbe struct {
+	A bool
+	B int64
+	C string
+	D []int32
+	E *int
+	F [3]uint16
+	G map[string]int
+	H float32
+	I layoutInner
+}
+
+type layoutInner struct {
+	X uint8
+	Y int
+}
+
+// VerifyLayout checks that the Type, StructType, SliceType, ArrayType and
+// PtrType mirrors still match the layout used by the running Go or TinyGo
+// runtime. It compares sizes, kinds, field offsets and field names of a
+// corpus of types against the values computed by the compiler and returns
+// a *LayoutError for the first mismatch, or nil when the layout matches.
+//
+// Call it once at startup (or in a test) after upgrading the toolchain.
+func VerifyLayout() error {
+	var probe layoutProbe
+
+	basics := []struct {
+		name string
+		v    any
+		size uintptr
+		kind Kind
+	}{
+		{"bool", false, unsafe.Sizeof(false), K.Bool},
+		{"int", int(0), unsafe.Sizeof(int(0)), K.Int},
+		{"int8", int8(0), 1, K.Int8},
+		{"int16", int16(0), 2, K.Int16},
+		{"int32", int32(0), 4, K.Int32},
+		{"int64", int64(0), 8, K.Int64},
+		{"uint", uint(0), unsafe.Sizeof(uint(0)), K.Uint},
+		{"uint8", uint8(0), 1, K.Uint8},
+		{"uint16", uint16(0), 2, K.Uint16},
+		{"uint32", uint32(0), 4, K.Uint32},
+		{"uint64", uint64(0), 8, K.Uint64},
+		{"float32", float32(0), 4, K.Float32},
+		{"float64", float64(0), 8, K.Float64},
+		{"string", "", unsafe.Sizeof(""), K.String},
+		{"[]int32", []int32(nil), unsafe.Sizeof([]int32(nil)), K.Slice},
+		{"*int", (*int)(nil), unsafe.Sizeof((*int)(nil)), K.Pointer},
+		{"[3]uint16", [3]uint16{}, unsafe.Sizeof([3]uint16{}), K.Array},
+		{"map[string]int", map[string]int(nil), unsafe.Sizeof(map[string]int(nil)), K.Map},
+		{"layoutProbe", probe, unsafe.Sizeof(probe), K.Struct},
+	}
+	for _, b := range basics {
+		t := TypeOf(b.v)
+		if t == nil {
+			return &LayoutError{Type: b.name, Check: "type", Want: "non-nil", Got: "nil"}
+		}
+		if k := t.Kind(); k != b.kind {
+			return &LayoutError{Type: b.name, Check: "kind", Want: b.kind.String(), Got: k.String()}
+		}
+		if s := t.Size(); s != b.size {
+			return &LayoutError{Type: b.name, Check: "size", Want: layoutNum(b.size), Got: layoutNum(s)}
+		}
+	}
+
+	// Values must round-trip through interfaces: direct-iface detection
+	// decides whether the data word holds the value or points to it.
+	x := 42
+	if elem, err := ValueOf(&x).Elem(); err != nil || elem.ptr != unsafe.Pointer(&x) {
+		return &LayoutError{Type: "*int", Check: "iface data", Want: "pointer to value", Got: "other"}
+	}
+	if ValueOf(map[string]int{}).flag&flagIndir != 0 {
+		return &LayoutError{Type: "map[string]int", Check: "iface data", Want: "direct", Got: "indirect"}
+	}
+
+	fields := []struct {
+		name string
+		off  uintptr
+		kind Kind
+	}{
+		{"A", unsafe.Offsetof(probe.A), K.Bool},
+		{"B", unsafe.Offsetof(probe.B), K.Int64},
+		{"C", unsafe.Offsetof(probe.C), K.String},
+		{"D", unsafe.Offsetof(probe.D), K.Slice},
+		{"E", unsafe.Offsetof(probe.E), K.Pointer},
+		{"F", unsafe.Offsetof(probe.F), K.Array},
+		{"G", unsafe.Offsetof(probe.G), K.Map},
+		{"H", unsafe.Offsetof(probe.H), K.Float32},
+		{"I", unsafe.Offsetof(probe.I), K.Struct},
+	}
+	t := TypeOf(probe)
+	if n, err := t.NumField(); err != nil || n != len(fields) {
+		return &LayoutError{Type: "layoutProbe", Check: "fields", Want: layoutNum(uintptr(len(fields))), Got: layoutNum(uintptr(n))}
+	}
+	for i, want := range fields {
+		f, err := t.Field(i)
+		if err != nil || f.Typ == nil {
+			return &LayoutError{Type: "layoutProbe", Field: want.name, Check: "field", Want: "readable", Got: "error"}
+		}
+		if f.Off != want.off {
+			return &LayoutError{Type: "layoutProbe", Field: want.name, Check: "offset", Want: layoutNum(want.off), Got: layoutNum(f.Off)}
+		}
+		if k := f.Typ.Kind(); k != want.kind {
+			return &LayoutError{Type: "layoutProbe", Field: want.name, Check: "kind", Want: want.kind.String(), Got: k.String()}
+		}
+		if name, _ := t.NameByIndex(i); name != want.name {
+			return &LayoutError{Type: "layoutProbe", Field: want.name, Check: "name", Want: want.name, Got: name}
+		}
+	}
+
+	// Element types of the composite fields
+	elems := []struct {
+		name string
+		typ  *Type
+		want Kind
+	}{
+		{"D", TypeOf(probe.D).Elem(), K.Int32},
+		{"E", TypeOf(probe.E).Elem(), K.Int},
+		{"F", TypeOf(probe.F).Elem(), K.Uint16},
+	}
+	for _, e := range elems {
+		if e.typ == nil || e.typ.Kind() != e.want {
+			got := "nil"
+			if e.typ != nil {
+				got = e.typ.Kind().String()
+			}
+			return &LayoutError{Type: "layoutProbe", Field: e.name, Check: "elem", Want: e.want.String(), Got: got}
+		}
+	}
+	at := TypeOf(probe.F).ArrayType()
+	if at.Length() != len(probe.F) {
+		return &LayoutError{Type: "layoutProbe", Field: "F", Check: "len", Want: layoutNum(uintptr(len(probe.F))), Got: layoutNum(uintptr(at.Length()))}
+	}
+	if at.SliceOf() == nil || at.SliceOf().Kind() != K.Slice {
+		return &LayoutError{Type: "layoutProbe", Field: "F", Check: "slice type", Want: K.Slice.String(), Got: "other"}
+	}
+
+	// Nested struct offsets
+	inner := TypeOf(probe.I)
+	for i, want := range []uintptr{unsafe.Offsetof(probe.I.X), unsafe.Offsetof(probe.I.Y)} {
+		f, err := inner.Field(i)
+		if err != nil || f.Off != want {
+			return &LayoutError{Type: "layoutInner", Field: []string{"X", "Y"}[i], Check: "offset", Want: layoutNum(want), Got: layoutNum(f.Off)}
+		}
+	}
+	return nil
+}
+
+// layoutNum formats n for LayoutError messages.
+func layoutNum(n uintptr) string {
+	return Convert(int(n)).String()
+}
@@ -0,0 +1,226 @@
+package tinyreflect
+
+import (
+	"unsafe"
+
+	. "github.com/cdvelop/tinystring"
+)
+
+// MapType is defined in Map_stdlib.go and Map_tinygo.go with build tags
+// to match each runtime's layout.
+
+// MapType returns t cast to a *MapType, or nil if its tag does not match.
+func (t *Type) MapType() *MapType {
+	if t.Kind() != K.Map {
+		return nil
+	}
+	return (*MapType)(unsafe.Pointer(t.underlying()))
+}
+
+// Key returns a map type's key type, or nil if t is not a map.
+func (t *Type) Key() *Type {
+	if mt := t.MapType(); mt != nil {
+		return mt.Key
+	}
+	return nil
+}
+
+// MakeMap creates a new map with the specified type.
+func MakeMap(typ *Type) (Value, error) {
+	return MakeMapWithSize(typ, 0)
+}
+
+// MakeMapWithSize creates a new map with the specified type
+// and initial space for approximately n elements.
+func MakeMapWithSize(typ *Type, n int) (Value, error) {
+	if typ == nil {
+		return Value{}, newValueError("MakeMap", K.Invalid, ErrNilValue)
+	}
+	if typ.Kind() != K.Map {
+		return Value{}, newValueError("MakeMap", typ.Kind(), ErrUnsupportedKind)
+	}
+	if n < 0 {
+		return Value{}, newValueError("MakeMap", K.Map, ErrInvalidArgument)
+	}
+	return Value{typ, makemap(typ, n), flag(K.Map)}, nil
+}
+
+// SetMapIndex sets the element associated with key in the map v to elem.
+// If elem is the zero Value, SetMapIndex deletes the key from the map.
+// It returns an error if v's Kind is not Map, if v is a nil map or was
+// obtained through an unexported field, or if key or elem do not match
+// the map's key and element types. Any value can be stored in a map of
+// empty interfaces.
+func (v Value) SetMapIndex(key, elem Value) error {
+	if err := v.mustBe("SetMapIndex", K.Map); err != nil {
+		return err
+	}
+	if v.flag&flagRO != 0 {
+		return newValueError("SetMapIndex", K.Map, ErrNotAssignable)
+	}
+	mt := v.typ_.MapType()
+	if key.typ_ != mt.Key {
+		return newValueError("SetMapIndex", K.Map, ErrTypeMismatch)
+	}
+	m := v.pointer()
+	if m == nil {
+		return newValueError("SetMapIndex", K.Map, ErrNilValue)
+	}
+
+	if elem.typ_ == nil {
+		mapdelete(v.typ_, m, key.dataPointer())
+		return nil
+	}
+	if elem.typ_ != mt.Elem {
+		if mt.Elem.Kind() != K.Interface {
+			return newValueError("SetMapIndex", K.Map, ErrTypeMismatch)
+		}
+		// Box elem into a temporary interface of the element type
+		tmp := Value{mt.Elem, unsafeNew(mt.Elem), flag(K.Interface) | flagIndir | flagAddr}
+		if err := tmp.Set(elem); err != nil {
+			return err
+		}
+		elem = tmp
+	}
+	mapassign(v.typ_, m, key.dataPointer(), elem.dataPointer())
+	return nil
+}
+
+// MapIndex returns the value associated with key in the map v.
+// It returns the zero Value if key is not found in the map or if v is a nil map.
+// It returns an error if v's Kind is not Map or key's type does not match the map key type.
+func (v Value) MapIndex(key Value) (Value, error) {
+	if err := v.mustBe("MapIndex", K.Map); err != nil {
+		return Value{}, err
+	}
+	mt := v.typ_.MapType()
+	if key.typ_ != mt.Key {
+		return Value{}, newValueError("MapIndex", K.Map, ErrTypeMismatch)
+	}
+	m := v.pointer()
+	if m == nil {
+		return Value{}, nil
+	}
+	e := mapaccess(v.typ_, m, key.dataPointer())
+	if e == nil {
+		return Value{}, nil
+	}
+	return copyVal(mt.Elem, v.flag&flagRO, e), nil
+}
+
+// MapKeys returns a slice containing all the keys present in the map,
+// in unspecified order. It returns an empty slice if v is a nil map.
+// It returns an error if v's Kind is not Map.
+func (v Value) MapKeys() ([]Value, error) {
+	iter, err := v.MapRange()
+	if err != nil {
+		return nil, err
+	}
+	n, _ := v.Len()
+	keys := make([]Value, 0, n)
+	for iter.Next() {
+		keys = append(keys, iter.Key())
+	}
+	return keys, nil
+}
+
+// MapRange returns a range iterator for a map.
+// It returns an error if v's Kind is not Map.
+//
+// Call Next to advance the iterator, and Key/Value to access each entry.
+// Next returns false when the iterator is exhausted.
+//
+//	iter, err := v.MapRange()
+//	for iter.Next() {
+//		k := iter.Key()
+//		v := iter.Value()
+//		...
+//	}
+func (v Value) MapRange() (*MapIter, error) {
+	if err := v.mustBe("MapRange", K.Map); err != nil {
+		return nil, err
+	}
+	return &MapIter{m: v}, nil
+}
+
+// A MapIter is an iterator for ranging over a map. See Value.MapRange.
+type MapIter struct {
+	m       Value
+	it      mapIter
+	started bool
+	done    bool
+}
+
+// Next advances the map iterator and reports whether there is another entry.
+// It returns false when the iterator is exhausted.
+func (it *MapIter) Next() bool {
+	if it.done {
+		return false
+	}
+	if !it.started {
+		m := it.m.pointer()
+		if m == nil || maplen(m) == 0 {
+			it.done = true
+			return false
+		}
+		it.it.init(it.m.typ_.MapType(), m)
+	}
+	ok := it.it.next(it.started)
+	it.started = true
+	if !ok {
+		it.done = true
+	}
+	return ok
+}
+
+// Key returns a copy of the key of the iterator's current map entry.
+// It returns the zero Value if Next has not been called or returned false.
+func (it *MapIter) Key() Value {
+	if !it.started || it.done {
+		return Value{}
+	}
+	k, _ := it.it.entry()
+	return copyVal(it.m.typ_.MapType().Key, it.m.flag&flagRO, k)
+}
+
+// Value returns a copy of the value of the iterator's current map entry.
+// It returns the zero Value if Next has not been called or returned false.
+func (it *MapIter) Value() Value {
+	if !it.started || it.done {
+		return Value{}
+	}
+	_, e := it.it.entry()
+	return copyVal(it.m.typ_.MapType().Elem, it.m.flag&flagRO, e)
+}
+
+// Reset modifies it to iterate over v.
+// It returns an error if v's Kind is not Map.
+func (it *MapIter) Reset(v Value) error {
+	if err := v.mustBe("MapIter.Reset", K.Map); err != nil {
+		return err
+	}
+	*it = MapIter{m: v}
+	return nil
+}
+
+// copyVal returns a Value containing the map key or value at ptr,
+// allocating a new variable as needed so the result outlives map updates.
+func copyVal(typ *Type, fl flag, ptr unsafe.Pointer) Value {
+	if !typ.IfaceIndir() {
+		// Pointer-shaped values fit in the Value itself
+		return Value{typ, *(*unsafe.Pointer)(ptr), fl | flag(typ.Kind())}
+	}
+	c := unsafeNew(typ)
+	typedmemmove(typ, c, ptr)
+	return Value{typ, c, fl | flag(typ.Kind()) | flagIndir}
+}
+
+// dataPointer returns a pointer to v's data, boxing pointer-shaped values
+// that are stored directly in v.ptr.
+func (v Value) dataPointer() unsafe.Pointer {
+	if v.flag&flagIndir != 0 {
+		return v.ptr
+	}
+	p := v.ptr
+	return unsafe.Pointer(&p)
+}
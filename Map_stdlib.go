@@ -0,0 +1,63 @@
+//go:build !tinygo
+
+package tinyreflect
+
+import "unsafe"
+
+// MapType represents a map type.
+// Layout matches the prefix of stdlib's abi.MapType; the remaining fields
+// (group type, hasher, slot sizes) are only used by the runtime.
+type MapType struct {
+	Type
+	Key  *Type // map key type
+	Elem *Type // map element (value) type
+}
+
+// mapIter holds the runtime iterator state.
+// Layout matches runtime.linknameIter; iteration ends when key is nil.
+type mapIter struct {
+	key  unsafe.Pointer
+	elem unsafe.Pointer
+	typ  *MapType
+	it   unsafe.Pointer
+}
+
+//go:linkname maplen reflect.maplen
+func maplen(m unsafe.Pointer) int
+
+//go:linkname mapaccess reflect.mapaccess
+func mapaccess(t *Type, m unsafe.Pointer, key unsafe.Pointer) unsafe.Pointer
+
+//go:linkname mapassign reflect.mapassign0
+func mapassign(t *Type, m unsafe.Pointer, key, elem unsafe.Pointer)
+
+//go:linkname mapdelete reflect.mapdelete
+func mapdelete(t *Type, m unsafe.Pointer, key unsafe.Pointer)
+
+//go:linkname makemap reflect.makemap
+func makemap(t *Type, cap int) unsafe.Pointer
+
+//go:linkname mapiterinit runtime.mapiterinit
+func mapiterinit(t *MapType, m unsafe.Pointer, it *mapIter)
+
+//go:linkname mapiternext runtime.mapiternext
+func mapiternext(it *mapIter)
+
+// init starts iterating over the map m of type t.
+func (it *mapIter) init(t *MapType, m unsafe.Pointer) {
+	mapiterinit(t, m, it)
+}
+
+// next advances the iterator and reports whether an entry is available.
+// The first call after init returns the first entry.
+func (it *mapIter) next(started bool) bool {
+	if started {
+		mapiternext(it)
+	}
+	return it.key != nil
+}
+
+// entry returns pointers to the current key and element.
+func (it *mapIter) entry() (key, elem unsafe.Pointer) {
+	return it.key, it.elem
+}
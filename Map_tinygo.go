@@ -0,0 +1,143 @@
+//go:build tinygo
+
+package tinyreflect
+
+import (
+	"unsafe"
+
+	. "github.com/cdvelop/tinystring"
+)
+
+// MapType represents a map type.
+// Layout matches TinyGo's internal/reflectlite mapType.
+type MapType struct {
+	Type
+	numMethod uint16
+	ptrTo     *Type
+	Elem      *Type // map element (value) type
+	Key       *Type // map key type
+}
+
+// hashmapIterator matches the layout of TinyGo's runtime.hashmapIterator.
+type hashmapIterator struct {
+	buckets      unsafe.Pointer
+	numBuckets   uintptr
+	bucketNumber uintptr
+	bucket       unsafe.Pointer
+	bucketIndex  uint8
+	startBucket  uintptr
+	wrapped      bool
+}
+
+// mapIter holds the runtime iterator state and the current entry.
+type mapIter struct {
+	m    unsafe.Pointer
+	it   hashmapIterator
+	key  unsafe.Pointer
+	elem unsafe.Pointer
+}
+
+//go:linkname hashmapLen runtime.hashmapLen
+func hashmapLen(m unsafe.Pointer) int
+
+//go:linkname hashmapNext runtime.hashmapNext
+func hashmapNext(m unsafe.Pointer, it *hashmapIterator, key, value unsafe.Pointer) bool
+
+//go:linkname hashmapMake runtime.hashmapMake
+func hashmapMake(keySize, valueSize uintptr, sizeHint uintptr, alg uint8) unsafe.Pointer
+
+//go:linkname hashmapStringGet runtime.hashmapStringGet
+func hashmapStringGet(m unsafe.Pointer, key string, value unsafe.Pointer, valueSize uintptr) bool
+
+//go:linkname hashmapBinaryGet runtime.hashmapBinaryGet
+func hashmapBinaryGet(m unsafe.Pointer, key, value unsafe.Pointer, valueSize uintptr) bool
+
+//go:linkname hashmapStringSet runtime.hashmapStringSet
+func hashmapStringSet(m unsafe.Pointer, key string, value unsafe.Pointer)
+
+//go:linkname hashmapBinarySet runtime.hashmapBinarySet
+func hashmapBinarySet(m unsafe.Pointer, key, value unsafe.Pointer)
+
+//go:linkname hashmapStringDelete runtime.hashmapStringDelete
+func hashmapStringDelete(m unsafe.Pointer, key string)
+
+//go:linkname hashmapBinaryDelete runtime.hashmapBinaryDelete
+func hashmapBinaryDelete(m unsafe.Pointer, key unsafe.Pointer)
+
+// TinyGo hashmap key algorithms (runtime.hashmapAlgorithm).
+const (
+	hashmapAlgBinary uint8 = iota
+	hashmapAlgString
+)
+
+func maplen(m unsafe.Pointer) int {
+	if m == nil {
+		return 0
+	}
+	return hashmapLen(m)
+}
+
+func mapaccess(t *Type, m unsafe.Pointer, key unsafe.Pointer) unsafe.Pointer {
+	if m == nil {
+		return nil
+	}
+	mt := (*MapType)(unsafe.Pointer(t.underlying()))
+	elem := unsafeNew(mt.Elem)
+	var ok bool
+	if mt.Key.Kind() == K.String {
+		ok = hashmapStringGet(m, *(*string)(key), elem, mt.Elem.Size())
+	} else {
+		ok = hashmapBinaryGet(m, key, elem, mt.Elem.Size())
+	}
+	if !ok {
+		return nil
+	}
+	return elem
+}
+
+func mapassign(t *Type, m unsafe.Pointer, key, elem unsafe.Pointer) {
+	mt := (*MapType)(unsafe.Pointer(t.underlying()))
+	if mt.Key.Kind() == K.String {
+		hashmapStringSet(m, *(*string)(key), elem)
+	} else {
+		hashmapBinarySet(m, key, elem)
+	}
+}
+
+func mapdelete(t *Type, m unsafe.Pointer, key unsafe.Pointer) {
+	mt := (*MapType)(unsafe.Pointer(t.underlying()))
+	if mt.Key.Kind() == K.String {
+		hashmapStringDelete(m, *(*string)(key))
+	} else {
+		hashmapBinaryDelete(m, key)
+	}
+}
+
+func makemap(t *Type, cap int) unsafe.Pointer {
+	mt := (*MapType)(unsafe.Pointer(t.underlying()))
+	alg := hashmapAlgBinary
+	if mt.Key.Kind() == K.String {
+		alg = hashmapAlgString
+	}
+	return hashmapMake(mt.Key.Size(), mt.Elem.Size(), uintptr(cap), alg)
+}
+
+// init starts iterating over the map m of type t.
+func (it *mapIter) init(t *MapType, m unsafe.Pointer) {
+	it.m = m
+	it.key = unsafeNew(t.Key)
+	it.elem = unsafeNew(t.Elem)
+}
+
+// next advances the iterator and reports whether an entry is available.
+func (it *mapIter) next(started bool) bool {
+	if it.m == nil {
+		return false
+	}
+	return hashmapNext(it.m, &it.it, it.key, it.elem)
+}
+
+// entry returns pointers to the current key and element.
+func (it *mapIter) entry() (key, elem unsafe.Pointer) {
+	return it.key, it.elem
+}
@@ -0,0 +1,403 @@
+package tinyreflect
+
+import (
+	"slices"
+
+	"github.com/cdvelop/tinyreflect/internal/cache"
+	. "github.com/cdvelop/tinystring"
+)
+
+// MapOptions configures Map.
+type MapOptions struct {
+	// TagKey matches fields by the name in this tag, e.g. "json"; fields
+	// without the tag use their Go name, and "-" leaves a field out.
+	// Empty matches Go names only.
+	TagKey string
+	// Rename maps a destination field's Go name to the Go name of the
+	// source field it is copied from, in every struct Map meets; "-"
+	// leaves the destination field alone.
+	Rename map[string]string
+}
+
+// Map copies the fields of the struct src, or of the struct it points
+// to, into the struct dst points to, matching fields across the two types
+// as opts describes. Values of the same type are assigned as they are;
+// numbers convert between kinds with range checks, and nested structs,
+// pointers, slices, arrays and maps convert element by element. It
+// returns the sorted paths of the destination fields no source field
+// fills, such as "Address.Zip"; paths through slices and maps have no index.
+// A matched pair of fields whose types cannot convert is ErrTypeMismatch.
+// Source data nested deeper than maxMapDepth, such as a pointer cycle,
+// is ErrInvalidArgument.
+// The plan for each pair of types is built once and cached.
+func Map(dst, src any, opts MapOptions) (unmapped []string, err error) {
+	dv := ValueOf(dst)
+	if dv.Kind() != K.Pointer || dv.IsZero() {
+		return nil, newValueError("Map", dv.Kind(), ErrInvalidArgument)
+	}
+	if dv, err = dv.Elem(); err != nil {
+		return nil, err
+	}
+	sv := Indirect(ValueOf(src))
+	if dv.Kind() != K.Struct || sv.Kind() != K.Struct {
+		return nil, newValueError("Map", sv.Kind(), ErrNotStruct)
+	}
+	plan, err := cachedPlan(dv.Type(), sv.Type(), opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := plan.apply(dv, sv, 0); err != nil {
+		return nil, err
+	}
+	return slices.Clone(plan.unmapped), nil
+}
+
+// mapConv converts src into the settable dst; depth is the nesting of
+// the values, bounded by maxMapDepth so source pointer cycles fail.
+type mapConv func(dst, src Value, depth int) error
+
+// mapStep copies one source field into one destination field.
+type mapStep struct {
+	dst, src int
+	name     string // destination Go name, for error paths
+	conv     mapConv
+}
+
+// mapPlan is the cached recipe for mapping one struct type onto another.
+type mapPlan struct {
+	steps    []mapStep
+	unmapped []string
+}
+
+func (p *mapPlan) apply(dst, src Value, depth int) error {
+	if depth > maxMapDepth {
+		return newValueError("Map", K.Struct, ErrInvalidArgument)
+	}
+	for _, s := range p.steps {
+		to, err := dst.Field(s.dst)
+		if err != nil {
+			return err
+		}
+		from, err := src.Field(s.src)
+		if err != nil {
+			return err
+		}
+		if err := s.conv(to, from, depth+1); err != nil {
+			return withPath(err, s.name)
+		}
+	}
+	return nil
+}
+
+// planKey identifies a plan: the two struct types and the options.
+type planKey struct {
+	dst, src *Type
+	opts     string
+}
+
+// mapCache keeps the plans built for each pair of struct types.
+var mapCache cache.Cache[planKey, *mapPlan]
+
+// optionsKey writes opts as a string that can key the plan cache.
+func optionsKey(opts MapOptions) string {
+	key := opts.TagKey
+	names := make([]string, 0, len(opts.Rename))
+	for dst := range opts.Rename {
+		names = append(names, dst)
+	}
+	slices.Sort(names)
+	for _, dst := range names {
+		key += "\x00" + dst + "=" + opts.Rename[dst]
+	}
+	return key
+}
+
+// cachedPlan returns the plan for mapping src onto dst, building it and
+// the plans of the structs nested in them on first use.
+func cachedPlan(dst, src *Type, opts MapOptions) (*mapPlan, error) {
+	key := planKey{dst, src, optionsKey(opts)}
+	if plan, ok := mapCache.Load(key); ok {
+		return plan, nil
+	}
+
+	b := planBuilder{opts: opts, optsKey: key.opts, built: make(map[planKey]*mapPlan)}
+	plan, err := b.plan(dst, src)
+	if err != nil {
+		return nil, err
+	}
+	// Unmapped paths are collected once every nested plan is complete
+	full := make(map[*mapPlan][]string, len(b.built))
+	for _, p := range b.built {
+		full[p] = b.collect(p, "", map[*mapPlan]bool{})
+		slices.Sort(full[p])
+	}
+	for _, p := range b.built {
+		p.unmapped = full[p]
+	}
+
+	for k, p := range b.built {
+		mapCache.Store(k, p)
+	}
+	return plan, nil
+}
+
+// planBuilder builds the plans for one Map call. Plans under construction
+// are kept in built, so recursive types end up sharing them.
+type planBuilder struct {
+	opts    MapOptions
+	optsKey string
+	built   map[planKey]*mapPlan
+	nested  map[*mapPlan][]nestedPlan
+}
+
+// nestedPlan records that the field name of a plan is mapped by another plan.
+type nestedPlan struct {
+	name string
+	plan *mapPlan
+}
+
+func (b *planBuilder) plan(dst, src *Type) (*mapPlan, error) {
+	key := planKey{dst, src, b.optsKey}
+	if p, ok := b.built[key]; ok {
+		return p, nil
+	}
+	p := &mapPlan{}
+	b.built[key] = p
+
+	srcFields, err := mapFields(src, b.opts.TagKey)
+	if err != nil {
+		return nil, err
+	}
+	n, err := dst.NumField()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		df, err := dst.Field(i)
+		if err != nil {
+			return nil, err
+		}
+		if !df.IsExported() {
+			continue
+		}
+		name := df.Name.String()
+		want, renamed := b.opts.Rename[name]
+		if !renamed && b.opts.TagKey != "" {
+			tag, _ := df.Tag().Lookup(b.opts.TagKey)
+			want, _ = ParseTag(tag)
+		}
+		if want == "-" {
+			continue
+		}
+		if want == "" {
+			want = name
+		}
+
+		j := -1
+		for _, f := range srcFields {
+			key := f.key
+			if renamed {
+				key, _ = src.NameByIndex(f.index)
+			}
+			if key == want {
+				j = f.index
+				break
+			}
+		}
+		if j < 0 {
+			p.unmapped = append(p.unmapped, name)
+			continue
+		}
+		sf, err := src.Field(j)
+		if err != nil {
+			return nil, err
+		}
+		conv, sub, err := b.conv(df.Typ, sf.Typ)
+		if err != nil {
+			return nil, withPath(err, name)
+		}
+		if sub != nil {
+			if b.nested == nil {
+				b.nested = make(map[*mapPlan][]nestedPlan)
+			}
+			b.nested[p] = append(b.nested[p], nestedPlan{name, sub})
+		}
+		p.steps = append(p.steps, mapStep{dst: i, src: j, name: name, conv: conv})
+	}
+	return p, nil
+}
+
+// collect returns the unmapped field paths of p and of the plans nested
+// in it, under every field that reaches them. A plan already on the
+// stack of plans being collected is a recursive type and is not entered
+// again.
+func (b *planBuilder) collect(p *mapPlan, prefix string, stack map[*mapPlan]bool) []string {
+	if stack[p] {
+		return nil
+	}
+	stack[p] = true
+	defer delete(stack, p)
+	var out []string
+	for _, name := range p.unmapped {
+		out = append(out, joinPath(prefix, name))
+	}
+	for _, n := range b.nested[p] {
+		out = append(out, b.collect(n.plan, joinPath(prefix, n.name), stack)...)
+	}
+	return out
+}
+
+// conv returns the conversion from src to dst values and, when it maps
+// structs, the plan it uses. Types that cannot convert are ErrTypeMismatch.
+func (b *planBuilder) conv(dst, src *Type) (mapConv, *mapPlan, error) {
+	dk, sk := dst.Kind(), src.Kind()
+	switch {
+	case dst == src:
+		return func(d, s Value, _ int) error { return d.Set(s) }, nil, nil
+
+	case isNumberKind(dk) && isNumberKind(sk):
+		return func(d, s Value, _ int) error { return assignNumber(d, s) }, nil, nil
+
+	case dk == K.String && sk == K.String:
+		return func(d, s Value, _ int) error { return d.SetString(s.String()) }, nil, nil
+
+	case dk == K.Bool && sk == K.Bool:
+		return func(d, s Value, _ int) error {
+			x, _ := s.Bool()
+			return d.SetBool(x)
+		}, nil, nil
+
+	case sk == K.Pointer:
+		elem, sub, err := b.conv(dst, src.Elem())
+		if err != nil {
+			return nil, nil, err
+		}
+		return func(d, s Value, depth int) error {
+			if s.IsZero() {
+				return d.SetZero()
+			}
+			e, err := s.Elem()
+			if err != nil {
+				return err
+			}
+			return elem(d, e, depth+1)
+		}, sub, nil
+
+	case dk == K.Pointer:
+		elem, sub, err := b.conv(dst.Elem(), src)
+		if err != nil {
+			return nil, nil, err
+		}
+		return pointerConv(elem), sub, nil
+
+	case dk == K.Struct && sk == K.Struct:
+		plan, err := b.plan(dst, src)
+		if err != nil {
+			return nil, nil, err
+		}
+		return plan.apply, plan, nil
+
+	case dk == K.Slice && (sk == K.Slice || sk == K.Array):
+		elem, sub, err := b.conv(dst.Elem(), src.Elem())
+		if err != nil {
+			return nil, nil, err
+		}
+		return func(d, s Value, depth int) error {
+			if sk == K.Slice && s.IsZero() {
+				return d.SetZero()
+			}
+			n, _ := s.Len()
+			out, err := MakeSlice(d.Type(), n, n)
+			if err != nil {
+				return err
+			}
+			if err := convElems(out, s, n, elem, depth); err != nil {
+				return err
+			}
+			return d.Set(out)
+		}, sub, nil
+
+	case dk == K.Array && (sk == K.Slice || sk == K.Array):
+		elem, sub, err := b.conv(dst.Elem(), src.Elem())
+		if err != nil {
+			return nil, nil, err
+		}
+		return func(d, s Value, depth int) error {
+			n, _ := s.Len()
+			if dn, _ := d.Len(); n > dn {
+				return newValueError("Map", K.Array, ErrOutOfRange)
+			}
+			if err := d.SetZero(); err != nil {
+				return err
+			}
+			return convElems(d, s, n, elem, depth)
+		}, sub, nil
+
+	case dk == K.Map && sk == K.Map && dst.Key() == src.Key():
+		elem, sub, err := b.conv(dst.Elem(), src.Elem())
+		if err != nil {
+			return nil, nil, err
+		}
+		return func(d, s Value, depth int) error {
+			if s.IsZero() {
+				return d.SetZero()
+			}
+			n, _ := s.Len()
+			out, err := MakeMapWithSize(d.Type(), n)
+			if err != nil {
+				return err
+			}
+			iter, err := s.MapRange()
+			if err != nil {
+				return err
+			}
+			for iter.Next() {
+				e, err := NewValue(d.Type().Elem()).Elem()
+				if err != nil {
+					return err
+				}
+				if err := elem(e, iter.Value(), depth+1); err != nil {
+					return withPath(err, keyPath("", FromValue(iter.Key())))
+				}
+				if err := out.SetMapIndex(iter.Key(), e); err != nil {
+					return err
+				}
+			}
+			return d.Set(out)
+		}, sub, nil
+	}
+	return nil, nil, newValueError("Map", dk, ErrTypeMismatch)
+}
+
+// pointerConv wraps elem so that it fills a newly allocated pointee.
+func pointerConv(elem mapConv) mapConv {
+	return func(d, s Value, depth int) error {
+		p := NewValue(d.Type().Elem())
+		e, err := p.Elem()
+		if err != nil {
+			return err
+		}
+		if err := elem(e, s, depth+1); err != nil {
+			return err
+		}
+		return d.Set(p)
+	}
+}
+
+// convElems converts the first n elements of s into d.
+func convElems(d, s Value, n int, elem mapConv, depth int) error {
+	for i := 0; i < n; i++ {
+		to, err := d.Index(i)
+		if err != nil {
+			return err
+		}
+		from, err := s.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := elem(to, from, depth+1); err != nil {
+			return withPath(err, indexSegment(i))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+type mapperAddress struct {
+	Street string
+	City   string
+}
+
+type mapperUserDTO struct {
+	ID      int64
+	Name    string `json:"full_name"`
+	Age     uint8
+	Score   float64
+	Address *mapperAddress
+	Tags    []string
+	Counts  map[string]int64
+	Secret  string
+}
+
+type mapperUser struct {
+	ID      int32
+	Name    string `json:"full_name"`
+	Age     int
+	Score   float32
+	Address mapperAddressModel
+	Tags    [2]string
+	Counts  map[string]int8
+	Email   string
+	Secret  string `json:"-"`
+}
+
+type mapperAddressModel struct {
+	Street string
+	City   *string
+	Zip    string
+}
+
+type mapperTree struct {
+	Name     string
+	Children []*mapperTree
+}
+
+type mapperTreeModel struct {
+	Name     string
+	Children []mapperTreeModel
+	Depth    int
+}
+
+func TestMap(t *testing.T) {
+	src := mapperUserDTO{
+		ID:      7,
+		Name:    "Ann",
+		Age:     30,
+		Score:   1.5,
+		Address: &mapperAddress{Street: "Main", City: "Oslo"},
+		Tags:    []string{"a"},
+		Counts:  map[string]int64{"x": 3},
+		Secret:  "s",
+	}
+	var dst mapperUser
+	unmapped, err := tinyreflect.Map(&dst, src, tinyreflect.MapOptions{TagKey: "json"})
+	if err != nil {
+		t.Fatalf("Map: %v", err)
+	}
+	city := "Oslo"
+	want := mapperUser{
+		ID:      7,
+		Name:    "Ann",
+		Age:     30,
+		Score:   1.5,
+		Address: mapperAddressModel{Street: "Main", City: &city},
+		Tags:    [2]string{"a"},
+		Counts:  map[string]int8{"x": 3},
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("got  %+v\nwant %+v", dst, want)
+	}
+	if w := []string{"Address.Zip", "Email"}; !reflect.DeepEqual(unmapped, w) {
+		t.Errorf("unmapped %q, want %q", unmapped, w)
+	}
+
+	// The cached plan gives the same result, and the list is a fresh copy
+	unmapped[0] = "changed"
+	var again mapperUser
+	unmapped, err = tinyreflect.Map(&again, &src, tinyreflect.MapOptions{TagKey: "json"})
+	if err != nil || !reflect.DeepEqual(again, want) || unmapped[0] != "Address.Zip" {
+		t.Errorf("cached: %+v %q %v", again, unmapped, err)
+	}
+}
+
+func TestMapOptions(t *testing.T) {
+	type from struct {
+		Title string `db:"title"`
+		Body  string
+	}
+	type to struct {
+		Name string `db:"title"`
+		Text string
+		Body string
+	}
+	src := from{Title: "t", Body: "b"}
+
+	tests := []struct {
+		name     string
+		opts     tinyreflect.MapOptions
+		want     to
+		unmapped []string
+	}{
+		{"go names", tinyreflect.MapOptions{}, to{Body: "b"}, []string{"Name", "Text"}},
+		{"tag", tinyreflect.MapOptions{TagKey: "db"}, to{Name: "t", Body: "b"}, []string{"Text"}},
+		{
+			"rename",
+			tinyreflect.MapOptions{Rename: map[string]string{"Text": "Body", "Name": "Title", "Body": "-"}},
+			to{Name: "t", Text: "b"},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dst to
+			unmapped, err := tinyreflect.Map(&dst, src, tt.opts)
+			if err != nil {
+				t.Fatalf("Map: %v", err)
+			}
+			if dst != tt.want || !reflect.DeepEqual(unmapped, tt.unmapped) {
+				t.Errorf("got %+v %q, want %+v %q", dst, unmapped, tt.want, tt.unmapped)
+			}
+		})
+	}
+}
+
+func TestMapRecursive(t *testing.T) {
+	src := mapperTree{Name: "root", Children: []*mapperTree{{Name: "leaf"}, nil}}
+	var dst mapperTreeModel
+	unmapped, err := tinyreflect.Map(&dst, &src, tinyreflect.MapOptions{})
+	if err != nil {
+		t.Fatalf("Map: %v", err)
+	}
+	want := mapperTreeModel{Name: "root", Children: []mapperTreeModel{{Name: "leaf"}, {}}}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("got  %+v\nwant %+v", dst, want)
+	}
+	if !reflect.DeepEqual(unmapped, []string{"Depth"}) {
+		t.Errorf("unmapped %q", unmapped)
+	}
+}
+
+func TestMapSharedPlan(t *testing.T) {
+	type from struct{ Home, Work mapperAddress }
+	type to struct{ Home, Work mapperAddressModel }
+	var dst to
+	unmapped, err := tinyreflect.Map(&dst, from{Work: mapperAddress{Street: "Dock"}}, tinyreflect.MapOptions{})
+	if err != nil {
+		t.Fatalf("Map: %v", err)
+	}
+	if dst.Work.Street != "Dock" {
+		t.Errorf("got %+v", dst)
+	}
+	if w := []string{"Home.Zip", "Work.Zip"}; !reflect.DeepEqual(unmapped, w) {
+		t.Errorf("unmapped %q, want %q", unmapped, w)
+	}
+}
+
+func TestMapCycle(t *testing.T) {
+	type node struct {
+		N    int
+		Next *node
+	}
+	type nodeModel struct {
+		N    int
+		Next *nodeModel
+	}
+	a := &node{N: 1}
+	a.Next = a
+	_, err := tinyreflect.Map(&nodeModel{}, a, tinyreflect.MapOptions{})
+	if !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+		t.Errorf("self-referential source: got %v", err)
+	}
+}
+
+func TestMapErrors(t *testing.T) {
+	var ve *tinyreflect.ValueError
+
+	var big mapperUser
+	_, err := tinyreflect.Map(&big, mapperUserDTO{ID: 1 << 40}, tinyreflect.MapOptions{})
+	if !errors.Is(err, tinyreflect.ErrOverflow) || !errors.As(err, &ve) || ve.Path != "ID" {
+		t.Errorf("overflow: got %v", err)
+	}
+
+	var short mapperUser
+	_, err = tinyreflect.Map(&short, mapperUserDTO{Tags: []string{"a", "b", "c"}}, tinyreflect.MapOptions{})
+	if !errors.Is(err, tinyreflect.ErrOutOfRange) || !errors.As(err, &ve) || ve.Path != "Tags" {
+		t.Errorf("array too short: got %v", err)
+	}
+
+	type badFrom struct{ Address struct{ City []int } }
+	_, err = tinyreflect.Map(&mapperUser{}, badFrom{}, tinyreflect.MapOptions{})
+	if !errors.Is(err, tinyreflect.ErrTypeMismatch) || !errors.As(err, &ve) || ve.Path != "Address.City" {
+		t.Errorf("mismatch: got %v", err)
+	}
+
+	if _, err := tinyreflect.Map(mapperUser{}, mapperUserDTO{}, tinyreflect.MapOptions{}); !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+		t.Errorf("non-pointer dst: got %v", err)
+	}
+	n := 1
+	if _, err := tinyreflect.Map(&n, mapperUserDTO{}, tinyreflect.MapOptions{}); !errors.Is(err, tinyreflect.ErrNotStruct) {
+		t.Errorf("non-struct dst: got %v", err)
+	}
+}
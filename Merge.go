@@ -0,0 +1,206 @@
+package tinyreflect
+
+import . "github.com/cdvelop/tinystring"
+
+// MergeStrategy decides what Merge does with a field that is set in src.
+type MergeStrategy uint8
+
+const (
+	// MergeOverwrite replaces the destination value. It is the default.
+	MergeOverwrite MergeStrategy = iota
+	// MergeKeep leaves a destination value that is already set.
+	MergeKeep
+	// MergeAppend appends slices and adds map entries, overwriting the
+	// entries whose key is in both; other kinds are overwritten.
+	MergeAppend
+)
+
+// MergeOptions configures Merge.
+type MergeOptions struct {
+	// Strategy applies to the fields without a merge tag.
+	Strategy MergeStrategy
+}
+
+// Merge copies the fields of src that are not zero into dst, which must
+// point to a struct of src's type; src may be that struct or a pointer to
+// it. Nested structs are merged field by field and pointers through
+// their pointees, whatever the strategy; the strategy decides only for
+// the other kinds. Merge never writes memory dst shares with other
+// values: pointees are merged into a fresh copy and appended slices and
+// maps are built anew, so a shallow copy of a defaults struct can be
+// merged into without changing it. The tag `merge:"overwrite"`,
+// `merge:"keep"` or `merge:"append"` picks the strategy of a field, and
+// opts.Strategy that of untagged fields; `merge:"-"` leaves a field out.
+// Unexported fields are left alone.
+func Merge(dst, src any, opts MergeOptions) error {
+	dv := ValueOf(dst)
+	if dv.Kind() != K.Pointer || dv.IsZero() {
+		return newValueError("Merge", dv.Kind(), ErrInvalidArgument)
+	}
+	dv, err := dv.Elem()
+	if err != nil {
+		return err
+	}
+	sv := ValueOf(src)
+	if sv.Kind() == K.Pointer && sv.Type() != dv.Type() {
+		if sv.IsZero() {
+			return nil
+		}
+		if sv, err = sv.Elem(); err != nil {
+			return err
+		}
+	}
+	if sv.Type() != dv.Type() {
+		return newValueError("Merge", sv.Kind(), ErrTypeMismatch)
+	}
+	if dv.Kind() != K.Struct {
+		return newValueError("Merge", dv.Kind(), ErrNotStruct)
+	}
+	return mergeStruct(dv, sv, opts.Strategy, 0)
+}
+
+// mergeTag returns the strategy the merge tag names, ok false for "-".
+func mergeTag(tag string, def MergeStrategy) (MergeStrategy, bool) {
+	switch tag {
+	case "overwrite":
+		return MergeOverwrite, true
+	case "keep":
+		return MergeKeep, true
+	case "append":
+		return MergeAppend, true
+	case "-":
+		return def, false
+	}
+	return def, true
+}
+
+func mergeStruct(dst, src Value, def MergeStrategy, depth int) error {
+	t := src.Type()
+	n, err := t.NumField()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		sf, err := t.Field(i)
+		if err != nil {
+			return err
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		strategy, ok := mergeTag(sf.Tag().Get("merge"), def)
+		if !ok {
+			continue
+		}
+		to, err := dst.Field(i)
+		if err != nil {
+			return err
+		}
+		from, err := src.Field(i)
+		if err != nil {
+			return err
+		}
+		if err := mergeValue(to, from, strategy, def, depth+1); err != nil {
+			return withPath(err, sf.Name.String())
+		}
+	}
+	return nil
+}
+
+// mergeValue merges src into dst with the field's strategy; def is passed
+// on to the fields of nested structs.
+func mergeValue(dst, src Value, strategy, def MergeStrategy, depth int) error {
+	if depth > maxMapDepth {
+		return newValueError("Merge", src.Kind(), ErrInvalidArgument)
+	}
+	if src.IsZero() {
+		return nil
+	}
+	switch src.Kind() {
+	case K.Struct:
+		return mergeStruct(dst, src, def, depth)
+
+	case K.Pointer:
+		// Merge into a copy of the pointee so values sharing it keep theirs
+		p := NewValue(src.Type().Elem())
+		to, err := p.Elem()
+		if err != nil {
+			return err
+		}
+		if !dst.IsZero() {
+			cur, err := dst.Elem()
+			if err != nil {
+				return err
+			}
+			if err := to.Set(cur); err != nil {
+				return err
+			}
+		}
+		from, err := src.Elem()
+		if err != nil {
+			return err
+		}
+		if err := mergeValue(to, from, strategy, def, depth+1); err != nil {
+			return err
+		}
+		return dst.Set(p)
+	}
+
+	if strategy == MergeKeep && !dst.IsZero() {
+		return nil
+	}
+	if strategy == MergeAppend && !dst.IsZero() {
+		switch src.Kind() {
+		case K.Slice:
+			return appendSlice(dst, src)
+		case K.Map:
+			return appendMap(dst, src)
+		}
+	}
+	return dst.Set(src)
+}
+
+// appendSlice sets dst to a new slice holding the elements of dst followed
+// by those of src, so neither backing array is written.
+func appendSlice(dst, src Value) error {
+	n, _ := dst.Len()
+	m, _ := src.Len()
+	out, err := MakeSlice(dst.Type(), n+m, n+m)
+	if err != nil {
+		return err
+	}
+	if _, err := Copy(out, dst); err != nil {
+		return err
+	}
+	tail, err := out.Slice(n, n+m)
+	if err != nil {
+		return err
+	}
+	if _, err := Copy(tail, src); err != nil {
+		return err
+	}
+	return dst.Set(out)
+}
+
+// appendMap sets dst to a new map holding the entries of dst and then
+// those of src, so the map dst held is not written.
+func appendMap(dst, src Value) error {
+	n, _ := dst.Len()
+	m, _ := src.Len()
+	out, err := MakeMapWithSize(dst.Type(), n+m)
+	if err != nil {
+		return err
+	}
+	for _, from := range []Value{dst, src} {
+		iter, err := from.MapRange()
+		if err != nil {
+			return err
+		}
+		for iter.Next() {
+			if err := out.SetMapIndex(iter.Key(), iter.Value()); err != nil {
+				return withPath(err, keyPath("", FromValue(iter.Key())))
+			}
+		}
+	}
+	return dst.Set(out)
+}
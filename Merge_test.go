@@ -0,0 +1,122 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+type mergeDB struct {
+	Host string
+	Port int
+}
+
+type mergeConfig struct {
+	Name    string
+	Debug   bool
+	Timeout *int
+	DB      *mergeDB
+	Plugins []string          `merge:"append"`
+	Labels  map[string]string `merge:"append"`
+	Owner   string            `merge:"keep"`
+	Hosts   []string
+	Local   string `merge:"-"`
+	secret  string
+}
+
+func TestMerge(t *testing.T) {
+	timeout := 30
+	defaults := mergeConfig{
+		Name:    "app",
+		DB:      &mergeDB{Host: "localhost", Port: 5432},
+		Plugins: []string{"log"},
+		Labels:  map[string]string{"env": "dev", "team": "core"},
+		Owner:   "ops",
+		Hosts:   []string{"a"},
+		Local:   "l",
+	}
+	user := mergeConfig{
+		Debug:   true,
+		Timeout: &timeout,
+		DB:      &mergeDB{Port: 6543},
+		Plugins: []string{"auth"},
+		Labels:  map[string]string{"env": "prod"},
+		Owner:   "me",
+		Hosts:   []string{"b", "c"},
+		Local:   "x",
+		secret:  "s",
+	}
+	dst := defaults
+	if err := tinyreflect.Merge(&dst, &user, tinyreflect.MergeOptions{}); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	want := mergeConfig{
+		Name:    "app",
+		Debug:   true,
+		Timeout: &timeout,
+		DB:      &mergeDB{Host: "localhost", Port: 6543},
+		Plugins: []string{"log", "auth"},
+		Labels:  map[string]string{"env": "prod", "team": "core"},
+		Owner:   "ops",
+		Hosts:   []string{"b", "c"},
+		Local:   "l",
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("got  %+v\nwant %+v", dst, want)
+	}
+	if dst.Timeout == user.Timeout || dst.DB == user.DB {
+		t.Error("pointers shared with src instead of allocated")
+	}
+	if !reflect.DeepEqual(defaults.Plugins, []string{"log"}) {
+		t.Errorf("append wrote to the source slice: %q", defaults.Plugins)
+	}
+	if *defaults.DB != (mergeDB{Host: "localhost", Port: 5432}) {
+		t.Errorf("merge wrote through the shared pointer: %+v", *defaults.DB)
+	}
+	if !reflect.DeepEqual(defaults.Labels, map[string]string{"env": "dev", "team": "core"}) {
+		t.Errorf("append wrote to the shared map: %v", defaults.Labels)
+	}
+}
+
+func TestMergeStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy tinyreflect.MergeStrategy
+		want     mergeConfig
+	}{
+		{"overwrite", tinyreflect.MergeOverwrite, mergeConfig{Name: "b", Hosts: []string{"y"}, Owner: "o", DB: &mergeDB{Host: "y", Port: 5}}},
+		{"keep", tinyreflect.MergeKeep, mergeConfig{Name: "a", Hosts: []string{"x"}, Owner: "o", DB: &mergeDB{Host: "x", Port: 5}}},
+		{"append", tinyreflect.MergeAppend, mergeConfig{Name: "b", Hosts: []string{"x", "y"}, Owner: "o", DB: &mergeDB{Host: "y", Port: 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := mergeConfig{Name: "a", Hosts: []string{"x"}, DB: &mergeDB{Host: "x"}}
+			src := mergeConfig{Name: "b", Hosts: []string{"y"}, Owner: "o", DB: &mergeDB{Host: "y", Port: 5}}
+			if err := tinyreflect.Merge(&dst, src, tinyreflect.MergeOptions{Strategy: tt.strategy}); err != nil {
+				t.Fatalf("Merge: %v", err)
+			}
+			if !reflect.DeepEqual(dst, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", dst, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	var cfg mergeConfig
+	if err := tinyreflect.Merge(cfg, cfg, tinyreflect.MergeOptions{}); !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+		t.Errorf("non-pointer dst: got %v", err)
+	}
+	if err := tinyreflect.Merge(&cfg, mergeDB{}, tinyreflect.MergeOptions{}); !errors.Is(err, tinyreflect.ErrTypeMismatch) {
+		t.Errorf("other type: got %v", err)
+	}
+	n := 1
+	if err := tinyreflect.Merge(&n, 2, tinyreflect.MergeOptions{}); !errors.Is(err, tinyreflect.ErrNotStruct) {
+		t.Errorf("non-struct: got %v", err)
+	}
+	if err := tinyreflect.Merge(&cfg, (*mergeConfig)(nil), tinyreflect.MergeOptions{}); err != nil {
+		t.Errorf("nil src: got %v", err)
+	}
+}
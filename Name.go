@@ -6,48 +6,11 @@ type Name struct {
 	Bytes *byte
 }
 
-// IsExported reports whether the name is exported.
-func (n Name) IsExported() bool {
-	// In tinygo, we can't easily check for unexported names,
-	// for now we assume all are exported.
-	return true
-}
-
 // String returns the name as a string.
 func (n Name) String() string {
 	return n.Name()
 }
 
-// IsEmbedded returns true iff n is embedded (an anonymous field).
-func (n Name) IsEmbedded() bool {
-	return (*n.Bytes)&(1<<3) != 0
-}
-
-// Name returns the tag string for n, or empty if there is none.
-func (n Name) Name() string {
-	// Add safety check - if Bytes is nil or invalid, return empty
-	if n.Bytes == nil {
-		return ""
-	}
-
-	// Perform bounds checking before accessing memory
-	// In TinyGo/WASM, we can't easily check if a pointer is valid
-	// So we'll just try to read and hope for the best
-	// If it crashes, that's a TinyGo limitation we need to document
-
-	i, l := n.ReadVarint(1)
-	if l <= 0 || l > 1000 { // Sanity check on length
-		return ""
-	}
-
-	dataPtr := n.DataChecked(1+i, "non-empty string")
-	if dataPtr == nil {
-		return ""
-	}
-
-	return unsafe.String(dataPtr, l)
-}
-
 // ReadVarint parses a varint as encoded by encoding/binary.
 // It returns the number of encoded bytes and the encoded value.
 func (n Name) ReadVarint(off int) (int, int) {
@@ -67,22 +30,6 @@ func (n Name) DataChecked(off int, whySafe string) *byte {
 	return (*byte)(addChecked(unsafe.Pointer(n.Bytes), uintptr(off), whySafe))
 }
 
-// HasTag returns true iff there is tag data following this name
-func (n Name) HasTag() bool {
-	return (*n.Bytes)&(1<<1) != 0
-}
-
-// Tag returns the tag string for n, or empty if there is none.
-func (n Name) Tag() string {
-	if !n.HasTag() {
-		return ""
-	}
-	i, l := n.ReadVarint(1)
-	// Skip name
-	i2, l2 := n.ReadVarint(1 + i + l)
-	return unsafe.String(n.DataChecked(1+i+l+i2, "tag string"), l2)
-}
-
 // addChecked returns p+x.
 //
 // The whySafe string is ignored, so that the function still inlines
@@ -0,0 +1,51 @@
+//go:build !tinygo
+
+package tinyreflect
+
+import "unsafe"
+
+// IsExported reports whether the name is exported.
+func (n Name) IsExported() bool {
+	return n.Bytes != nil && (*n.Bytes)&(1<<0) != 0
+}
+
+// IsEmbedded returns true iff n is embedded (an anonymous field).
+func (n Name) IsEmbedded() bool {
+	return n.Bytes != nil && (*n.Bytes)&(1<<3) != 0
+}
+
+// Name returns the name string for n, or empty if there is none.
+func (n Name) Name() string {
+	// Add safety check - if Bytes is nil or invalid, return empty
+	if n.Bytes == nil {
+		return ""
+	}
+
+	i, l := n.ReadVarint(1)
+	if l <= 0 || l > 1000 { // Sanity check on length
+		return ""
+	}
+
+	dataPtr := n.DataChecked(1+i, "non-empty string")
+	if dataPtr == nil {
+		return ""
+	}
+
+	return unsafe.String(dataPtr, l)
+}
+
+// HasTag returns true iff there is tag data following this name
+func (n Name) HasTag() bool {
+	return n.Bytes != nil && (*n.Bytes)&(1<<1) != 0
+}
+
+// Tag returns the tag string for n, or empty if there is none.
+func (n Name) Tag() string {
+	if !n.HasTag() {
+		return ""
+	}
+	i, l := n.ReadVarint(1)
+	// Skip name
+	i2, l2 := n.ReadVarint(1 + i + l)
+	return unsafe.String(n.DataChecked(1+i+l+i2, "tag string"), l2)
+}
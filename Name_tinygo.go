@@ -0,0 +1,63 @@
+//go:build tinygo
+
+package tinyreflect
+
+import "unsafe"
+
+// On TinyGo, Bytes points at a struct field's packed data: a flags byte,
+// the field offset as a uvarint, the NUL-terminated name and, when the
+// field has a tag, its length byte and the tag itself.
+
+// flags returns the field flags byte, or 0 if n is empty.
+func (n Name) flags() byte {
+	if n.Bytes == nil {
+		return 0
+	}
+	return *n.Bytes
+}
+
+// IsExported reports whether the name is exported.
+func (n Name) IsExported() bool {
+	return n.flags()&structFieldFlagIsExported != 0
+}
+
+// IsEmbedded returns true iff n is embedded (an anonymous field).
+func (n Name) IsEmbedded() bool {
+	return n.flags()&structFieldFlagAnonymous != 0
+}
+
+// HasTag returns true iff there is tag data following this name
+func (n Name) HasTag() bool {
+	return n.flags()&structFieldFlagHasTag != 0
+}
+
+// nameData returns the start and length of the NUL-terminated name.
+func (n Name) nameData() (*byte, int) {
+	_, lenOffs := uvarint32(unsafe.Slice(n.DataChecked(1, "offset follows flags"), maxVarintLen32))
+	p := n.DataChecked(1+lenOffs, "name follows offset")
+	l := 0
+	for *(*byte)(unsafe.Add(unsafe.Pointer(p), l)) != 0 {
+		l++
+	}
+	return p, l
+}
+
+// Name returns the field name, or empty if there is none.
+func (n Name) Name() string {
+	if n.Bytes == nil {
+		return ""
+	}
+	p, l := n.nameData()
+	return unsafe.String(p, l)
+}
+
+// Tag returns the tag string for n, or empty if there is none.
+func (n Name) Tag() string {
+	if !n.HasTag() {
+		return ""
+	}
+	p, l := n.nameData()
+	// Skip the name and its NUL, then read the one-byte tag length
+	tag := unsafe.Add(unsafe.Pointer(p), l+1)
+	return unsafe.String((*byte)(unsafe.Add(tag, 1)), int(*(*byte)(tag)))
+}
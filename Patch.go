@@ -0,0 +1,701 @@
+package tinyreflect
+
+import (
+	"github.com/cdvelop/tinyreflect/internal/num"
+	. "github.com/cdvelop/tinystring"
+)
+
+var (
+	// ErrPathNotFound is returned when a patch path names a missing value.
+	ErrPathNotFound = &Error{[]any{D.Value, D.Not, D.Found}}
+	// ErrTestFailed is returned when a "test" patch operation does not match.
+	ErrTestFailed = &Error{[]any{D.Test, D.Failed}}
+)
+
+// PatchOp is one JSON Patch (RFC 6902) operation. Op is "add", "remove",
+// "replace", "move", "copy" or "test"; Path and From are JSON Pointers
+// (RFC 6901) such as "/items/0/price". Value is a Dynamic or any value
+// FromValue accepts, e.g. one decoded from JSON into an any; struct fields
+// in it are read by json tag or Go name.
+type PatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// MergePatch applies a JSON Merge Patch (RFC 7386) to the value dst points
+// to. patch is a Dynamic or any value FromValue accepts, such as a
+// map[string]any. Object members are merged recursively into struct fields,
+// matched by json tag or Go name, and into map entries; null clears a field
+// or deletes a map entry, and any other value replaces the target.
+// Members without a matching field are ignored.
+func MergePatch(dst any, patch any) error {
+	v, err := patchTarget("MergePatch", dst)
+	if err != nil {
+		return err
+	}
+	return mergePatch(v, toDynamic(patch), "", 0)
+}
+
+// ApplyPatch applies JSON Patch operations in order to the value dst
+// points to. Pointer tokens name struct fields by json tag or Go name, map
+// keys, and slice or array indexes, with "-" adding past the end of a
+// slice; nil pointers along an add or replace path are allocated. Moved
+// and copied values go through Dynamic, so unexported fields are not
+// carried. ApplyPatch stops at the first failing operation, leaving the
+// earlier ones applied. Errors carry the failing path in dotted form.
+func ApplyPatch(dst any, ops []PatchOp) error {
+	root, err := patchTarget("ApplyPatch", dst)
+	if err != nil {
+		return err
+	}
+	for _, op := range ops {
+		if err := applyOp(root, op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patchTarget returns the value a non-nil pointer points to.
+func patchTarget(method string, dst any) (Value, error) {
+	rv := ValueOf(dst)
+	if rv.Kind() != K.Pointer || rv.IsZero() {
+		return Value{}, newValueError(method, rv.Kind(), ErrInvalidArgument)
+	}
+	return rv.Elem()
+}
+
+// toDynamic returns x as a Dynamic, converting it unless it already is one.
+// Struct fields are keyed by json tag or Go name.
+func toDynamic(x any) Dynamic {
+	if d, ok := x.(Dynamic); ok {
+		return d
+	}
+	return fromValue(ValueOf(x), "json", 0)
+}
+
+func mergePatch(v Value, p Dynamic, path string, depth int) error {
+	if depth > maxMapDepth {
+		return &ValueError{Method: "MergePatch", Kind: v.Kind(), Path: path, Err: ErrInvalidArgument}
+	}
+	if p.Kind != DynMap {
+		return withPath(replaceWith(v, p), path)
+	}
+
+	switch v.Kind() {
+	case K.Pointer:
+		if v.IsZero() {
+			if err := v.Set(NewValue(v.Type().Elem())); err != nil {
+				return withPath(err, path)
+			}
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return err
+		}
+		return mergePatch(elem, p, path, depth+1)
+
+	case K.Interface:
+		cur := fromValue(v, "json", 0)
+		return withPath(mergeDynamic(cur, p).AssignTo(v), path)
+
+	case K.Struct:
+		fields, err := mapFields(v.Type(), "json")
+		if err != nil {
+			return err
+		}
+		for _, e := range p.Map {
+			if e.Key.Kind != DynString {
+				continue
+			}
+			for _, f := range fields {
+				if f.key != e.Key.Text {
+					continue
+				}
+				fv, err := v.Field(f.index)
+				if err != nil {
+					return err
+				}
+				if err := mergePatch(fv, e.Value, joinPath(path, f.key), depth+1); err != nil {
+					return err
+				}
+				break
+			}
+		}
+		return nil
+
+	case K.Map:
+		if v.IsZero() {
+			m, err := MakeMap(v.Type())
+			if err != nil {
+				return err
+			}
+			if err := v.Set(m); err != nil {
+				return withPath(err, path)
+			}
+		}
+		typ := v.Type()
+		for _, e := range p.Map {
+			seg := keyPath(path, e.Key)
+			key, err := dynamicKey(typ.Key(), e.Key)
+			if err != nil {
+				return withPath(err, seg)
+			}
+			if e.Value.Kind == DynNull {
+				if err := v.SetMapIndex(key, Value{}); err != nil {
+					return withPath(err, seg)
+				}
+				continue
+			}
+			elem, err := mapElemCopy(v, key)
+			if err != nil {
+				return withPath(err, seg)
+			}
+			if err := mergePatch(elem, e.Value, seg, depth+1); err != nil {
+				return err
+			}
+			if err := v.SetMapIndex(key, elem); err != nil {
+				return withPath(err, seg)
+			}
+		}
+		return nil
+	}
+	// A target that is not an object is replaced by the patch object
+	return withPath(replaceWith(v, p), path)
+}
+
+// mergeDynamic merges the patch p into target as RFC 7386 describes.
+func mergeDynamic(target, p Dynamic) Dynamic {
+	if p.Kind != DynMap {
+		return p
+	}
+	out := Dynamic{Kind: DynMap}
+	if target.Kind == DynMap {
+		out.Map = append(out.Map, target.Map...)
+	}
+	for _, e := range p.Map {
+		i := 0
+		for i < len(out.Map) && (out.Map[i].Key.Kind != e.Key.Kind || compareDynamic(out.Map[i].Key, e.Key) != 0) {
+			i++
+		}
+		switch {
+		case e.Value.Kind == DynNull:
+			if i < len(out.Map) {
+				out.Map = append(out.Map[:i], out.Map[i+1:]...)
+			}
+		case i < len(out.Map):
+			out.Map[i].Value = mergeDynamic(out.Map[i].Value, e.Value)
+		default:
+			out.Map = append(out.Map, DynamicEntry{e.Key, mergeDynamic(Dynamic{}, e.Value)})
+		}
+	}
+	return out
+}
+
+// replaceWith zeroes v and assigns d to it, so fields d leaves out are
+// cleared. Struct fields are matched by json tag or Go name.
+func replaceWith(v Value, d Dynamic) error {
+	if err := v.SetZero(); err != nil {
+		return err
+	}
+	return d.assignTo(v, "json", 0)
+}
+
+// mapElemCopy returns a settable copy of the map entry for key, or a zero
+// element when the key is missing.
+func mapElemCopy(m, key Value) (Value, error) {
+	elem, err := NewValue(m.Type().Elem()).Elem()
+	if err != nil {
+		return Value{}, err
+	}
+	cur, err := m.MapIndex(key)
+	if err != nil || cur.Kind() == K.Invalid {
+		return elem, err
+	}
+	return elem, elem.Set(cur)
+}
+
+// dynamicKey converts a patch member name into a key of type t. String
+// names are parsed when t is numeric, since JSON object keys are strings.
+func dynamicKey(t *Type, d Dynamic) (Value, error) {
+	if d.Kind == DynString && t.Kind() != K.String {
+		return textKey("MergePatch", t, d.Text)
+	}
+	key, err := NewValue(t).Elem()
+	if err != nil {
+		return Value{}, err
+	}
+	return key, d.AssignTo(key)
+}
+
+// textKey parses s into a map key of type t. Text that is not a valid
+// key cannot name an entry, so it reports ErrPathNotFound.
+func textKey(method string, t *Type, s string) (Value, error) {
+	key, err := NewValue(t).Elem()
+	if err != nil {
+		return Value{}, err
+	}
+	bits := int(t.Size() * 8)
+	switch k := t.Kind(); {
+	case k == K.String:
+		return key, key.SetString(s)
+	case isIntKind(k):
+		i, st := num.ParseInt(s, bits)
+		if st != num.OK {
+			return Value{}, newValueError(method, k, ErrPathNotFound)
+		}
+		return key, key.SetInt(i)
+	case isUintKind(k):
+		u, st := num.ParseUint(s, bits)
+		if st != num.OK {
+			return Value{}, newValueError(method, k, ErrPathNotFound)
+		}
+		return key, key.SetUint(u)
+	}
+	return Value{}, newValueError(method, t.Kind(), ErrUnsupportedKind)
+}
+
+func applyOp(root Value, op PatchOp) error {
+	tokens, err := parsePointer(op.Path)
+	if err != nil {
+		return err
+	}
+	switch op.Op {
+	case "add", "replace":
+		return setAt(root, tokens, toDynamic(op.Value), op.Op == "add")
+
+	case "remove":
+		return removeAt(root, tokens)
+
+	case "test":
+		v, err := getAt(root, tokens)
+		if err != nil {
+			return err
+		}
+		if !equalDynamic(fromValue(v, "json", 0), toDynamic(op.Value)) {
+			return &ValueError{Method: "ApplyPatch", Kind: v.Kind(), Path: dottedPath(tokens), Err: ErrTestFailed}
+		}
+		return nil
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return err
+		}
+		if op.Op == "move" && isPrefix(from, tokens) {
+			if len(from) == len(tokens) {
+				return nil
+			}
+			// A value cannot be moved into one of its own children
+			return &ValueError{Method: "ApplyPatch", Kind: root.Kind(), Path: dottedPath(tokens), Err: ErrInvalidArgument}
+		}
+		v, err := getAt(root, from)
+		if err != nil {
+			return err
+		}
+		d := fromValue(v, "json", 0)
+		if op.Op == "move" {
+			if err := removeAt(root, from); err != nil {
+				return err
+			}
+		}
+		return setAt(root, tokens, d, true)
+	}
+	return &ValueError{Method: "ApplyPatch", Kind: root.Kind(), Path: dottedPath(tokens), Err: ErrInvalidArgument}
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if p[0] != '/' {
+		return nil, &ValueError{Method: "ApplyPatch", Kind: K.String, Path: p, Err: ErrInvalidArgument}
+	}
+	var tokens []string
+	var tok []byte
+	for i := 1; i <= len(p); i++ {
+		switch {
+		case i == len(p) || p[i] == '/':
+			tokens = append(tokens, string(tok))
+			tok = tok[:0]
+		case p[i] == '~':
+			if i+1 == len(p) || p[i+1] != '0' && p[i+1] != '1' {
+				return nil, &ValueError{Method: "ApplyPatch", Kind: K.String, Path: p, Err: ErrInvalidArgument}
+			}
+			tok = append(tok, "~/"[p[i+1]-'0'])
+			i++
+		default:
+			tok = append(tok, p[i])
+		}
+	}
+	return tokens, nil
+}
+
+// dottedPath writes pointer tokens as a ValueError path: numeric tokens
+// become "[i]" and the others are joined with dots.
+func dottedPath(tokens []string) string {
+	path := ""
+	for _, tok := range tokens {
+		path = tokenPath(path, tok)
+	}
+	return path
+}
+
+func tokenPath(path, tok string) string {
+	if i, ok := parseIndex(tok); ok {
+		return path + indexSegment(i)
+	}
+	return joinPath(path, tok)
+}
+
+// parseIndex parses an array index token: decimal digits without a
+// leading zero.
+func parseIndex(tok string) (int, bool) {
+	if tok == "" || len(tok) > 1 && tok[0] == '0' {
+		return 0, false
+	}
+	for i := 0; i < len(tok); i++ {
+		if tok[i] < '0' || tok[i] > '9' {
+			return 0, false
+		}
+	}
+	u, st := num.ParseUint(tok, 31)
+	return int(u), st == num.OK
+}
+
+func isPrefix(prefix, tokens []string) bool {
+	if len(prefix) > len(tokens) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != tokens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// visitMode tells visitParent what it may change on the way down.
+type visitMode uint8
+
+const (
+	visitRead   visitMode = iota // only read; fn must not change parent
+	visitUpdate                  // store copies back; nil pointers are missing
+	visitCreate                  // store copies back and allocate nil pointers
+)
+
+// visitParent walks v along all tokens but the last and calls fn with the
+// container holding the last one. Unless mode is visitRead, copies taken
+// out of maps and interfaces are stored back once fn succeeds; only
+// visitCreate allocates nil pointers on the way.
+func visitParent(v Value, tokens []string, path string, mode visitMode, fn func(parent Value, tok, path string) error) error {
+	write := mode != visitRead
+	for v.Kind() == K.Pointer {
+		if v.IsZero() {
+			if mode != visitCreate {
+				return &ValueError{Method: "ApplyPatch", Kind: K.Pointer, Path: path, Err: ErrPathNotFound}
+			}
+			if err := v.Set(NewValue(v.Type().Elem())); err != nil {
+				return withPath(err, path)
+			}
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return err
+		}
+		v = elem
+	}
+
+	if v.Kind() == K.Interface {
+		if isNil, _ := v.IsNil(); isNil {
+			return &ValueError{Method: "ApplyPatch", Kind: K.Interface, Path: path, Err: ErrPathNotFound}
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return err
+		}
+		tmp, err := NewValue(elem.Type()).Elem()
+		if err != nil {
+			return err
+		}
+		if err := tmp.Set(elem); err != nil {
+			return err
+		}
+		if err := visitParent(tmp, tokens, path, mode, fn); err != nil || !write {
+			return err
+		}
+		return withPath(v.Set(tmp), path)
+	}
+
+	if len(tokens) == 1 {
+		return fn(v, tokens[0], tokenPath(path, tokens[0]))
+	}
+	tok, rest := tokens[0], tokens[1:]
+	seg := tokenPath(path, tok)
+
+	if v.Kind() == K.Map {
+		key, err := textKey("ApplyPatch", v.Type().Key(), tok)
+		if err != nil {
+			return withPath(err, seg)
+		}
+		cur, err := v.MapIndex(key)
+		if err != nil {
+			return err
+		}
+		if cur.Kind() == K.Invalid {
+			return &ValueError{Method: "ApplyPatch", Kind: K.Map, Path: seg, Err: ErrPathNotFound}
+		}
+		elem, err := mapElemCopy(v, key)
+		if err != nil {
+			return err
+		}
+		if err := visitParent(elem, rest, seg, mode, fn); err != nil || !write {
+			return err
+		}
+		return withPath(v.SetMapIndex(key, elem), seg)
+	}
+
+	child, err := childAt(v, tok, seg)
+	if err != nil {
+		return err
+	}
+	return visitParent(child, rest, seg, mode, fn)
+}
+
+// childAt returns the struct field or the slice or array element that tok
+// names in v. Map entries are handled by the callers.
+func childAt(v Value, tok, path string) (Value, error) {
+	switch v.Kind() {
+	case K.Struct:
+		fields, err := mapFields(v.Type(), "json")
+		if err != nil {
+			return Value{}, err
+		}
+		for _, f := range fields {
+			if f.key == tok {
+				return v.Field(f.index)
+			}
+		}
+	case K.Slice, K.Array:
+		n, _ := v.Len()
+		if i, ok := parseIndex(tok); ok && i < n {
+			return v.Index(i)
+		}
+	}
+	return Value{}, &ValueError{Method: "ApplyPatch", Kind: v.Kind(), Path: path, Err: ErrPathNotFound}
+}
+
+// getAt returns the value the pointer tokens name.
+func getAt(root Value, tokens []string) (Value, error) {
+	if len(tokens) == 0 {
+		return root, nil
+	}
+	var out Value
+	err := visitParent(root, tokens, "", visitRead, func(parent Value, tok, path string) error {
+		if parent.Kind() == K.Map {
+			key, err := textKey("ApplyPatch", parent.Type().Key(), tok)
+			if err != nil {
+				return withPath(err, path)
+			}
+			if out, err = parent.MapIndex(key); err == nil && out.Kind() == K.Invalid {
+				err = &ValueError{Method: "ApplyPatch", Kind: K.Map, Path: path, Err: ErrPathNotFound}
+			}
+			return err
+		}
+		var err error
+		out, err = childAt(parent, tok, path)
+		return err
+	})
+	return out, err
+}
+
+// setAt stores d at the pointer tokens. With insert set it adds a map
+// entry or inserts a slice element; otherwise the target must exist.
+func setAt(root Value, tokens []string, d Dynamic, insert bool) error {
+	if len(tokens) == 0 {
+		return replaceWith(root, d)
+	}
+	return visitParent(root, tokens, "", visitCreate, func(parent Value, tok, path string) error {
+		switch parent.Kind() {
+		case K.Map:
+			key, err := textKey("ApplyPatch", parent.Type().Key(), tok)
+			if err != nil {
+				return withPath(err, path)
+			}
+			if !insert {
+				if cur, _ := parent.MapIndex(key); cur.Kind() == K.Invalid {
+					return &ValueError{Method: "ApplyPatch", Kind: K.Map, Path: path, Err: ErrPathNotFound}
+				}
+			}
+			if parent.IsZero() {
+				m, err := MakeMap(parent.Type())
+				if err != nil {
+					return err
+				}
+				if err := parent.Set(m); err != nil {
+					return withPath(err, path)
+				}
+			}
+			elem, err := NewValue(parent.Type().Elem()).Elem()
+			if err != nil {
+				return err
+			}
+			if err := d.assignTo(elem, "json", 0); err != nil {
+				return withPath(err, path)
+			}
+			return withPath(parent.SetMapIndex(key, elem), path)
+
+		case K.Slice:
+			if !insert {
+				break
+			}
+			n, _ := parent.Len()
+			i, ok := n, tok == "-"
+			if !ok {
+				i, ok = parseIndex(tok)
+			}
+			if !ok || i > n {
+				return &ValueError{Method: "ApplyPatch", Kind: K.Slice, Path: path, Err: ErrOutOfRange}
+			}
+			if _, err := appendElem(parent); err != nil {
+				return withPath(err, path)
+			}
+			if i < n {
+				dst, _ := parent.Slice(i+1, n+1)
+				src, _ := parent.Slice(i, n)
+				if _, err := Copy(dst, src); err != nil {
+					return err
+				}
+			}
+			elem, err := parent.Index(i)
+			if err != nil {
+				return err
+			}
+			return withPath(replaceWith(elem, d), path)
+
+		case K.Array:
+			if insert {
+				// Arrays have a fixed length
+				return &ValueError{Method: "ApplyPatch", Kind: K.Array, Path: path, Err: ErrUnsupportedKind}
+			}
+		}
+		child, err := childAt(parent, tok, path)
+		if err != nil {
+			return err
+		}
+		return withPath(replaceWith(child, d), path)
+	})
+}
+
+// removeAt deletes the value at the pointer tokens: map entries are
+// deleted, slice elements are cut out and struct fields are zeroed. A nil
+// pointer on the path is ErrPathNotFound; nothing is allocated.
+func removeAt(root Value, tokens []string) error {
+	if len(tokens) == 0 {
+		return root.SetZero()
+	}
+	return visitParent(root, tokens, "", visitUpdate, func(parent Value, tok, path string) error {
+		switch parent.Kind() {
+		case K.Map:
+			key, err := textKey("ApplyPatch", parent.Type().Key(), tok)
+			if err != nil {
+				return withPath(err, path)
+			}
+			if cur, _ := parent.MapIndex(key); cur.Kind() == K.Invalid {
+				return &ValueError{Method: "ApplyPatch", Kind: K.Map, Path: path, Err: ErrPathNotFound}
+			}
+			return withPath(parent.SetMapIndex(key, Value{}), path)
+
+		case K.Slice:
+			n, _ := parent.Len()
+			i, ok := parseIndex(tok)
+			if !ok || i >= n {
+				return &ValueError{Method: "ApplyPatch", Kind: K.Slice, Path: path, Err: ErrPathNotFound}
+			}
+			dst, _ := parent.Slice(i, n-1)
+			src, _ := parent.Slice(i+1, n)
+			if _, err := Copy(dst, src); err != nil {
+				return err
+			}
+			last, err := parent.Index(n - 1)
+			if err != nil {
+				return err
+			}
+			if err := last.SetZero(); err != nil {
+				return err
+			}
+			return withPath(parent.SetLen(n-1), path)
+
+		case K.Array:
+			return &ValueError{Method: "ApplyPatch", Kind: K.Array, Path: path, Err: ErrUnsupportedKind}
+		}
+		child, err := childAt(parent, tok, path)
+		if err != nil {
+			return err
+		}
+		return withPath(child.SetZero(), path)
+	})
+}
+
+// equalDynamic reports whether a and b hold the same JSON value: numbers
+// compare by value whatever their kind, and maps ignore entry order.
+func equalDynamic(a, b Dynamic) bool {
+	if isDynNumber(a.Kind) && isDynNumber(b.Kind) {
+		switch {
+		case a.Kind == b.Kind:
+			return compareDynamic(a, b) == 0
+		case a.Kind == DynFloat || b.Kind == DynFloat:
+			x, _ := toFloat64(ValueOf(a.Interface()))
+			y, _ := toFloat64(ValueOf(b.Interface()))
+			return x == y
+		case a.Kind == DynInt:
+			return a.Int >= 0 && uint64(a.Int) == b.Uint
+		}
+		return b.Int >= 0 && uint64(b.Int) == a.Uint
+	}
+	if a.Kind == DynBytes && b.Kind == DynString || a.Kind == DynString && b.Kind == DynBytes {
+		return string(a.Bytes)+a.Text == string(b.Bytes)+b.Text
+	}
+	if a.Kind != b.Kind {
+		return false
+	}
+	switch a.Kind {
+	case DynBytes:
+		return string(a.Bytes) == string(b.Bytes)
+	case DynList:
+		if len(a.List) != len(b.List) {
+			return false
+		}
+		for i := range a.List {
+			if !equalDynamic(a.List[i], b.List[i]) {
+				return false
+			}
+		}
+		return true
+	case DynMap:
+		if len(a.Map) != len(b.Map) {
+			return false
+		}
+		for _, e := range a.Map {
+			found := false
+			for _, f := range b.Map {
+				if e.Key.Kind == f.Key.Kind && compareDynamic(e.Key, f.Key) == 0 {
+					found = equalDynamic(e.Value, f.Value)
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+	return compareDynamic(a, b) == 0
+}
+
+func isDynNumber(k DynamicKind) bool {
+	return k == DynInt || k == DynUint || k == DynFloat
+}
@@ -0,0 +1,221 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+type patchItem struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+type patchDoc struct {
+	Title  string               `json:"title"`
+	Count  int8                 `json:"count"`
+	Owner  *patchItem           `json:"owner"`
+	Items  []patchItem          `json:"items"`
+	Grid   [2]int               `json:"grid"`
+	Labels map[string]string    `json:"labels"`
+	Stock  map[int]patchItem    `json:"stock"`
+	Nested map[string]patchItem `json:"nested"`
+	Extra  any                  `json:"extra"`
+	Plain  string
+	Hidden string `json:"-"`
+}
+
+func TestMergePatch(t *testing.T) {
+	doc := patchDoc{
+		Title:  "a",
+		Count:  1,
+		Items:  []patchItem{{"x", 1}},
+		Labels: map[string]string{"env": "dev", "team": "core"},
+		Nested: map[string]patchItem{"k": {"n", 2}},
+		Extra:  map[string]any{"keep": "yes", "drop": "no"},
+		Hidden: "h",
+	}
+	patch := map[string]any{
+		"title":  "b",
+		"count":  nil,
+		"owner":  map[string]any{"name": "o"},
+		"items":  []any{map[string]any{"name": "y"}},
+		"labels": map[string]any{"env": "prod", "team": nil, "zone": "eu"},
+		"stock":  map[string]any{"7": map[string]any{"price": 3}},
+		"nested": map[string]any{"k": map[string]any{"price": 5}},
+		"extra":  map[string]any{"drop": nil, "add": true},
+		"Plain":  "p",
+		"Hidden": "ignored",
+		"other":  1,
+	}
+	if err := tinyreflect.MergePatch(&doc, patch); err != nil {
+		t.Fatalf("MergePatch: %v", err)
+	}
+	want := patchDoc{
+		Title:  "b",
+		Owner:  &patchItem{Name: "o"},
+		Items:  []patchItem{{Name: "y"}},
+		Labels: map[string]string{"env": "prod", "zone": "eu"},
+		Stock:  map[int]patchItem{7: {Price: 3}},
+		Nested: map[string]patchItem{"k": {"n", 5}},
+		Extra:  map[string]any{"keep": "yes", "add": true},
+		Plain:  "p",
+		Hidden: "h",
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("got  %+v\nwant %+v", doc, want)
+	}
+
+	// A Dynamic patch and a patch that is not an object
+	var d tinyreflect.Dynamic
+	d.Set("title", tinyreflect.Dynamic{Kind: tinyreflect.DynString, Text: "c"})
+	if err := tinyreflect.MergePatch(&doc, d); err != nil || doc.Title != "c" {
+		t.Errorf("Dynamic patch: title %q, err %v", doc.Title, err)
+	}
+	var title string
+	if err := tinyreflect.MergePatch(&title, "whole"); err != nil || title != "whole" {
+		t.Errorf("scalar patch: %q, %v", title, err)
+	}
+}
+
+func TestMergePatchErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		dst     any
+		patch   any
+		wantErr error
+		path    string
+	}{
+		{"Not a pointer", patchDoc{}, map[string]any{}, tinyreflect.ErrInvalidArgument, ""},
+		{"Overflow", &patchDoc{}, map[string]any{"count": 300}, tinyreflect.ErrOverflow, "count"},
+		{"Object into slice", &patchDoc{}, map[string]any{"items": map[string]any{"a": 1}}, tinyreflect.ErrTypeMismatch, "items"},
+		{"Bad map key", &patchDoc{}, map[string]any{"stock": map[string]any{"x": nil}}, tinyreflect.ErrPathNotFound, "stock.x"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tinyreflect.MergePatch(tc.dst, tc.patch)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got %v, want %v", err, tc.wantErr)
+			}
+			var ve *tinyreflect.ValueError
+			if !errors.As(err, &ve) || ve.Path != tc.path {
+				t.Errorf("got path %q, want %q", ve.Path, tc.path)
+			}
+		})
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	base := func() patchDoc {
+		return patchDoc{
+			Title:  "a",
+			Items:  []patchItem{{"x", 1}, {"y", 2}},
+			Labels: map[string]string{"a/b": "1", "m~n": "2"},
+			Nested: map[string]patchItem{"k": {"n", 2}},
+			Extra:  map[string]any{"list": []any{"p"}},
+		}
+	}
+	testCases := []struct {
+		name string
+		ops  []tinyreflect.PatchOp
+		want func(d *patchDoc)
+	}{
+		{"Replace field", []tinyreflect.PatchOp{{Op: "replace", Path: "/title", Value: "b"}},
+			func(d *patchDoc) { d.Title = "b" }},
+		{"Add allocates pointer", []tinyreflect.PatchOp{{Op: "add", Path: "/owner/name", Value: "o"}},
+			func(d *patchDoc) { d.Owner = &patchItem{Name: "o"} }},
+		{"Insert element", []tinyreflect.PatchOp{{Op: "add", Path: "/items/1", Value: map[string]any{"name": "z"}}},
+			func(d *patchDoc) { d.Items = []patchItem{{"x", 1}, {Name: "z"}, {"y", 2}} }},
+		{"Append element", []tinyreflect.PatchOp{{Op: "add", Path: "/items/-", Value: map[string]any{"price": 3}}},
+			func(d *patchDoc) { d.Items = append(d.Items, patchItem{Price: 3}) }},
+		{"Replace element clears fields", []tinyreflect.PatchOp{{Op: "replace", Path: "/items/0", Value: map[string]any{"price": 9}}},
+			func(d *patchDoc) { d.Items[0] = patchItem{Price: 9} }},
+		{"Remove element", []tinyreflect.PatchOp{{Op: "remove", Path: "/items/0"}},
+			func(d *patchDoc) { d.Items = []patchItem{{"y", 2}} }},
+		{"Escaped keys", []tinyreflect.PatchOp{
+			{Op: "remove", Path: "/labels/a~1b"},
+			{Op: "replace", Path: "/labels/m~0n", Value: "3"},
+		}, func(d *patchDoc) { d.Labels = map[string]string{"m~n": "3"} }},
+		{"Field inside map entry", []tinyreflect.PatchOp{{Op: "replace", Path: "/nested/k/price", Value: 7}},
+			func(d *patchDoc) { d.Nested["k"] = patchItem{"n", 7} }},
+		{"Int map key", []tinyreflect.PatchOp{{Op: "add", Path: "/stock/5", Value: map[string]any{"name": "s"}}},
+			func(d *patchDoc) { d.Stock = map[int]patchItem{5: {Name: "s"}} }},
+		{"Inside interface", []tinyreflect.PatchOp{{Op: "add", Path: "/extra/list/0", Value: "q"}},
+			func(d *patchDoc) { d.Extra = map[string]any{"list": []any{"q", "p"}} }},
+		{"Array element", []tinyreflect.PatchOp{{Op: "replace", Path: "/grid/1", Value: 4}},
+			func(d *patchDoc) { d.Grid[1] = 4 }},
+		{"Go name", []tinyreflect.PatchOp{{Op: "add", Path: "/Plain", Value: "p"}},
+			func(d *patchDoc) { d.Plain = "p" }},
+		{"Move", []tinyreflect.PatchOp{{Op: "move", From: "/items/0/name", Path: "/title"}},
+			func(d *patchDoc) { d.Title, d.Items[0].Name = "x", "" }},
+		{"Copy", []tinyreflect.PatchOp{{Op: "copy", From: "/items/1", Path: "/nested/c"}},
+			func(d *patchDoc) { d.Nested["c"] = patchItem{"y", 2} }},
+		{"Test passes", []tinyreflect.PatchOp{
+			{Op: "test", Path: "/items/1", Value: map[string]any{"price": 2.0, "name": "y"}},
+			{Op: "test", Path: "/count", Value: 0},
+			{Op: "replace", Path: "/count", Value: 5},
+		}, func(d *patchDoc) { d.Count = 5 }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, want := base(), base()
+			if err := tinyreflect.ApplyPatch(&got, tc.ops); err != nil {
+				t.Fatalf("ApplyPatch: %v", err)
+			}
+			tc.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got  %+v\nwant %+v", got, want)
+			}
+		})
+	}
+
+	var n int
+	if err := tinyreflect.ApplyPatch(&n, []tinyreflect.PatchOp{{Op: "replace", Path: "", Value: 3}}); err != nil || n != 3 {
+		t.Errorf("root replace: %d, %v", n, err)
+	}
+}
+
+func TestApplyPatchErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		op      tinyreflect.PatchOp
+		wantErr error
+		path    string
+	}{
+		{"Unknown op", tinyreflect.PatchOp{Op: "swap", Path: "/title"}, tinyreflect.ErrInvalidArgument, "title"},
+		{"Bad pointer", tinyreflect.PatchOp{Op: "remove", Path: "title"}, tinyreflect.ErrInvalidArgument, "title"},
+		{"Bad escape", tinyreflect.PatchOp{Op: "remove", Path: "/a~2"}, tinyreflect.ErrInvalidArgument, "/a~2"},
+		{"Unknown field", tinyreflect.PatchOp{Op: "replace", Path: "/nope", Value: 1}, tinyreflect.ErrPathNotFound, "nope"},
+		{"Hidden field", tinyreflect.PatchOp{Op: "replace", Path: "/Hidden", Value: "x"}, tinyreflect.ErrPathNotFound, "Hidden"},
+		{"Missing map key", tinyreflect.PatchOp{Op: "replace", Path: "/labels/zz", Value: "x"}, tinyreflect.ErrPathNotFound, "labels.zz"},
+		{"Remove missing key", tinyreflect.PatchOp{Op: "remove", Path: "/labels/zz"}, tinyreflect.ErrPathNotFound, "labels.zz"},
+		{"Index past end", tinyreflect.PatchOp{Op: "replace", Path: "/items/2", Value: nil}, tinyreflect.ErrPathNotFound, "items[2]"},
+		{"Insert past end", tinyreflect.PatchOp{Op: "add", Path: "/items/3", Value: nil}, tinyreflect.ErrOutOfRange, "items[3]"},
+		{"Leading zero", tinyreflect.PatchOp{Op: "remove", Path: "/items/01"}, tinyreflect.ErrPathNotFound, "items.01"},
+		{"Nil pointer", tinyreflect.PatchOp{Op: "test", Path: "/owner/name", Value: ""}, tinyreflect.ErrPathNotFound, "owner"},
+		{"Remove through nil pointer", tinyreflect.PatchOp{Op: "remove", Path: "/owner/name"}, tinyreflect.ErrPathNotFound, "owner"},
+		{"Add to array", tinyreflect.PatchOp{Op: "add", Path: "/grid/0", Value: 1}, tinyreflect.ErrUnsupportedKind, "grid[0]"},
+		{"Test fails", tinyreflect.PatchOp{Op: "test", Path: "/items/0/name", Value: "no"}, tinyreflect.ErrTestFailed, "items[0].name"},
+		{"Type mismatch", tinyreflect.PatchOp{Op: "replace", Path: "/items/0/price", Value: "x"}, tinyreflect.ErrTypeMismatch, "items[0].price"},
+		{"Move into child", tinyreflect.PatchOp{Op: "move", From: "/items", Path: "/items/0"}, tinyreflect.ErrInvalidArgument, "items[0]"},
+		{"Missing from", tinyreflect.PatchOp{Op: "copy", From: "/labels/zz", Path: "/title"}, tinyreflect.ErrPathNotFound, "labels.zz"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := patchDoc{Items: []patchItem{{"x", 1}, {"y", 2}}, Labels: map[string]string{}}
+			err := tinyreflect.ApplyPatch(&doc, []tinyreflect.PatchOp{tc.op})
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got %v, want %v", err, tc.wantErr)
+			}
+			var ve *tinyreflect.ValueError
+			if !errors.As(err, &ve) || ve.Path != tc.path {
+				t.Errorf("got path %q, want %q", ve.Path, tc.path)
+			}
+			if doc.Owner != nil {
+				t.Errorf("failed op allocated Owner: %+v", doc.Owner)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package tinyreflect
+
+import . "github.com/cdvelop/tinystring"
+
+// Lookup returns the value the path expression names inside root, e.g.
+// "Items[2].Price" or "Labels.env". Dotted names select struct fields, by
+// Go name or json tag name, or string map keys; "[i]" selects a slice or
+// array element or a map key of any scalar type. Pointers and interfaces
+// along the way are followed, and a nil one is ErrNilValue. Map entries
+// are returned as copies, so they cannot be set.
+func Lookup(root Value, path string) (Value, error) {
+	steps, err := parsePath("Lookup", path)
+	if err != nil {
+		return Value{}, err
+	}
+	w := pathWalker{method: "Lookup"}
+	if err := w.walk(root, steps, ""); err != nil {
+		return Value{}, err
+	}
+	return w.out, nil
+}
+
+// PathSetter sets deep values by path expression.
+type PathSetter struct {
+	// Grow extends a slice to reach an index past its length instead of
+	// returning ErrOutOfRange.
+	Grow bool
+}
+
+// SetPath stores x at path inside root with the default PathSetter.
+func SetPath(root Value, path string, x any) error {
+	return PathSetter{}.SetPath(root, path, x)
+}
+
+// SetPath stores x at the value path names inside root, using the
+// expressions Lookup accepts. root must be settable or a pointer. Nil
+// pointers and maps along the way are allocated and map entries are
+// written back after they change. x must have the target's type, fit an
+// interface target, or be a number that fits a numeric target; a pointer
+// target is followed unless x has its type, and nil sets the target to
+// its zero value.
+func (s PathSetter) SetPath(root Value, path string, x any) error {
+	steps, err := parsePath("SetPath", path)
+	if err != nil {
+		return err
+	}
+	w := pathWalker{method: "SetPath", write: true, grow: s.Grow, x: x}
+	return w.walk(root, steps, "")
+}
+
+// pathStep is one step of a path expression: a dotted name or the text
+// between brackets.
+type pathStep struct {
+	text    string
+	bracket bool
+}
+
+// parsePath splits a path expression such as "Items[2].Price" into steps.
+func parsePath(method, path string) ([]pathStep, error) {
+	var steps []pathStep
+	for i := 0; i < len(path); {
+		switch {
+		case path[i] == '[':
+			end := i + 1
+			for end < len(path) && path[end] != ']' {
+				end++
+			}
+			if end == len(path) || end == i+1 {
+				return nil, &ValueError{Method: method, Kind: K.String, Path: path, Err: ErrInvalidArgument}
+			}
+			steps = append(steps, pathStep{path[i+1 : end], true})
+			i = end + 1
+		case path[i] == '.' && i > 0 || i == 0:
+			if i > 0 {
+				i++
+			}
+			end := i
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			if end == i {
+				return nil, &ValueError{Method: method, Kind: K.String, Path: path, Err: ErrInvalidArgument}
+			}
+			steps = append(steps, pathStep{path[i:end], false})
+			i = end
+		default:
+			return nil, &ValueError{Method: method, Kind: K.String, Path: path, Err: ErrInvalidArgument}
+		}
+	}
+	return steps, nil
+}
+
+// pathWalker follows path steps, reading the value at the end or, with
+// write set, storing x there.
+type pathWalker struct {
+	method string
+	write  bool
+	grow   bool
+	x      any
+	out    Value
+}
+
+func (w *pathWalker) fail(k Kind, path string, err error) error {
+	return &ValueError{Method: w.method, Kind: k, Path: path, Err: err}
+}
+
+func (w *pathWalker) walk(v Value, steps []pathStep, path string) error {
+	if len(steps) == 0 {
+		if !w.write {
+			w.out = v
+			return nil
+		}
+		// A pointer target takes x itself when x is a pointer of its type
+		if v.Kind() != K.Pointer || w.x == nil || ValueOf(w.x).Type() == v.Type() {
+			return withPath(setAny(w.method, v, w.x), path)
+		}
+	}
+
+	switch v.Kind() {
+	case K.Pointer:
+		if v.IsZero() {
+			if !w.write {
+				return w.fail(K.Pointer, path, ErrNilValue)
+			}
+			if err := v.Set(NewValue(v.Type().Elem())); err != nil {
+				return withPath(err, path)
+			}
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return err
+		}
+		return w.walk(elem, steps, path)
+
+	case K.Interface:
+		if isNil, _ := v.IsNil(); isNil {
+			return w.fail(K.Interface, path, ErrNilValue)
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return err
+		}
+		if !w.write {
+			return w.walk(elem, steps, path)
+		}
+		// The dynamic value is not settable: change a copy and store it back
+		tmp, err := NewValue(elem.Type()).Elem()
+		if err != nil {
+			return err
+		}
+		if err := tmp.Set(elem); err != nil {
+			return err
+		}
+		if err := w.walk(tmp, steps, path); err != nil {
+			return err
+		}
+		return withPath(v.Set(tmp), path)
+	}
+
+	step, rest := steps[0], steps[1:]
+	seg := joinPath(path, step.text)
+	if step.bracket {
+		seg = path + "[" + step.text + "]"
+	}
+
+	switch k := v.Kind(); k {
+	case K.Struct:
+		if step.bracket {
+			break
+		}
+		i, ok := fieldByPathName(v.Type(), step.text)
+		if !ok {
+			break
+		}
+		field, err := v.Field(i)
+		if err != nil {
+			return withPath(err, seg)
+		}
+		return w.walk(field, rest, seg)
+
+	case K.Slice, K.Array:
+		if !step.bracket {
+			break
+		}
+		i, ok := parseIndex(step.text)
+		if !ok {
+			return w.fail(k, seg, ErrInvalidArgument)
+		}
+		if n, _ := v.Len(); i >= n {
+			if !w.write || !w.grow || k != K.Slice {
+				return w.fail(k, seg, ErrOutOfRange)
+			}
+			for ; n <= i; n++ {
+				if _, err := appendElem(v); err != nil {
+					return withPath(err, path)
+				}
+			}
+		}
+		elem, err := v.Index(i)
+		if err != nil {
+			return err
+		}
+		return w.walk(elem, rest, seg)
+
+	case K.Map:
+		key, err := textKey(w.method, v.Type().Key(), step.text)
+		if err != nil {
+			return withPath(err, seg)
+		}
+		if !w.write {
+			elem, err := v.MapIndex(key)
+			if err != nil {
+				return err
+			}
+			if elem.Kind() == K.Invalid {
+				return w.fail(k, seg, ErrPathNotFound)
+			}
+			return w.walk(elem, rest, seg)
+		}
+		if v.IsZero() {
+			m, err := MakeMap(v.Type())
+			if err != nil {
+				return err
+			}
+			if err := v.Set(m); err != nil {
+				return withPath(err, path)
+			}
+		}
+		elem, err := mapElemCopy(v, key)
+		if err != nil {
+			return err
+		}
+		if err := w.walk(elem, rest, seg); err != nil {
+			return err
+		}
+		return withPath(v.SetMapIndex(key, elem), seg)
+	}
+	return w.fail(v.Kind(), seg, ErrPathNotFound)
+}
+
+// fieldByPathName returns the index of the exported field of the struct
+// type t whose Go name or json tag name is name. Go names win.
+func fieldByPathName(t *Type, name string) (int, bool) {
+	n, _ := t.NumField()
+	tagged := -1
+	for i := 0; i < n; i++ {
+		sf, err := t.Field(i)
+		if err != nil || !sf.IsExported() {
+			continue
+		}
+		if sf.Name.String() == name {
+			return i, true
+		}
+		if tag, _ := ParseTag(sf.Tag().Get("json")); tag == name && tagged < 0 {
+			tagged = i
+		}
+	}
+	return tagged, tagged >= 0
+}
+
+// setAny stores x in the settable v. Numbers convert between kinds when
+// they fit; other values must have v's type unless v is an interface.
+func setAny(method string, v Value, x any) error {
+	xv := ValueOf(x)
+	switch {
+	case xv.Kind() == K.Invalid:
+		return v.SetZero()
+	case xv.Type() == v.Type() || v.Kind() == K.Interface:
+		return v.Set(xv)
+	case isNumberKind(v.Kind()) && isNumberKind(xv.Kind()):
+		return assignNumber(v, xv)
+	}
+	return newValueError(method, v.Kind(), ErrTypeMismatch)
+}
+
+func isNumberKind(k Kind) bool {
+	return isIntKind(k) || isUintKind(k) || k == K.Float32 || k == K.Float64
+}
@@ -0,0 +1,176 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+type pathItem struct {
+	SKU   string  `json:"sku"`
+	Price float64 `json:"price"`
+}
+
+type pathOrder struct {
+	ID     int
+	Items  []pathItem `json:"items"`
+	Owner  *pathItem
+	Labels map[string]string
+	Stock  map[int]*pathItem
+	Byname map[string]pathItem
+	Grid   [2]uint8
+	Extra  any
+	Tagged string `json:"ID"`
+	secret string
+}
+
+func TestLookup(t *testing.T) {
+	order := pathOrder{
+		ID:     7,
+		Items:  []pathItem{{"a", 1}, {"b", 2}, {"c", 3.5}},
+		Owner:  &pathItem{SKU: "o"},
+		Labels: map[string]string{"env": "dev"},
+		Stock:  map[int]*pathItem{4: {SKU: "s"}},
+		Grid:   [2]uint8{1, 2},
+		Extra:  map[string]any{"deep": []any{"x", "y"}},
+		Tagged: "tag",
+	}
+	testCases := []struct {
+		path string
+		want any
+	}{
+		{"ID", 7},
+		{"Items[2].Price", 3.5},
+		{"items[1].sku", "b"},
+		{"Owner.SKU", "o"},
+		{"Labels.env", "dev"},
+		{"Labels[env]", "dev"},
+		{"Stock[4].sku", "s"},
+		{"Grid[1]", uint8(2)},
+		{"Extra.deep[1]", "y"},
+		{"", order},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			v, err := tinyreflect.Lookup(tinyreflect.ValueOf(&order), tc.path)
+			if err != nil {
+				t.Fatalf("Lookup: %v", err)
+			}
+			if tc.path == "" {
+				v, _ = v.Elem()
+			}
+			got, err := v.Interface()
+			if err != nil {
+				t.Fatalf("Interface: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	var order pathOrder
+	root := tinyreflect.ValueOf(&order)
+	grow := tinyreflect.PathSetter{Grow: true}
+	steps := []struct {
+		set  tinyreflect.PathSetter
+		path string
+		x    any
+	}{
+		{tinyreflect.PathSetter{}, "ID", int8(3)},
+		{grow, "Items[1].Price", 9},
+		{grow, "items[0].sku", "first"},
+		{tinyreflect.PathSetter{}, "Owner.Price", float32(1.5)},
+		{tinyreflect.PathSetter{}, "Labels.env", "prod"},
+		{tinyreflect.PathSetter{}, "Stock[4].SKU", "s"},
+		{tinyreflect.PathSetter{}, "Byname.k.Price", 2},
+		{tinyreflect.PathSetter{}, "Grid[1]", 200},
+		{tinyreflect.PathSetter{}, "Extra", map[string]any{"list": []any{"a"}}},
+		{tinyreflect.PathSetter{}, "Extra.list[0]", "b"},
+	}
+	for _, s := range steps {
+		if err := s.set.SetPath(root, s.path, s.x); err != nil {
+			t.Fatalf("SetPath(%q): %v", s.path, err)
+		}
+	}
+	want := pathOrder{
+		ID:     3,
+		Items:  []pathItem{{SKU: "first"}, {Price: 9}},
+		Owner:  &pathItem{Price: 1.5},
+		Labels: map[string]string{"env": "prod"},
+		Stock:  map[int]*pathItem{4: {SKU: "s"}},
+		Byname: map[string]pathItem{"k": {Price: 2}},
+		Grid:   [2]uint8{0, 200},
+		Extra:  map[string]any{"list": []any{"b"}},
+	}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("got  %+v\nwant %+v", order, want)
+	}
+
+	// A pointer target takes a pointer of its type, and nil clears it
+	owner := &pathItem{SKU: "new"}
+	if err := tinyreflect.SetPath(root, "Owner", owner); err != nil || order.Owner != owner {
+		t.Errorf("set pointer: %v", err)
+	}
+	if err := tinyreflect.SetPath(root, "Owner", nil); err != nil || order.Owner != nil {
+		t.Errorf("set nil: %v", err)
+	}
+	if err := tinyreflect.SetPath(root, "Owner", pathItem{SKU: "v"}); err != nil || order.Owner == nil || order.Owner.SKU != "v" {
+		t.Errorf("set through pointer: %v", err)
+	}
+}
+
+func TestPathErrors(t *testing.T) {
+	order := pathOrder{Items: []pathItem{{"a", 1}}, Labels: map[string]string{}}
+	root := tinyreflect.ValueOf(&order)
+	testCases := []struct {
+		name    string
+		lookup  bool
+		path    string
+		x       any
+		wantErr error
+		errPath string
+	}{
+		{"Empty step", true, "Items..SKU", nil, tinyreflect.ErrInvalidArgument, "Items..SKU"},
+		{"Leading dot", true, ".ID", nil, tinyreflect.ErrInvalidArgument, ".ID"},
+		{"Unclosed bracket", true, "Items[0", nil, tinyreflect.ErrInvalidArgument, "Items[0"},
+		{"Unknown field", true, "Nope", nil, tinyreflect.ErrPathNotFound, "Nope"},
+		{"Unexported field", true, "secret", nil, tinyreflect.ErrPathNotFound, "secret"},
+		{"Index out of range", true, "Items[1]", nil, tinyreflect.ErrOutOfRange, "Items[1]"},
+		{"Bad index", true, "Items[x]", nil, tinyreflect.ErrInvalidArgument, "Items[x]"},
+		{"Name on slice", true, "Items.SKU", nil, tinyreflect.ErrPathNotFound, "Items.SKU"},
+		{"Missing key", true, "Labels.env", nil, tinyreflect.ErrPathNotFound, "Labels.env"},
+		{"Nil pointer", true, "Owner.SKU", nil, tinyreflect.ErrNilValue, "Owner"},
+		{"Into scalar", true, "ID.x", nil, tinyreflect.ErrPathNotFound, "ID.x"},
+		{"No grow", false, "Items[3].SKU", "x", tinyreflect.ErrOutOfRange, "Items[3]"},
+		{"Array bound", false, "Grid[2]", 1, tinyreflect.ErrOutOfRange, "Grid[2]"},
+		{"Type mismatch", false, "Items[0].SKU", 1, tinyreflect.ErrTypeMismatch, "Items[0].SKU"},
+		{"Overflow", false, "Grid[0]", 256, tinyreflect.ErrOverflow, "Grid[0]"},
+		{"Bad map key", false, "Stock[x]", nil, tinyreflect.ErrPathNotFound, "Stock[x]"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var err error
+			if tc.lookup {
+				_, err = tinyreflect.Lookup(root, tc.path)
+			} else {
+				err = tinyreflect.SetPath(root, tc.path, tc.x)
+			}
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got %v, want %v", err, tc.wantErr)
+			}
+			var ve *tinyreflect.ValueError
+			if !errors.As(err, &ve) || ve.Path != tc.errPath {
+				t.Errorf("got path %q, want %q", ve.Path, tc.errPath)
+			}
+		})
+	}
+
+	if err := tinyreflect.SetPath(tinyreflect.ValueOf(order), "ID", 1); !errors.Is(err, tinyreflect.ErrNotAssignable) {
+		t.Errorf("unaddressable root: got %v, want ErrNotAssignable", err)
+	}
+}
@@ -1,6 +1,8 @@
 package tinyreflect
 
 import (
+	"math"
+
 	. "github.com/cdvelop/tinystring"
 )
 
@@ -10,19 +12,26 @@ func (v Value) kind() Kind {
 }
 
 // mustBeAssignable checks if the value is assignable and returns an error if not.
-// A value is assignable if it's addressable (has flagAddr) or is a direct interface value.
-func (v Value) mustBeAssignable() error {
-	// Temporarily allow all non-zero values to be assignable for debugging
-	if v.flag == 0 {
-		return Err(D.Value, D.Not, "assignable")
+// A value is assignable if it's addressable (has flagAddr) and was not
+// obtained through an unexported field.
+func (v Value) mustBeAssignable(method string) error {
+	if v.flag&flagAddr == 0 || v.flag&flagRO != 0 {
+		return newValueError(method, v.kind(), ErrNotAssignable)
 	}
 	return nil
 }
 
-// mustBe checks if the value's kind is one of the expected kinds and returns an error if not.
-func (v Value) mustBe(expected Kind) error {
+// CanSet reports whether the value of v can be changed.
+// A Value can be changed only if it is addressable and was not
+// obtained by the use of unexported struct fields.
+func (v Value) CanSet() bool {
+	return v.flag&(flagAddr|flagRO) == flagAddr
+}
+
+// mustBe checks if the value's kind is the expected kind and returns an error if not.
+func (v Value) mustBe(method string, expected Kind) error {
 	if k := v.kind(); k != expected {
-		return Err(D.Call, D.Of, D.Method, k.String(), D.Value)
+		return newValueError(method, k, ErrUnsupportedKind)
 	}
 	return nil
 }
@@ -30,10 +39,10 @@ func (v Value) mustBe(expected Kind) error {
 // SetString sets the string value to the field represented by Value.
 // It uses unsafe to write the value to the memory location of the field.
 func (v Value) SetString(x string) error {
-	if err := v.mustBeAssignable(); err != nil {
+	if err := v.mustBeAssignable("SetString"); err != nil {
 		return err
 	}
-	if err := v.mustBe(K.String); err != nil {
+	if err := v.mustBe("SetString", K.String); err != nil {
 		return err
 	}
 	*(*string)(v.ptr) = x
@@ -42,10 +51,10 @@ func (v Value) SetString(x string) error {
 
 // SetBool sets the bool value to the field represented by Value.
 func (v Value) SetBool(x bool) error {
-	if err := v.mustBeAssignable(); err != nil {
+	if err := v.mustBeAssignable("SetBool"); err != nil {
 		return err
 	}
-	if err := v.mustBe(K.Bool); err != nil {
+	if err := v.mustBe("SetBool", K.Bool); err != nil {
 		return err
 	}
 	*(*bool)(v.ptr) = x
@@ -54,10 +63,10 @@ func (v Value) SetBool(x bool) error {
 
 // SetBytes sets the byte slice value to the field represented by Value.
 func (v Value) SetBytes(x []byte) error {
-	if err := v.mustBeAssignable(); err != nil {
+	if err := v.mustBeAssignable("SetBytes"); err != nil {
 		return err
 	}
-	if err := v.mustBe(K.Slice); err != nil {
+	if err := v.mustBe("SetBytes", K.Slice); err != nil {
 		return err
 	}
 	// For tinyreflect, we simplify and assume it's a []byte slice
@@ -67,7 +76,7 @@ func (v Value) SetBytes(x []byte) error {
 
 // SetInt sets the int value to the field represented by Value.
 func (v Value) SetInt(x int64) error {
-	if err := v.mustBeAssignable(); err != nil {
+	if err := v.mustBeAssignable("SetInt"); err != nil {
 		return err
 	}
 
@@ -83,14 +92,14 @@ func (v Value) SetInt(x int64) error {
 	case K.Int64:
 		*(*int64)(v.ptr) = x
 	default:
-		return Err(D.Call, D.Of, "SetInt", D.Method, k.String(), D.Value)
+		return newValueError("SetInt", k, ErrUnsupportedKind)
 	}
 	return nil
 }
 
 // SetUint sets the uint value to the field represented by Value.
 func (v Value) SetUint(x uint64) error {
-	if err := v.mustBeAssignable(); err != nil {
+	if err := v.mustBeAssignable("SetUint"); err != nil {
 		return err
 	}
 
@@ -108,14 +117,14 @@ func (v Value) SetUint(x uint64) error {
 	case K.Uintptr:
 		*(*uintptr)(v.ptr) = uintptr(x)
 	default:
-		return Err(D.Call, D.Of, "SetUint", D.Method, k.String(), D.Value)
+		return newValueError("SetUint", k, ErrUnsupportedKind)
 	}
 	return nil
 }
 
 // SetFloat sets the float value to the field represented by Value.
 func (v Value) SetFloat(x float64) error {
-	if err := v.mustBeAssignable(); err != nil {
+	if err := v.mustBeAssignable("SetFloat"); err != nil {
 		return err
 	}
 
@@ -125,7 +134,79 @@ func (v Value) SetFloat(x float64) error {
 	case K.Float64:
 		*(*float64)(v.ptr) = x
 	default:
-		return Err(D.Call, D.Of, "SetFloat", D.Method, k.String(), D.Value)
+		return newValueError("SetFloat", k, ErrUnsupportedKind)
+	}
+	return nil
+}
+
+// SetZero sets v to the zero value of its type.
+// It returns an error if CanSet would return false.
+func (v Value) SetZero() error {
+	if err := v.mustBeAssignable("SetZero"); err != nil {
+		return err
+	}
+	if v.typ_ == nil {
+		return newValueError("SetZero", v.kind(), ErrNilValue)
+	}
+	typedmemclr(v.typ_, v.ptr)
+	return nil
+}
+
+// SetLen sets v's length to n.
+// It returns an error if v's Kind is not Slice, if CanSet would return
+// false, or if n is negative or greater than the capacity of the slice.
+func (v Value) SetLen(n int) error {
+	if err := v.mustBeAssignable("SetLen"); err != nil {
+		return err
 	}
+	if err := v.mustBe("SetLen", K.Slice); err != nil {
+		return err
+	}
+	s := (*sliceHeader)(v.ptr)
+	if uint(n) > uint(s.Cap) {
+		return newValueError("SetLen", K.Slice, ErrOutOfRange)
+	}
+	s.Len = n
 	return nil
 }
+
+// OverflowInt reports whether the int64 x cannot be represented by v's type.
+// Kinds other than Int, Int8, Int16, Int32 and Int64 cannot hold x, so it
+// reports true for them.
+func (v Value) OverflowInt(x int64) bool {
+	switch v.kind() {
+	case K.Int, K.Int8, K.Int16, K.Int32, K.Int64:
+		bitSize := v.typ_.Size() * 8
+		trunc := (x << (64 - bitSize)) >> (64 - bitSize)
+		return x != trunc
+	}
+	return true
+}
+
+// OverflowUint reports whether the uint64 x cannot be represented by v's type.
+// Kinds other than Uint, Uint8, Uint16, Uint32, Uint64 and Uintptr cannot
+// hold x, so it reports true for them.
+func (v Value) OverflowUint(x uint64) bool {
+	switch v.kind() {
+	case K.Uint, K.Uint8, K.Uint16, K.Uint32, K.Uint64, K.Uintptr:
+		bitSize := v.typ_.Size() * 8
+		trunc := (x << (64 - bitSize)) >> (64 - bitSize)
+		return x != trunc
+	}
+	return true
+}
+
+// OverflowFloat reports whether the float64 x cannot be represented by v's type.
+// Kinds other than Float32 and Float64 cannot hold x, so it reports true for them.
+func (v Value) OverflowFloat(x float64) bool {
+	switch v.kind() {
+	case K.Float32:
+		if x < 0 {
+			x = -x
+		}
+		return math.MaxFloat32 < x && x <= math.MaxFloat64
+	case K.Float64:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+func TestSetZeroAndSetLen(t *testing.T) {
+	type Record struct {
+		Name  string
+		Items []int
+		Ptr   *int
+	}
+	n := 5
+	r := Record{Name: "x", Items: []int{1, 2, 3}, Ptr: &n}
+	v, _ := tinyreflect.ValueOf(&r).Elem()
+
+	items, _ := v.Field(1)
+	if err := items.SetLen(1); err != nil {
+		t.Fatalf("SetLen failed: %v", err)
+	}
+	if len(r.Items) != 1 || r.Items[0] != 1 {
+		t.Errorf("Items = %v, want [1]", r.Items)
+	}
+	if err := items.SetLen(4); !errors.Is(err, tinyreflect.ErrOutOfRange) {
+		t.Errorf("SetLen beyond cap: got %v, want ErrOutOfRange", err)
+	}
+
+	if err := v.SetZero(); err != nil {
+		t.Fatalf("SetZero failed: %v", err)
+	}
+	if r.Name != "" || r.Items != nil || r.Ptr != nil {
+		t.Errorf("record = %+v, want zero", r)
+	}
+
+	if err := tinyreflect.ValueOf(1).SetZero(); !errors.Is(err, tinyreflect.ErrNotAssignable) {
+		t.Errorf("SetZero on non-addressable: got %v, want ErrNotAssignable", err)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	var i8 int8
+	var u16 uint16
+	var f32 float32
+	vi, _ := tinyreflect.ValueOf(&i8).Elem()
+	vu, _ := tinyreflect.ValueOf(&u16).Elem()
+	vf, _ := tinyreflect.ValueOf(&f32).Elem()
+
+	testCases := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"int8 127", vi.OverflowInt(127), false},
+		{"int8 128", vi.OverflowInt(128), true},
+		{"int8 -129", vi.OverflowInt(-129), true},
+		{"uint16 65535", vu.OverflowUint(65535), false},
+		{"uint16 65536", vu.OverflowUint(65536), true},
+		{"float32 max", vf.OverflowFloat(math.MaxFloat32), false},
+		{"float32 1e39", vf.OverflowFloat(1e39), true},
+		{"float32 -1e39", vf.OverflowFloat(-1e39), true},
+		{"wrong kind", vi.OverflowUint(1), true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.got != tc.want {
+				t.Errorf("got %v, want %v", tc.got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCopy(t *testing.T) {
+	src := []string{"a", "b", "c"}
+	dst := make([]string, 2)
+	n, err := tinyreflect.Copy(tinyreflect.ValueOf(dst), tinyreflect.ValueOf(src))
+	if err != nil || n != 2 || dst[0] != "a" || dst[1] != "b" {
+		t.Errorf("Copy = %d, %v; dst = %v", n, err, dst)
+	}
+
+	// Overlapping copy within one slice shifts elements right
+	s := []int{1, 2, 3, 4}
+	n, err = tinyreflect.Copy(tinyreflect.ValueOf(s[1:]), tinyreflect.ValueOf(s))
+	if err != nil || n != 3 || s[0] != 1 || s[1] != 1 || s[2] != 2 || s[3] != 3 {
+		t.Errorf("overlapping Copy = %d, %v; s = %v", n, err, s)
+	}
+
+	var arr [2]string
+	av, _ := tinyreflect.ValueOf(&arr).Elem()
+	if _, err := tinyreflect.Copy(av, tinyreflect.ValueOf(src)); err != nil || arr != [2]string{"a", "b"} {
+		t.Errorf("Copy into array: %v, arr = %v", err, arr)
+	}
+	if _, err := tinyreflect.Copy(tinyreflect.ValueOf(arr), tinyreflect.ValueOf(src)); !errors.Is(err, tinyreflect.ErrNotAssignable) {
+		t.Errorf("Copy into unaddressable array: got %v, want ErrNotAssignable", err)
+	}
+	if _, err := tinyreflect.Copy(tinyreflect.ValueOf(dst), tinyreflect.ValueOf([]int{1})); !errors.Is(err, tinyreflect.ErrTypeMismatch) {
+		t.Errorf("Copy between element types: got %v, want ErrTypeMismatch", err)
+	}
+}
+
+func TestSetInterface(t *testing.T) {
+	type Box struct {
+		V   any
+		Err error
+	}
+	var b Box
+	v, _ := tinyreflect.ValueOf(&b).Elem()
+	f, _ := v.Field(0)
+
+	x := [3]int{1, 2, 3}
+	if err := f.Set(tinyreflect.ValueOf(x)); err != nil {
+		t.Fatalf("Set any failed: %v", err)
+	}
+	x[0] = 9
+	if got, ok := b.V.([3]int); !ok || got != [3]int{1, 2, 3} {
+		t.Errorf("V = %v, want an independent copy of [1 2 3]", b.V)
+	}
+
+	if err := f.Set(tinyreflect.ValueOf("s")); err != nil || b.V != "s" {
+		t.Errorf("Set string: %v, V = %v", err, b.V)
+	}
+
+	fe, _ := v.Field(1)
+	if err := fe.Set(tinyreflect.ValueOf(1)); !errors.Is(err, tinyreflect.ErrTypeMismatch) {
+		t.Errorf("Set int into error: got %v, want ErrTypeMismatch", err)
+	}
+}
+
+func TestNewValueAndMakeSlice(t *testing.T) {
+	p := tinyreflect.NewValue(tinyreflect.TypeOf(""))
+	e, err := p.Elem()
+	if err != nil {
+		t.Fatalf("Elem failed: %v", err)
+	}
+	if err := e.SetString("hi"); err != nil {
+		t.Fatalf("SetString on new value failed: %v", err)
+	}
+	if again, _ := p.Elem(); again.String() != "hi" {
+		t.Errorf("NewValue elem = %q, want hi", again.String())
+	}
+
+	sv, err := tinyreflect.MakeSlice(tinyreflect.TypeOf([]*int(nil)), 2, 8)
+	if err != nil {
+		t.Fatalf("MakeSlice failed: %v", err)
+	}
+	if c, _ := sv.Cap(); c != 8 {
+		t.Errorf("Cap = %d, want 8", c)
+	}
+	s, _ := sv.Interface()
+	if ps := s.([]*int); len(ps) != 2 || ps[0] != nil {
+		t.Errorf("MakeSlice result = %v", ps)
+	}
+}
@@ -1,18 +1,7 @@
 package tinyreflect
 
-// SliceType represents a slice type.
-type SliceType struct {
-	Type
-	Elem *Type // slice element type
-}
-
-// ArrayType represents an array type.
-type ArrayType struct {
-	Type
-	Elem  *Type   // array element type
-	Slice *Type   // slice type
-	Len   uintptr // array length
-}
+// SliceType and ArrayType are defined in SliceType_stdlib.go and
+// SliceType_tinygo.go with build tags to match each runtime's layout.
 
 // Elem returns the element type of the slice
 func (t *SliceType) Element() *Type {
@@ -28,3 +17,8 @@ func (t *ArrayType) Element() *Type {
 func (t *ArrayType) Length() int {
 	return int(t.Len)
 }
+
+// SliceOf returns the type of a slice of the array's element type.
+func (t *ArrayType) SliceOf() *Type {
+	return t.Slice
+}
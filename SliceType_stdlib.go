@@ -0,0 +1,19 @@
+//go:build !tinygo
+
+package tinyreflect
+
+// SliceType represents a slice type.
+// Layout matches stdlib's abi.SliceType for compatibility.
+type SliceType struct {
+	Type
+	Elem *Type // slice element type
+}
+
+// ArrayType represents an array type.
+// Layout matches stdlib's abi.ArrayType for compatibility.
+type ArrayType struct {
+	Type
+	Elem  *Type   // array element type
+	Slice *Type   // slice type
+	Len   uintptr // array length
+}
@@ -0,0 +1,23 @@
+//go:build tinygo
+
+package tinyreflect
+
+// SliceType represents a slice type.
+// Layout matches TinyGo's internal elemType for compatibility.
+type SliceType struct {
+	Type
+	numMethod uint16
+	ptrTo     *Type
+	Elem      *Type // slice element type
+}
+
+// ArrayType represents an array type.
+// Layout matches TinyGo's internal arrayType for compatibility.
+type ArrayType struct {
+	Type
+	numMethod uint16
+	ptrTo     *Type
+	Elem      *Type   // array element type
+	Len       uintptr // array length
+	Slice     *Type   // slice type
+}
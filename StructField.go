@@ -15,3 +15,8 @@ func (f *StructField) Embedded() bool {
 func (f StructField) Tag() StructTag {
 	return StructTag(f.Name.Tag())
 }
+
+// IsExported reports whether the field is exported.
+func (f StructField) IsExported() bool {
+	return f.Name.IsExported()
+}
@@ -26,33 +26,12 @@ func (f *tinygoStructField) toStructField() *StructField {
 		return nil
 	}
 
-	data := f.data
-
-	// Read flags byte
-	flagsByte := *(*byte)(data)
-	data = unsafe.Add(data, 1)
-
-	// Read offset (uvarint32)
-	offset, lenOffs := uvarint32(unsafe.Slice((*byte)(data), maxVarintLen32))
-	data = unsafe.Add(data, lenOffs)
-
-	// Read name (null-terminated string)
-	namePtr := (*byte)(data)
-	// Calculate name length by finding null terminator
-	nameLen := 0
-	for {
-		b := *(*byte)(unsafe.Add(data, nameLen))
-		if b == 0 {
-			break
-		}
-		nameLen++
-		if nameLen > 1000 { // safety check
-			break
-		}
-	}
+	// Name keeps the whole packed data, flags byte first, and decodes the
+	// name, tag and flags from it; see Name_tinygo.go
+	offset, _ := uvarint32(unsafe.Slice((*byte)(unsafe.Add(f.data, 1)), maxVarintLen32))
 
 	return &StructField{
-		Name: Name{Bytes: namePtr},
+		Name: Name{Bytes: (*byte)(f.data)},
 		Typ:  f.fieldType,
 		Off:  uintptr(offset),
 	}
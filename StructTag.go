@@ -1,15 +1,132 @@
 package tinyreflect
 
-import (
-	. "github.com/cdvelop/tinystring"
-)
-
 // StructTag is the tag string in a struct field (similar to reflect.StructTag)
 type StructTag string
 
 // Get returns the value associated with key in the tag string.
 // If there is no such key in the tag, Get returns the empty string.
 func (tag StructTag) Get(key string) string {
-	out, _ := Convert(string(tag)).TagValue(key)
-	return out
+	v, _ := tag.Lookup(key)
+	return v
+}
+
+// Lookup returns the value associated with key in the tag string.
+// If the key is present in the tag the value (which may be empty)
+// is returned. Otherwise the returned value will be the empty string.
+// The ok return value reports whether the value was explicitly set in
+// the tag string. Values may contain spaces, e.g. `usage:"port to use"`.
+func (tag StructTag) Lookup(key string) (value string, ok bool) {
+	for tag != "" {
+		// Skip leading space.
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		// Scan to colon. A space, a quote or a control character is a syntax error.
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		name := string(tag[:i])
+		tag = tag[i+1:]
+
+		// Scan quoted string to find value.
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		qvalue := string(tag[1:i])
+		tag = tag[i+1:]
+
+		if key == name {
+			return unquoteTag(qvalue), true
+		}
+	}
+	return "", false
+}
+
+// unquoteTag resolves the backslash escapes of a quoted tag value.
+// Only \" and \\ are expected in struct tags, other escapes are kept as is.
+func unquoteTag(s string) string {
+	esc := false
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			esc = true
+			break
+		}
+	}
+	if !esc {
+		return s
+	}
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\') {
+			i++
+		}
+		out = append(out, s[i])
+	}
+	return string(out)
+}
+
+// TagOptions is the comma-separated list of options that follows the name
+// in a tag value, e.g. "omitempty,string" in `json:"id,omitempty,string"`.
+type TagOptions string
+
+// ParseTag splits a tag value such as "id,omitempty" into its name
+// and its options.
+func ParseTag(value string) (name string, opts TagOptions) {
+	for i := 0; i < len(value); i++ {
+		if value[i] == ',' {
+			return value[:i], TagOptions(value[i+1:])
+		}
+	}
+	return value, ""
+}
+
+// Has reports whether opt is one of the comma-separated options.
+func (o TagOptions) Has(opt string) bool {
+	_, ok := o.Lookup(opt)
+	return ok
+}
+
+// Lookup returns the value of a "key=value" option, e.g. Lookup("min")
+// returns "3" for "required,min=3". Options without "=" have an empty
+// value. The ok result reports whether the option is present.
+func (o TagOptions) Lookup(key string) (value string, ok bool) {
+	s := string(o)
+	for s != "" {
+		next := ""
+		for i := 0; i < len(s); i++ {
+			if s[i] == ',' {
+				s, next = s[:i], s[i+1:]
+				break
+			}
+		}
+		name, val := s, ""
+		for i := 0; i < len(s); i++ {
+			if s[i] == '=' {
+				name, val = s[:i], s[i+1:]
+				break
+			}
+		}
+		if name == key {
+			return val, true
+		}
+		s = next
+	}
+	return "", false
 }
@@ -62,4 +62,43 @@ func TestGetFieldName(t *testing.T) {
 			t.Errorf("Field %d: expected Label %s, got %s", i, expectedFields[i].Label, label)
 		}
 	}
-}
\ No newline at end of file
+}
+func TestStructTagLookup(t *testing.T) {
+	tag := tinyreflect.StructTag(`json:"id,omitempty,string" usage:"port to listen on" empty:"" esc:"a\"b"`)
+
+	testCases := []struct {
+		key    string
+		want   string
+		wantOk bool
+	}{
+		{"json", "id,omitempty,string", true},
+		{"usage", "port to listen on", true},
+		{"empty", "", true},
+		{"esc", `a"b`, true},
+		{"missing", "", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			got, ok := tag.Lookup(tc.key)
+			if got != tc.want || ok != tc.wantOk {
+				t.Errorf("Lookup(%q) = %q, %v; want %q, %v", tc.key, got, ok, tc.want, tc.wantOk)
+			}
+		})
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	name, opts := tinyreflect.ParseTag("name,omitempty,min=3")
+	if name != "name" {
+		t.Errorf("name = %q, want %q", name, "name")
+	}
+	if !opts.Has("omitempty") || opts.Has("string") || opts.Has("min=3") {
+		t.Errorf("unexpected options %q", opts)
+	}
+	if v, ok := opts.Lookup("min"); !ok || v != "3" {
+		t.Errorf("Lookup(min) = %q, %v", v, ok)
+	}
+	if name, opts := tinyreflect.ParseTag(""); name != "" || opts != "" {
+		t.Errorf("ParseTag(\"\") = %q, %q", name, opts)
+	}
+}
@@ -24,8 +24,6 @@ func (st *StructType) getField(i int) *StructField {
 	}
 	// Calculate field address using TinyGo's internal field size
 	fieldSize := unsafe.Sizeof(tinygoStructField{})
-	println("DEBUG getField: i =", i, "fieldSize =", fieldSize)
-
 	offset := uintptr(i) * fieldSize
 	tinyField := (*tinygoStructField)(unsafe.Add(unsafe.Pointer(&st.Fields[0]), offset))
 
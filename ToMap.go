@@ -0,0 +1,561 @@
+package tinyreflect
+
+import (
+	"math"
+	"unsafe"
+
+	"github.com/cdvelop/tinyreflect/internal/cache"
+	. "github.com/cdvelop/tinystring"
+)
+
+// ToMap converts the struct v, or the struct v points to, to a
+// map[string]any keyed by the name in the tagKey tag of each exported field,
+// or by the field name when the tag is absent, has no name or tagKey is "".
+// Fields tagged "-" are left out, and so are zero fields tagged omitempty.
+//
+// Nested structs become map[string]any, slices and arrays become []any
+// (except []byte, which is copied), maps with string keys become
+// map[string]any and nil pointers, slices and maps become nil. Maps with
+// other keys become deep copies of the same type, and other values keep
+// their type.
+func ToMap(v any, tagKey string) (map[string]any, error) {
+	rv := ValueOf(v)
+	if rv.Kind() == K.Pointer {
+		if rv.IsZero() {
+			return nil, newValueError("ToMap", K.Pointer, ErrNilValue)
+		}
+		var err error
+		if rv, err = rv.Elem(); err != nil {
+			return nil, err
+		}
+	}
+	if rv.Kind() != K.Struct {
+		return nil, newValueError("ToMap", rv.Kind(), ErrNotStruct)
+	}
+	return structToMap(rv, tagKey, 0)
+}
+
+// maxMapDepth bounds the nesting ToMap and FromMap follow, so pointer
+// cycles fail instead of overflowing the stack.
+const maxMapDepth = 1000
+
+// mapField is an exported struct field with its map key.
+type mapField struct {
+	index     int
+	key       string
+	omitEmpty bool
+}
+
+// fieldsKey identifies the fields of a struct type read with one tag key.
+type fieldsKey struct {
+	t      *Type
+	tagKey string
+}
+
+// fieldsCache keeps the fields mapFields returns, so tags are parsed once
+// per type and tag key instead of once per value or form key.
+var fieldsCache cache.Cache[fieldsKey, []mapField]
+
+// mapFields returns the exported fields of the struct type t that are not
+// tagged "-", keyed as ToMap describes. The result is cached and shared,
+// so callers must not modify it.
+func mapFields(t *Type, tagKey string) ([]mapField, error) {
+	key := fieldsKey{t, tagKey}
+	if fields, ok := fieldsCache.Load(key); ok {
+		return fields, nil
+	}
+	fields, err := readMapFields(t, tagKey)
+	if err != nil {
+		return nil, err
+	}
+	fieldsCache.Store(key, fields)
+	return fields, nil
+}
+
+// readMapFields reads the fields of t for mapFields.
+func readMapFields(t *Type, tagKey string) ([]mapField, error) {
+	n, err := t.NumField()
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]mapField, 0, n)
+	for i := 0; i < n; i++ {
+		sf, err := t.Field(i)
+		if err != nil {
+			return nil, err
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		f := mapField{index: i}
+		if tagKey != "" {
+			tag, _ := sf.Tag().Lookup(tagKey)
+			if tag == "-" {
+				continue
+			}
+			var opts TagOptions
+			f.key, opts = ParseTag(tag)
+			f.omitEmpty = opts.Has("omitempty")
+		}
+		if f.key == "" {
+			if f.key, err = t.NameByIndex(i); err != nil {
+				return nil, err
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func structToMap(v Value, tagKey string, depth int) (map[string]any, error) {
+	fields, err := mapFields(v.Type(), tagKey)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]any, len(fields))
+	for _, f := range fields {
+		fv, err := v.Field(f.index)
+		if err != nil {
+			return nil, withPath(err, f.key)
+		}
+		if f.omitEmpty && isEmptyField(fv) {
+			continue
+		}
+		if m[f.key], err = toMapValue(fv, tagKey, depth+1); err != nil {
+			return nil, withPath(err, f.key)
+		}
+	}
+	return m, nil
+}
+
+// isEmptyField reports whether omitempty leaves v out: zero values and
+// empty strings, slices and maps.
+func isEmptyField(v Value) bool {
+	switch v.Kind() {
+	case K.String, K.Slice, K.Map:
+		n, _ := v.Len()
+		return n == 0
+	}
+	return v.IsZero()
+}
+
+// toMapValue converts one field value for ToMap.
+func toMapValue(v Value, tagKey string, depth int) (any, error) {
+	if depth > maxMapDepth {
+		return nil, newValueError("ToMap", v.Kind(), ErrInvalidArgument)
+	}
+	switch k := v.Kind(); k {
+	case K.Struct:
+		return structToMap(v, tagKey, depth)
+
+	case K.Pointer, K.Interface:
+		if v.IsZero() {
+			return nil, nil
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return nil, err
+		}
+		return toMapValue(elem, tagKey, depth+1)
+
+	case K.Slice, K.Array:
+		if k == K.Slice && v.IsZero() {
+			return nil, nil
+		}
+		n, _ := v.Len()
+		if v.Type().Elem().Kind() == K.Uint8 && k == K.Slice {
+			b := make([]byte, n)
+			if n > 0 {
+				p, _ := v.UnsafePointer()
+				copy(b, unsafe.Slice((*byte)(p), n))
+			}
+			return b, nil
+		}
+		list := make([]any, n)
+		for i := range list {
+			elem, err := v.Index(i)
+			if err != nil {
+				return nil, err
+			}
+			if list[i], err = toMapValue(elem, tagKey, depth+1); err != nil {
+				return nil, withPath(err, indexSegment(i))
+			}
+		}
+		return list, nil
+
+	case K.Map:
+		if v.IsZero() {
+			return nil, nil
+		}
+		if v.Type().Key().Kind() != K.String {
+			// Keep the map's type, but on a copy so writes to the result
+			// do not reach the source
+			c, err := NewValue(v.Type()).Elem()
+			if err != nil {
+				return nil, err
+			}
+			if err := v.DeepCopyInto(c); err != nil {
+				return nil, err
+			}
+			return c.detached(), nil
+		}
+		iter, err := v.MapRange()
+		if err != nil {
+			return nil, err
+		}
+		n, _ := v.Len()
+		m := make(map[string]any, n)
+		for iter.Next() {
+			key := iter.Key().String()
+			if m[key], err = toMapValue(iter.Value(), tagKey, depth+1); err != nil {
+				return nil, withPath(err, key)
+			}
+		}
+		return m, nil
+
+	case K.Invalid, K.Chan, K.Func, K.UnsafePointer:
+		return nil, newValueError("ToMap", k, ErrUnsupportedKind)
+	}
+	return v.detached(), nil
+}
+
+// detached returns v as an any that holds its own copy of the data, so
+// later writes to v do not show through it.
+func (v Value) detached() any {
+	if v.typ_.IfaceIndir() {
+		c := unsafeNew(v.typ_)
+		typedmemmove(v.typ_, c, v.dataPointer())
+		v = Value{v.typ_, c, flag(v.kind()) | flagIndir}
+	}
+	return packEface(v)
+}
+
+// FromMap sets the exported fields of the struct ptr points to from the
+// entries of m, the reverse of ToMap. Keys are matched against the tagKey
+// tag when one is given, and the field names otherwise; fields without an
+// entry keep their value and unknown keys are ignored.
+//
+// Values are converted to the field's type: numbers of any kind are
+// accepted by numeric fields when they fit (ErrOverflow otherwise, or
+// ErrTypeMismatch for fractions into integers), map[string]any fills
+// structs and maps, slices and arrays fill slices and arrays element by
+// element, and nil sets the field to zero. A pointer field takes a pointer
+// of its own type as it is and converts what other pointers point to;
+// a nil field is allocated once the value converts.
+func FromMap(m map[string]any, ptr any, tagKey ...string) error {
+	rv := ValueOf(ptr)
+	if rv.Kind() != K.Pointer || rv.IsZero() {
+		return newValueError("FromMap", rv.Kind(), ErrInvalidArgument)
+	}
+	elem, err := rv.Elem()
+	if err != nil {
+		return err
+	}
+	if elem.Kind() != K.Struct {
+		return newValueError("FromMap", elem.Kind(), ErrNotStruct)
+	}
+	key := ""
+	if len(tagKey) > 0 {
+		key = tagKey[0]
+	}
+	return mapToStruct(m, elem, key, 0)
+}
+
+func mapToStruct(m map[string]any, v Value, tagKey string, depth int) error {
+	fields, err := mapFields(v.Type(), tagKey)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		x, ok := m[f.key]
+		if !ok {
+			continue
+		}
+		fv, err := v.Field(f.index)
+		if err != nil {
+			return withPath(err, f.key)
+		}
+		if err := fromMapValue(fv, x, tagKey, depth+1); err != nil {
+			return withPath(err, f.key)
+		}
+	}
+	return nil
+}
+
+// fromMapValue stores x in v, converting it as FromMap describes.
+func fromMapValue(v Value, x any, tagKey string, depth int) error {
+	if depth > maxMapDepth {
+		return newValueError("FromMap", v.Kind(), ErrInvalidArgument)
+	}
+	if x == nil {
+		return v.SetZero()
+	}
+	xv := ValueOf(x)
+	k := v.Kind()
+	if xv.Type() == v.Type() && k != K.Struct && k != K.Slice && k != K.Map && k != K.Array {
+		return v.Set(xv)
+	}
+
+	switch {
+	case k == K.Bool:
+		if xv.Kind() == K.Bool {
+			b, _ := xv.Bool()
+			return v.SetBool(b)
+		}
+
+	case k == K.String:
+		if xv.Kind() == K.String {
+			return v.SetString(xv.String())
+		}
+
+	case isIntKind(k):
+		i, err := toInt64("FromMap", xv)
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(i) {
+			return newValueError("FromMap", k, ErrOverflow)
+		}
+		return v.SetInt(i)
+
+	case isUintKind(k):
+		u, err := toUint64("FromMap", xv)
+		if err != nil {
+			return err
+		}
+		if v.OverflowUint(u) {
+			return newValueError("FromMap", k, ErrOverflow)
+		}
+		return v.SetUint(u)
+
+	case k == K.Float32 || k == K.Float64:
+		f, ok := toFloat64(xv)
+		if !ok {
+			break
+		}
+		if v.OverflowFloat(f) {
+			return newValueError("FromMap", k, ErrOverflow)
+		}
+		return v.SetFloat(f)
+
+	case k == K.Interface:
+		return v.Set(xv)
+
+	case k == K.Pointer:
+		if xv.Kind() == K.Pointer {
+			// Convert what a typed pointer of another type points to
+			if xv.IsZero() {
+				return v.SetZero()
+			}
+			xe, err := xv.Elem()
+			if err != nil {
+				return err
+			}
+			if x, err = xe.Interface(); err != nil {
+				return err
+			}
+		}
+		if !v.IsZero() {
+			elem, err := v.Elem()
+			if err != nil {
+				return err
+			}
+			return fromMapValue(elem, x, tagKey, depth+1)
+		}
+		// Allocate the pointee only once it converts
+		p := NewValue(v.Type().Elem())
+		elem, err := p.Elem()
+		if err != nil {
+			return err
+		}
+		if err := fromMapValue(elem, x, tagKey, depth+1); err != nil {
+			return err
+		}
+		return v.Set(p)
+
+	case k == K.Struct:
+		if m, ok := x.(map[string]any); ok {
+			return mapToStruct(m, v, tagKey, depth)
+		}
+		if xv.Type() == v.Type() {
+			return v.Set(xv)
+		}
+
+	case k == K.Slice:
+		if b, ok := x.([]byte); ok && v.Type().Elem().Kind() == K.Uint8 {
+			s, err := MakeSlice(v.Type(), len(b), len(b))
+			if err != nil {
+				return err
+			}
+			if len(b) > 0 {
+				p, _ := s.UnsafePointer()
+				copy(unsafe.Slice((*byte)(p), len(b)), b)
+			}
+			return v.Set(s)
+		}
+		if xk := xv.Kind(); xk != K.Slice && xk != K.Array {
+			break
+		}
+		n, _ := xv.Len()
+		s, err := MakeSlice(v.Type(), n, n)
+		if err != nil {
+			return err
+		}
+		if err := fillElems(s, xv, n, tagKey, depth); err != nil {
+			return err
+		}
+		return v.Set(s)
+
+	case k == K.Array:
+		if xk := xv.Kind(); xk != K.Slice && xk != K.Array {
+			break
+		}
+		n, _ := xv.Len()
+		if size, _ := v.Len(); n > size {
+			return newValueError("FromMap", k, ErrOutOfRange)
+		}
+		if err := v.SetZero(); err != nil {
+			return err
+		}
+		return fillElems(v, xv, n, tagKey, depth)
+
+	case k == K.Map:
+		if xv.Kind() != K.Map {
+			break
+		}
+		return fillMap(v, xv, tagKey, depth)
+	}
+	return newValueError("FromMap", k, ErrTypeMismatch)
+}
+
+// fillElems converts the first n elements of src into dst.
+func fillElems(dst, src Value, n int, tagKey string, depth int) error {
+	for i := 0; i < n; i++ {
+		se, err := src.Index(i)
+		if err != nil {
+			return err
+		}
+		de, err := dst.Index(i)
+		if err != nil {
+			return err
+		}
+		x, err := se.Interface()
+		if err != nil {
+			return err
+		}
+		if err := fromMapValue(de, x, tagKey, depth+1); err != nil {
+			return withPath(err, indexSegment(i))
+		}
+	}
+	return nil
+}
+
+// fillMap replaces v with a new map holding the converted entries of src.
+func fillMap(v, src Value, tagKey string, depth int) error {
+	n, _ := src.Len()
+	typ := v.Type()
+	m, err := MakeMapWithSize(typ, n)
+	if err != nil {
+		return err
+	}
+	iter, err := src.MapRange()
+	if err != nil {
+		return err
+	}
+	for iter.Next() {
+		key, err := NewValue(typ.Key()).Elem()
+		if err != nil {
+			return err
+		}
+		kx, err := iter.Key().Interface()
+		if err != nil {
+			return err
+		}
+		if err := fromMapValue(key, kx, tagKey, depth+1); err != nil {
+			return err
+		}
+		elem, err := NewValue(typ.Elem()).Elem()
+		if err != nil {
+			return err
+		}
+		ex, err := iter.Value().Interface()
+		if err != nil {
+			return err
+		}
+		if err := fromMapValue(elem, ex, tagKey, depth+1); err != nil {
+			if key.Kind() == K.String {
+				return withPath(err, key.String())
+			}
+			return err
+		}
+		if err := m.SetMapIndex(key, elem); err != nil {
+			return err
+		}
+	}
+	return v.Set(m)
+}
+
+// toInt64 returns the integer held by the numeric value x for method.
+// Floats must have no fraction.
+func toInt64(method string, x Value) (int64, error) {
+	switch k := x.Kind(); {
+	case isIntKind(k):
+		return x.Int()
+	case isUintKind(k):
+		u, _ := x.Uint()
+		if u > math.MaxInt64 {
+			return 0, newValueError(method, k, ErrOverflow)
+		}
+		return int64(u), nil
+	case k == K.Float32 || k == K.Float64:
+		f, _ := x.Float()
+		if f != math.Trunc(f) {
+			return 0, newValueError(method, k, ErrTypeMismatch)
+		}
+		if f < -(1<<63) || f >= 1<<63 {
+			return 0, newValueError(method, k, ErrOverflow)
+		}
+		return int64(f), nil
+	}
+	return 0, newValueError(method, x.Kind(), ErrTypeMismatch)
+}
+
+// toUint64 is like toInt64 for unsigned targets; negative numbers overflow.
+func toUint64(method string, x Value) (uint64, error) {
+	switch k := x.Kind(); {
+	case isUintKind(k):
+		return x.Uint()
+	case isIntKind(k):
+		i, _ := x.Int()
+		if i < 0 {
+			return 0, newValueError(method, k, ErrOverflow)
+		}
+		return uint64(i), nil
+	case k == K.Float32 || k == K.Float64:
+		f, _ := x.Float()
+		if f != math.Trunc(f) {
+			return 0, newValueError(method, k, ErrTypeMismatch)
+		}
+		if f < 0 || f >= 1<<64 {
+			return 0, newValueError(method, k, ErrOverflow)
+		}
+		return uint64(f), nil
+	}
+	return 0, newValueError(method, x.Kind(), ErrTypeMismatch)
+}
+
+// toFloat64 returns the number held by x as a float64.
+func toFloat64(x Value) (float64, bool) {
+	switch k := x.Kind(); {
+	case k == K.Float32 || k == K.Float64:
+		f, _ := x.Float()
+		return f, true
+	case isIntKind(k):
+		i, _ := x.Int()
+		return float64(i), true
+	case isUintKind(k):
+		u, _ := x.Uint()
+		return float64(u), true
+	}
+	return 0, false
+}
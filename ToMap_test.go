@@ -0,0 +1,221 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+type mapAddress struct {
+	City string `json:"city"`
+	Zip  *int   `json:"zip,omitempty"`
+}
+
+type mapRecord struct {
+	ID       uint32            `json:"id"`
+	Name     string            `json:"name"`
+	Score    float32           `json:"score,omitempty"`
+	Level    int8              `json:"level"`
+	Active   bool              `json:"active"`
+	Home     mapAddress        `json:"home"`
+	Work     *mapAddress       `json:"work"`
+	Tags     []string          `json:"tags,omitempty"`
+	Past     []mapAddress      `json:"past"`
+	Grid     [2]int            `json:"grid"`
+	Limits   map[string]uint16 `json:"limits"`
+	Codes    map[int]string    `json:"codes"`
+	Raw      []byte            `json:"raw"`
+	Any      any               `json:"any"`
+	Password string            `json:"-"`
+	NoTag    int
+	private  int
+}
+
+func TestToMap(t *testing.T) {
+	zip := 15001
+	r := mapRecord{
+		ID: 7, Name: "Ana", Level: -3, Active: true,
+		Home:     mapAddress{City: "Lima", Zip: &zip},
+		Past:     []mapAddress{{City: "Cusco"}},
+		Grid:     [2]int{1, 2},
+		Limits:   map[string]uint16{"a": 1},
+		Codes:    map[int]string{1: "x"},
+		Raw:      []byte("hi"),
+		Any:      mapAddress{City: "Puno"},
+		Password: "secret",
+		NoTag:    5,
+	}
+	got, err := tinyreflect.ToMap(&r, "json")
+	if err != nil {
+		t.Fatalf("ToMap: %v", err)
+	}
+	want := map[string]any{
+		"id": uint32(7), "name": "Ana", "level": int8(-3), "active": true,
+		"home":   map[string]any{"city": "Lima", "zip": 15001},
+		"work":   nil,
+		"past":   []any{map[string]any{"city": "Cusco"}},
+		"grid":   []any{1, 2},
+		"limits": map[string]any{"a": uint16(1)},
+		"codes":  map[int]string{1: "x"},
+		"raw":    []byte("hi"),
+		"any":    map[string]any{"city": "Puno"},
+		"NoTag":  5,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %#v\nwant %#v", got, want)
+	}
+
+	// Values are copies: later writes to r do not show through
+	r.Name = "Eva"
+	r.Raw[0] = 'H'
+	r.Codes[1] = "y"
+	got["codes"].(map[int]string)[2] = "z"
+	if got["name"] != "Ana" || string(got["raw"].([]byte)) != "hi" || got["codes"].(map[int]string)[1] != "x" {
+		t.Errorf("ToMap result aliases the struct: %v %q %v", got["name"], got["raw"], got["codes"])
+	}
+	if len(r.Codes) != 1 {
+		t.Errorf("write to the result reached the struct: %v", r.Codes)
+	}
+
+	byName, err := tinyreflect.ToMap(mapAddress{City: "Lima"}, "")
+	if err != nil || !reflect.DeepEqual(byName, map[string]any{"City": "Lima", "Zip": nil}) {
+		t.Errorf("no tag key: got %v, %v", byName, err)
+	}
+}
+
+func TestFromMap(t *testing.T) {
+	m := map[string]any{
+		"id":     float64(7), // as decoded from JSON
+		"name":   "Ana",
+		"score":  int64(3),
+		"level":  -3,
+		"active": true,
+		"home":   map[string]any{"city": "Lima", "zip": 15001.0},
+		"work":   map[string]any{"city": "Quito"},
+		"tags":   []any{"a", "b"},
+		"past":   []any{map[string]any{"city": "Cusco"}, nil},
+		"grid":   []int{4},
+		"limits": map[string]any{"a": 1, "b": uint8(2)},
+		"raw":    []byte("hi"),
+		"any":    []any{1, "x"},
+		"NoTag":  9,
+		"-":      "ignored",
+		"extra":  "ignored",
+	}
+
+	got := mapRecord{Password: "kept", Grid: [2]int{8, 9}}
+	if err := tinyreflect.FromMap(m, &got, "json"); err != nil {
+		t.Fatalf("FromMap: %v", err)
+	}
+	zip := 15001
+	want := mapRecord{
+		ID: 7, Name: "Ana", Score: 3, Level: -3, Active: true,
+		Home:     mapAddress{City: "Lima", Zip: &zip},
+		Work:     &mapAddress{City: "Quito"},
+		Tags:     []string{"a", "b"},
+		Past:     []mapAddress{{City: "Cusco"}, {}},
+		Grid:     [2]int{4, 0},
+		Limits:   map[string]uint16{"a": 1, "b": 2},
+		Raw:      []byte("hi"),
+		Any:      []any{1, "x"},
+		Password: "kept",
+		NoTag:    9,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+
+	// A ToMap result converts back to the same struct
+	back, err := tinyreflect.ToMap(&want, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again mapRecord
+	if err := tinyreflect.FromMap(back, &again, "json"); err != nil {
+		t.Fatalf("FromMap round trip: %v", err)
+	}
+	want.Password = ""
+	if !reflect.DeepEqual(again, want) {
+		t.Errorf("round trip:\ngot  %+v\nwant %+v", again, want)
+	}
+}
+
+func TestFromMapPointers(t *testing.T) {
+	type inner struct{ N int }
+	type S struct {
+		P *int
+		I *inner
+		W *int64
+		Z *int
+	}
+	n, in := 5, &inner{3}
+	z := 1
+	s := S{Z: &z}
+	m := map[string]any{"P": &n, "I": in, "W": &n, "Z": (*int)(nil)}
+	if err := tinyreflect.FromMap(m, &s); err != nil {
+		t.Fatalf("FromMap: %v", err)
+	}
+	if s.P != &n || s.I != in {
+		t.Errorf("pointers of the field's type were not stored as they are: %+v", s)
+	}
+	if s.W == nil || *s.W != 5 || s.Z != nil {
+		t.Errorf("got %+v", s)
+	}
+
+	// A value that does not convert leaves a nil pointer field nil
+	var r mapRecord
+	err := tinyreflect.FromMap(map[string]any{"work": map[string]any{"city": false}}, &r, "json")
+	if !errors.Is(err, tinyreflect.ErrTypeMismatch) || r.Work != nil {
+		t.Errorf("got %v, work %+v", err, r.Work)
+	}
+}
+
+func TestFromMapErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		m       map[string]any
+		wantErr error
+		path    string
+	}{
+		{"Int8 overflow", map[string]any{"level": 128}, tinyreflect.ErrOverflow, "level"},
+		{"Negative into uint", map[string]any{"id": -1}, tinyreflect.ErrOverflow, "id"},
+		{"Uint32 overflow", map[string]any{"id": uint64(math.MaxUint32 + 1)}, tinyreflect.ErrOverflow, "id"},
+		{"Fraction into int", map[string]any{"level": 1.5}, tinyreflect.ErrTypeMismatch, "level"},
+		{"Float32 overflow", map[string]any{"score": 1e39}, tinyreflect.ErrOverflow, "score"},
+		{"String into int", map[string]any{"level": "1"}, tinyreflect.ErrTypeMismatch, "level"},
+		{"Number into string", map[string]any{"name": 1}, tinyreflect.ErrTypeMismatch, "name"},
+		{"Nested path", map[string]any{"past": []any{map[string]any{}, map[string]any{"city": false}}}, tinyreflect.ErrTypeMismatch, "past[1].city"},
+		{"Map value path", map[string]any{"limits": map[string]any{"a": 70000}}, tinyreflect.ErrOverflow, "limits.a"},
+		{"Array too long", map[string]any{"grid": []any{1, 2, 3}}, tinyreflect.ErrOutOfRange, "grid"},
+		{"Scalar into struct", map[string]any{"home": "Lima"}, tinyreflect.ErrTypeMismatch, "home"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var r mapRecord
+			err := tinyreflect.FromMap(tc.m, &r, "json")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got %v, want %v", err, tc.wantErr)
+			}
+			var ve *tinyreflect.ValueError
+			if !errors.As(err, &ve) || ve.Path != tc.path {
+				t.Errorf("got path %q, want %q", ve.Path, tc.path)
+			}
+		})
+	}
+
+	if err := tinyreflect.FromMap(nil, mapRecord{}); !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+		t.Errorf("non-pointer: got %v, want ErrInvalidArgument", err)
+	}
+	if err := tinyreflect.FromMap(nil, new(int)); !errors.Is(err, tinyreflect.ErrNotStruct) {
+		t.Errorf("pointer to int: got %v, want ErrNotStruct", err)
+	}
+	if _, err := tinyreflect.ToMap(42, "json"); !errors.Is(err, tinyreflect.ErrNotStruct) {
+		t.Errorf("ToMap int: got %v, want ErrNotStruct", err)
+	}
+	if _, err := tinyreflect.ToMap((*mapRecord)(nil), "json"); !errors.Is(err, tinyreflect.ErrNilValue) {
+		t.Errorf("ToMap nil: got %v, want ErrNilValue", err)
+	}
+}
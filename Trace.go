@@ -0,0 +1,76 @@
+package tinyreflect
+
+import (
+	"sync"
+
+	. "github.com/cdvelop/tinystring"
+)
+
+// TraceEvent describes one reflection operation observed by a Tracer.
+type TraceEvent struct {
+	Op    string // operation name, e.g. "Field" or "Type.NameByIndex"
+	Kind  Kind   // kind of the value or type the operation ran on
+	Index int    // field or element index requested
+	Err   error  // error returned by the operation, nil on success
+}
+
+// Tracer receives diagnostics events from reflection operations.
+type Tracer interface {
+	Trace(e TraceEvent)
+}
+
+// tracer is the installed Tracer, nil when tracing is disabled.
+var tracer Tracer
+
+// SetTracer installs t as the diagnostics tracer and returns the previous one.
+// Passing nil disables tracing. Install the tracer at startup or in test
+// setup: it is not synchronized with reflection calls running concurrently.
+//
+// Building with the tinyreflect_notrace tag removes every trace call site,
+// so installed tracers receive nothing and the checks cost nothing.
+func SetTracer(t Tracer) Tracer {
+	prev := tracer
+	tracer = t
+	return prev
+}
+
+// trace reports an operation to the installed tracer, if any.
+func trace(op string, k Kind, i int, err error) {
+	if traceEnabled && tracer != nil {
+		tracer.Trace(TraceEvent{Op: op, Kind: k, Index: i, Err: err})
+	}
+}
+
+// traceErr reports a failed operation to the installed tracer and returns err.
+func traceErr(op string, k Kind, i int, err error) error {
+	trace(op, k, i, err)
+	return err
+}
+
+// TraceCollector is a Tracer that records every event it receives.
+// It is meant for tests that assert on reflection diagnostics.
+type TraceCollector struct {
+	mu     sync.Mutex
+	events []TraceEvent
+}
+
+// Trace records e.
+func (c *TraceCollector) Trace(e TraceEvent) {
+	c.mu.Lock()
+	c.events = append(c.events, e)
+	c.mu.Unlock()
+}
+
+// Events returns a copy of the recorded events in the order they arrived.
+func (c *TraceCollector) Events() []TraceEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]TraceEvent(nil), c.events...)
+}
+
+// Reset discards the recorded events.
+func (c *TraceCollector) Reset() {
+	c.mu.Lock()
+	c.events = c.events[:0]
+	c.mu.Unlock()
+}
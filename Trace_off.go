@@ -0,0 +1,6 @@
+//go:build tinyreflect_notrace
+
+package tinyreflect
+
+// traceEnabled removes every trace call site at compile time.
+const traceEnabled = false
@@ -0,0 +1,6 @@
+//go:build !tinyreflect_notrace
+
+package tinyreflect
+
+// traceEnabled keeps trace call sites; the installed Tracer decides if they report.
+const traceEnabled = true
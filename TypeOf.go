@@ -56,18 +56,15 @@ func (t *Type) Field(i int) (StructField, error) {
 	// Get underlying type first (handles named types)
 	ut := t.underlying()
 	if ut.Kind() != K.Struct {
-		return StructField{}, Err(ref, D.Field, D.NotOfType, "Struct")
+		return StructField{}, newValueError("Type.Field", ut.Kind(), ErrNotStruct)
 	}
 	st := (*StructType)(unsafe.Pointer(ut))
-	if st == nil {
-		return StructField{}, Err(ref, D.Field, D.NotOfType, "Struct")
-	}
 	if i < 0 || i >= st.numFields() {
-		return StructField{}, Err(ref, D.Field, D.Out, D.Of, D.Range)
+		return StructField{}, newValueError("Type.Field", K.Struct, ErrOutOfRange)
 	}
 	f := st.getField(i)
 	if f == nil {
-		return StructField{}, Err(ref, D.Field, D.Out, D.Of, D.Range)
+		return StructField{}, newValueError("Type.Field", K.Struct, ErrOutOfRange)
 	}
 	return *f, nil
 }
@@ -77,22 +74,12 @@ func (t *Type) Field(i int) (StructField, error) {
 func (t *Type) NumField() (int, error) {
 	// Get underlying type first (handles named types)
 	ut := t.underlying()
-
-	// Convert to StructType and check if it has fields
-	st := (*StructType)(unsafe.Pointer(ut))
-	if st == nil {
-		return 0, Err(ref, D.Numbers, D.Fields, D.NotOfType, "Struct")
-	}
-
-	n := st.numFields()
-	if n == 0 {
-		// Verify it's actually a struct type
-		if ut.Kind() != K.Struct {
-			return 0, Err(ref, D.Numbers, D.Fields, D.Type, "Struct")
-		}
+	if ut.Kind() != K.Struct {
+		return 0, newValueError("Type.NumField", ut.Kind(), ErrNotStruct)
 	}
 
-	return n, nil
+	st := (*StructType)(unsafe.Pointer(ut))
+	return st.numFields(), nil
 }
 
 // PtrType represents a pointer type.
@@ -101,56 +88,39 @@ type PtrType struct {
 	Elem *Type // pointer element type
 }
 
-// Name returns the name of a struct type's i'th field.
-// It panics if the type's Kind is not Struct.
-// It panics if i is out of range.
-func (t *Type) NameByIndex(i int) (string, error) {
+// NameByIndex returns the name of a struct type's i'th field.
+// It returns an error if the type's Kind is not Struct or i is out of range.
+func (t *Type) NameByIndex(i int) (name string, err error) {
 	// Get underlying type first (handles named types)
 	ut := t.underlying()
-	tt := (*StructType)(unsafe.Pointer(ut))
-
-	if tt == nil {
-		println("DEBUG NameByIndex: StructType is nil")
-		return "", Err(ref, D.Type, D.NotOfType, "Struct")
-	}
-
-	numFields := tt.numFields()
-	println("DEBUG NameByIndex: numFields =", numFields, "requested i =", i)
-
-	// If no fields, verify it's actually supposed to be a struct
-	if numFields == 0 {
-		kind := ut.Kind()
-		println("DEBUG NameByIndex: numFields=0, checking kind =", kind)
-		if kind != K.Struct {
-			return "", Err(ref, D.Type, D.NotOfType, "Struct")
-		}
+	if ut.Kind() != K.Struct {
+		return "", traceErr("Type.NameByIndex", ut.Kind(), i, newValueError("Type.NameByIndex", ut.Kind(), ErrNotStruct))
 	}
+	tt := (*StructType)(unsafe.Pointer(ut))
 
-	if i < 0 || i >= numFields {
-		return "", Err(ref, D.Index, D.Out, D.Of, D.Range)
+	if i < 0 || i >= tt.numFields() {
+		return "", traceErr("Type.NameByIndex", K.Struct, i, newValueError("Type.NameByIndex", K.Struct, ErrOutOfRange))
 	}
 
 	f := tt.getField(i)
-	println("DEBUG NameByIndex: field pointer =", f != nil)
 	if f == nil {
-		return "", Err(ref, "field is nil")
+		return "", traceErr("Type.NameByIndex", K.Struct, i, newValueError("Type.NameByIndex", K.Struct, ErrOutOfRange))
 	}
 
-	println("DEBUG NameByIndex: f.Name.Bytes =", f.Name.Bytes != nil)
 	if f.Name.Bytes == nil {
-		println("DEBUG NameByIndex: Name.Bytes is nil, returning empty")
+		trace("Type.NameByIndex", K.Struct, i, nil)
 		return "", nil
 	}
 
-	// Try to safely read the name with error recovery
+	// Name data comes from runtime metadata; report unreadable data as an error
 	defer func() {
 		if r := recover(); r != nil {
-			println("DEBUG NameByIndex: PANIC recovered:", r)
+			name, err = "", traceErr("Type.NameByIndex", K.Struct, i, newValueError("Type.NameByIndex", K.Struct, ErrNilValue))
 		}
 	}()
 
-	name := f.Name.Name()
-	println("DEBUG NameByIndex: name =", name)
+	name = f.Name.Name()
+	trace("Type.NameByIndex", K.Struct, i, nil)
 	return name, nil
 }
 
@@ -159,7 +129,7 @@ func (t *Type) SliceType() *SliceType {
 	if t.Kind() != K.Slice {
 		return nil
 	}
-	return (*SliceType)(unsafe.Pointer(t))
+	return (*SliceType)(unsafe.Pointer(t.underlying()))
 }
 
 // ArrayType returns t cast to a *ArrayType, or nil if its tag does not match.
@@ -167,7 +137,7 @@ func (t *Type) ArrayType() *ArrayType {
 	if t.Kind() != K.Array {
 		return nil
 	}
-	return (*ArrayType)(unsafe.Pointer(t))
+	return (*ArrayType)(unsafe.Pointer(t.underlying()))
 }
 
 // PtrType returns t cast to a *PtrType, or nil if its tag does not match.
@@ -182,14 +152,14 @@ func (t *Type) PtrType() *PtrType {
 func (t *Type) Elem() *Type {
 	switch t.Kind() {
 	case K.Array:
-		tt := (*ArrayType)(unsafe.Pointer(t))
-		return tt.Elem
+		return t.ArrayType().Elem
 	case K.Pointer:
 		tt := (*PtrType)(unsafe.Pointer(t))
 		return tt.Elem
 	case K.Slice:
-		tt := (*SliceType)(unsafe.Pointer(t))
-		return tt.Elem
+		return t.SliceType().Elem
+	case K.Map:
+		return t.MapType().Elem
 	default:
 		return nil
 	}
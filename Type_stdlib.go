@@ -11,7 +11,7 @@ import (
 // Type is the runtime representation of a Go type (stdlib version).
 // This matches the stdlib's internal/abi.Type structure.
 type Type struct {
-	Size        uintptr
+	Size_       uintptr
 	PtrBytes    uintptr // number of (prefix) bytes in the type that can contain pointers
 	Hash        uint32  // Hash of type; avoids computation in Hash tables
 	TFlag       TFlag   // extra type information flags
@@ -46,9 +46,18 @@ func (t *Type) Kind() Kind {
 	return t.Kind_ & KindMask
 }
 
+// Size returns the number of bytes needed to store a value of the given type.
+func (t *Type) Size() uintptr {
+	return t.Size_
+}
+
+// tflagDirectIface marks types stored directly in an interface value.
+// Go 1.26+ moved this bit from Kind_ (KindDirectIface) to TFlag.
+const tflagDirectIface TFlag = 1 << 5
+
 // IfaceIndir reports whether t is stored indirectly in an interface value.
+// Pointer-shaped types (pointers, maps, chans, funcs and single-pointer
+// structs or arrays) are stored directly in the interface data word.
 func (t *Type) IfaceIndir() bool {
-	// Simplified for now - most types are stored indirectly
-	kind := t.Kind()
-	return kind != K.Pointer && kind != K.UnsafePointer
+	return t.TFlag&tflagDirectIface == 0 && t.Kind_&KindDirectIface == 0
 }
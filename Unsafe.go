@@ -0,0 +1,49 @@
+package tinyreflect
+
+import (
+	"unsafe"
+
+	. "github.com/cdvelop/tinystring"
+)
+
+// NewAt returns a Value representing a pointer to a value of the specified
+// type, using p as that pointer. The memory at p is wrapped without copying,
+// so the Value returned by Elem is addressable and writes go straight to p.
+func NewAt(typ *Type, p unsafe.Pointer) Value {
+	if typ == nil {
+		return Value{}
+	}
+	return Value{ptrTo(typ), p, flag(K.Pointer)}
+}
+
+// UnsafePointer returns v's value as an unsafe.Pointer.
+// For slices it returns the pointer to the first element.
+// It returns an error if v's Kind is not Pointer, Map, Slice or UnsafePointer.
+func (v Value) UnsafePointer() (unsafe.Pointer, error) {
+	switch v.kind() {
+	case K.Pointer, K.Map, K.UnsafePointer:
+		return v.pointer(), nil
+
+	case K.Slice:
+		return (*sliceHeader)(v.ptr).Data, nil
+	}
+	return nil, newValueError("UnsafePointer", v.kind(), ErrUnsupportedKind)
+}
+
+// Pointer returns v's value as a uintptr.
+// It returns an error if v's Kind is not Pointer, Map, Slice or UnsafePointer.
+//
+// Prefer UnsafePointer: a uintptr does not keep the memory it points to alive.
+func (v Value) Pointer() (uintptr, error) {
+	p, err := v.UnsafePointer()
+	return uintptr(p), err
+}
+
+// UnsafeAddr returns a pointer to v's data, as a uintptr.
+// It returns an error if v is not addressable.
+func (v Value) UnsafeAddr() (uintptr, error) {
+	if v.flag&flagAddr == 0 {
+		return 0, newValueError("UnsafeAddr", v.kind(), ErrNotAddressable)
+	}
+	return uintptr(v.ptr), nil
+}
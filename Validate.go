@@ -0,0 +1,427 @@
+package tinyreflect
+
+import (
+	"cmp"
+	"unicode/utf8"
+
+	"github.com/cdvelop/tinyreflect/internal/cache"
+	"github.com/cdvelop/tinyreflect/internal/num"
+	. "github.com/cdvelop/tinystring"
+)
+
+// Sentinel errors wrapped by each Violation, one per rule.
+var (
+	// ErrRequired is reported by "required" when the value is zero.
+	ErrRequired = &Error{[]any{D.Field, D.Required}}
+	// ErrBelowMin is reported by "min" when a number or a length is too small.
+	ErrBelowMin = &Error{[]any{D.Value, D.Out, D.Of, D.Range}}
+	// ErrAboveMax is reported by "max" when a number or a length is too large.
+	ErrAboveMax = &Error{[]any{D.Maximum, D.Exceeds}}
+	// ErrNotOneOf is reported by "oneof" when the value is not in the list.
+	ErrNotOneOf = &Error{[]any{D.Value, D.Not, D.Allowed}}
+	// ErrInvalidEmail is reported by "email" when the string is not an address.
+	ErrInvalidEmail = &Error{[]any{D.Invalid, D.Format}}
+)
+
+// Violation is a rule that a field did not satisfy.
+type Violation struct {
+	Path  string // field path, e.g. "Items[2].Name"
+	Rule  string // rule name, e.g. "min"
+	Param string // rule parameter, e.g. "3"
+	Err   error  // one of the rule sentinels
+}
+
+// Error returns "path: reason (rule=param)" with the reason translated.
+func (v *Violation) Error() string {
+	msg := v.Err.Error()
+	if v.Path != "" {
+		msg = v.Path + ": " + msg
+	}
+	if v.Param != "" {
+		msg += " (" + v.Rule + "=" + v.Param + ")"
+	}
+	return msg
+}
+
+// Unwrap returns the rule sentinel.
+func (v *Violation) Unwrap() error {
+	return v.Err
+}
+
+// ValidationErrors lists every violation found by Validate.
+type ValidationErrors []*Violation
+
+// Error joins the violations with "; ".
+func (e ValidationErrors) Error() string {
+	msg := ""
+	for i, v := range e {
+		if i > 0 {
+			msg += "; "
+		}
+		msg += v.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the violations, so errors.Is finds any rule sentinel.
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, v := range e {
+		errs[i] = v
+	}
+	return errs
+}
+
+// Validate checks v against the `validate` tags of its struct fields,
+// walking nested structs, pointers, slices and arrays. Rules are separated
+// by commas:
+//
+//	required     the value is not zero (IsZero)
+//	omitempty    skip the other rules when the value is zero
+//	min=N max=N  bounds for numbers, and for the length of strings (in
+//	             runes), slices, arrays and maps
+//	oneof=a|b    the string or number is one of the listed values
+//	email        the string looks like an e-mail address
+//
+// A field tagged `validate:"-"` is neither checked nor walked into. Rules
+// on a nil pointer other than required are skipped; otherwise they apply
+// to the value it points to.
+//
+// Validate returns nil when every rule holds, ValidationErrors with all the
+// violations otherwise, or a *ValueError when a tag is malformed or a rule
+// does not apply to the field's kind.
+func Validate(v any) error {
+	rv := ValueOf(v)
+	if rv.Kind() == K.Invalid {
+		return newValueError("Validate", K.Invalid, ErrNilValue)
+	}
+	w := validator{seen: map[uintptr]*Type{}}
+	if err := w.walk(rv, ""); err != nil {
+		return err
+	}
+	if len(w.errs) > 0 {
+		return w.errs
+	}
+	return nil
+}
+
+// validator holds the state of one Validate call.
+type validator struct {
+	errs ValidationErrors
+	seen map[uintptr]*Type // pointers already walked, to stop at cycles
+}
+
+// walk checks the struct fields reachable from v.
+func (w *validator) walk(v Value, path string) error {
+	switch v.Kind() {
+	case K.Pointer:
+		if v.IsZero() {
+			return nil
+		}
+		p, _ := v.Pointer()
+		if w.seen[p] == v.Type() {
+			return nil
+		}
+		w.seen[p] = v.Type()
+		elem, err := v.Elem()
+		if err != nil {
+			return err
+		}
+		return w.walk(elem, path)
+
+	case K.Interface:
+		if v.IsZero() {
+			return nil
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return err
+		}
+		return w.walk(elem, path)
+
+	case K.Slice, K.Array:
+		if k := v.Type().Elem().Kind(); k != K.Struct && k != K.Pointer && k != K.Interface && k != K.Slice && k != K.Array {
+			return nil
+		}
+		n, _ := v.Len()
+		for i := 0; i < n; i++ {
+			elem, err := v.Index(i)
+			if err != nil {
+				return err
+			}
+			if err := w.walk(elem, path+indexSegment(i)); err != nil {
+				return err
+			}
+		}
+
+	case K.Struct:
+		rules, err := cachedRules(v.Type())
+		if err != nil {
+			return err
+		}
+		for i := range rules {
+			f := &rules[i]
+			fv, err := v.Field(f.index)
+			if err != nil {
+				return err
+			}
+			fpath := f.name
+			if path != "" {
+				fpath = path + "." + f.name
+			}
+			if err := w.check(fv, fpath, f.rules); err != nil {
+				return err
+			}
+			if err := w.walk(fv, fpath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rule is one comma-separated entry of a validate tag.
+type rule struct {
+	name, param string
+}
+
+// fieldRules are the rules of one struct field.
+type fieldRules struct {
+	index int
+	name  string
+	rules []rule
+}
+
+// validateCache keeps the parsed validate tags of each struct type.
+var validateCache cache.Cache[*Type, []fieldRules]
+
+// cachedRules returns the exported fields of the struct type t that are
+// not tagged "-", with their rules.
+func cachedRules(t *Type) ([]fieldRules, error) {
+	rules, ok := validateCache.Load(t)
+	if ok {
+		return rules, nil
+	}
+
+	n, err := t.NumField()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		sf, err := t.Field(i)
+		if err != nil {
+			return nil, err
+		}
+		tag := sf.Tag().Get("validate")
+		if tag == "-" || !sf.IsExported() {
+			continue
+		}
+		f := fieldRules{index: i, name: sf.Name.String()}
+		for tag != "" {
+			entry, rest := ParseTag(tag)
+			tag = string(rest)
+			if entry == "" {
+				continue
+			}
+			r := rule{name: entry}
+			for j := 0; j < len(entry); j++ {
+				if entry[j] == '=' {
+					r = rule{entry[:j], entry[j+1:]}
+					break
+				}
+			}
+			if !validRule(r, sf.Typ) {
+				return nil, &ValueError{Method: "Validate", Kind: sf.Typ.Kind(), Path: f.name, Err: ErrInvalidArgument}
+			}
+			f.rules = append(f.rules, r)
+		}
+		rules = append(rules, f)
+	}
+
+	validateCache.Store(t, rules)
+	return rules, nil
+}
+
+// validRule reports whether r is a known rule with a parameter that suits
+// the type t, or the type t points to.
+func validRule(r rule, t *Type) bool {
+	if t.Kind() == K.Pointer {
+		t = t.Elem()
+	}
+	k := t.Kind()
+	switch r.name {
+	case "required", "omitempty":
+		return r.param == ""
+	case "min", "max":
+		switch {
+		case isIntKind(k):
+			_, st := num.ParseInt(r.param, 64)
+			return st == num.OK
+		case isUintKind(k):
+			_, st := num.ParseUint(r.param, 64)
+			return st == num.OK
+		case k == K.Float32 || k == K.Float64:
+			_, st := num.ParseFloat(r.param, 64)
+			return st == num.OK
+		case k == K.String || k == K.Slice || k == K.Array || k == K.Map:
+			n, st := num.ParseInt(r.param, 64)
+			return st == num.OK && n >= 0
+		}
+	case "oneof":
+		return r.param != "" && (k == K.String || isIntKind(k) || isUintKind(k))
+	case "email":
+		return r.param == "" && k == K.String
+	}
+	return false
+}
+
+func isIntKind(k Kind) bool {
+	return k == K.Int || k == K.Int8 || k == K.Int16 || k == K.Int32 || k == K.Int64
+}
+
+func isUintKind(k Kind) bool {
+	return k == K.Uint || k == K.Uint8 || k == K.Uint16 || k == K.Uint32 || k == K.Uint64 || k == K.Uintptr
+}
+
+// check applies rules to the field v and records the violations.
+func (w *validator) check(v Value, path string, rules []rule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	zero := v.IsZero()
+	for _, r := range rules {
+		if r.name == "required" && zero {
+			w.errs = append(w.errs, &Violation{Path: path, Rule: r.name, Err: ErrRequired})
+			return nil // the other rules would only repeat it
+		}
+		if r.name == "omitempty" && zero {
+			return nil
+		}
+	}
+	if v.Kind() == K.Pointer {
+		if zero {
+			return nil
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return err
+		}
+		v = elem
+	}
+
+	for _, r := range rules {
+		var failed *Error
+		switch r.name {
+		case "min":
+			if compareBound(v, r.param) < 0 {
+				failed = ErrBelowMin
+			}
+		case "max":
+			if compareBound(v, r.param) > 0 {
+				failed = ErrAboveMax
+			}
+		case "oneof":
+			if !oneOf(v, r.param) {
+				failed = ErrNotOneOf
+			}
+		case "email":
+			if !isEmail(v.String()) {
+				failed = ErrInvalidEmail
+			}
+		}
+		if failed != nil {
+			w.errs = append(w.errs, &Violation{Path: path, Rule: r.name, Param: r.param, Err: failed})
+		}
+	}
+	return nil
+}
+
+// compareBound compares v, or its length, with the bound in param and
+// returns -1, 0 or +1. The bound was checked by validRule.
+func compareBound(v Value, param string) int {
+	switch k := v.Kind(); {
+	case isIntKind(k):
+		x, _ := v.Int()
+		b, _ := num.ParseInt(param, 64)
+		return cmp.Compare(x, b)
+	case isUintKind(k):
+		x, _ := v.Uint()
+		b, _ := num.ParseUint(param, 64)
+		return cmp.Compare(x, b)
+	case k == K.Float32 || k == K.Float64:
+		x, _ := v.Float()
+		b, _ := num.ParseFloat(param, 64)
+		return cmp.Compare(x, b)
+	case k == K.String:
+		b, _ := num.ParseInt(param, 64)
+		return cmp.Compare(int64(utf8.RuneCountInString(v.String())), b)
+	}
+	n, _ := v.Len()
+	b, _ := num.ParseInt(param, 64)
+	return cmp.Compare(int64(n), b)
+}
+
+// oneOf reports whether v is one of the '|'-separated values in list.
+// Numbers are compared by their decimal form.
+func oneOf(v Value, list string) bool {
+	var s string
+	switch k := v.Kind(); {
+	case isIntKind(k):
+		x, _ := v.Int()
+		s = string(num.AppendInt(nil, x))
+	case isUintKind(k):
+		x, _ := v.Uint()
+		s = string(num.AppendUint(nil, x))
+	default:
+		s = v.String()
+	}
+	for list != "" {
+		opt := list
+		for i := 0; i < len(list); i++ {
+			if list[i] == '|' {
+				opt = list[:i]
+				break
+			}
+		}
+		if opt == s {
+			return true
+		}
+		if len(opt) == len(list) {
+			break
+		}
+		list = list[len(opt)+1:]
+	}
+	return false
+}
+
+// isEmail reports whether s has the shape local@domain.tld: one '@', a
+// non-empty local part and a domain with an inner dot, and no spaces or
+// control characters.
+func isEmail(s string) bool {
+	at := -1
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c <= ' ' || c == 0x7f:
+			return false
+		case c == '@':
+			if at >= 0 {
+				return false
+			}
+			at = i
+		}
+	}
+	if at <= 0 || at == len(s)-1 {
+		return false
+	}
+	domain := s[at+1:]
+	dot := false
+	for i := 0; i < len(domain); i++ {
+		if domain[i] == '.' {
+			if i == 0 || i == len(domain)-1 || domain[i-1] == '.' {
+				return false
+			}
+			dot = true
+		}
+	}
+	return dot
+}
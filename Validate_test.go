@@ -0,0 +1,150 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+type validateAddress struct {
+	City string `validate:"required"`
+	Zip  string `validate:"omitempty,min=5,max=5"`
+}
+
+type validateItem struct {
+	SKU   string  `validate:"required,min=3"`
+	Qty   uint16  `validate:"min=1,max=99"`
+	Price float64 `validate:"max=1000.5"`
+}
+
+type validateOrder struct {
+	Name     string           `validate:"required,min=3,max=20"`
+	Email    string           `validate:"email"`
+	Status   string           `validate:"oneof=new|paid|sent"`
+	Priority int8             `validate:"oneof=-1|0|1"`
+	Age      *int             `validate:"min=18"`
+	Ship     *validateAddress `validate:"required"`
+	Billing  validateAddress
+	Items    []validateItem    `validate:"min=1"`
+	Notes    []string          `validate:"max=2"`
+	Meta     map[string]string `validate:"max=1"`
+	Internal validateAddress   `validate:"-"`
+	private  string
+}
+
+func validOrder() validateOrder {
+	return validateOrder{
+		Name:    "Ana",
+		Email:   "ana@example.com",
+		Status:  "paid",
+		Ship:    &validateAddress{City: "Lima"},
+		Billing: validateAddress{City: "Quito", Zip: "17001"},
+		Items:   []validateItem{{SKU: "A-1", Qty: 2, Price: 9.99}},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	age := 17
+
+	testCases := []struct {
+		name   string
+		modify func(o *validateOrder)
+		want   []string // path and rule of each violation, in order
+	}{
+		{"Valid", func(o *validateOrder) {}, nil},
+		{"Required", func(o *validateOrder) { o.Name = "" }, []string{"Name required"}},
+		{"Min length in runes", func(o *validateOrder) { o.Name = "Añ" }, []string{"Name min"}},
+		{"Max length", func(o *validateOrder) { o.Name = "abcdefghijklmnopqrstu" }, []string{"Name max"}},
+		{"Email", func(o *validateOrder) { o.Email = "ana@example" }, []string{"Email email"}},
+		{"Oneof string", func(o *validateOrder) { o.Status = "lost" }, []string{"Status oneof"}},
+		{"Oneof int", func(o *validateOrder) { o.Priority = 2 }, []string{"Priority oneof"}},
+		{"Nil pointer skips min", func(o *validateOrder) { o.Age = nil }, nil},
+		{"Pointer min", func(o *validateOrder) { o.Age = &age }, []string{"Age min"}},
+		{"Required pointer", func(o *validateOrder) { o.Ship = nil }, []string{"Ship required"}},
+		{"Nested pointer", func(o *validateOrder) { o.Ship.City = "" }, []string{"Ship.City required"}},
+		{"Nested omitempty", func(o *validateOrder) { o.Billing.Zip = "" }, nil},
+		{"Nested length", func(o *validateOrder) { o.Billing.Zip = "170" }, []string{"Billing.Zip min"}},
+		{"Slice length", func(o *validateOrder) { o.Items = nil }, []string{"Items min"}},
+		{"Slice elements", func(o *validateOrder) {
+			o.Items = append(o.Items, validateItem{SKU: "B", Qty: 100, Price: 1000.75})
+		}, []string{"Items[1].SKU min", "Items[1].Qty max", "Items[1].Price max"}},
+		{"Map length", func(o *validateOrder) { o.Meta = map[string]string{"a": "", "b": ""} }, []string{"Meta max"}},
+		{"Skipped field", func(o *validateOrder) { o.Internal.City = "" }, nil},
+		{"All violations", func(o *validateOrder) {
+			o.Name, o.Email, o.Notes = "", "a b@c.d", []string{"", "", ""}
+		}, []string{"Name required", "Email email", "Notes max"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := validOrder()
+			o.Internal.City = "x"
+			tc.modify(&o)
+			err := tinyreflect.Validate(&o)
+			if tc.want == nil {
+				if err != nil {
+					t.Fatalf("got %v, want nil", err)
+				}
+				return
+			}
+			var errs tinyreflect.ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("got %v, want ValidationErrors", err)
+			}
+			if len(errs) != len(tc.want) {
+				t.Fatalf("got %v, want %d violations", err, len(tc.want))
+			}
+			for i, v := range errs {
+				if got := v.Path + " " + v.Rule; got != tc.want[i] {
+					t.Errorf("violation %d: got %q, want %q", i, got, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	o := validOrder()
+	o.Name = "Al"
+	o.Ship = nil
+	err := tinyreflect.Validate(o)
+	if !errors.Is(err, tinyreflect.ErrBelowMin) || !errors.Is(err, tinyreflect.ErrRequired) {
+		t.Errorf("got %v, want ErrBelowMin and ErrRequired", err)
+	}
+	if want := "Name: Value Out of Range (min=3); Ship: Field Required"; err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+
+	type Node struct {
+		Name string `validate:"required"`
+		Next *Node
+	}
+	n := &Node{}
+	n.Next = n
+	var errs tinyreflect.ValidationErrors
+	if err := tinyreflect.Validate(n); !errors.As(err, &errs) || len(errs) != 1 {
+		t.Errorf("cycle: got %v, want one violation", err)
+	}
+
+	type UnknownRule struct {
+		F string `validate:"uuid"`
+	}
+	type BadBound struct {
+		F int `validate:"min=x"`
+	}
+	type EmailOnInt struct {
+		F int `validate:"email"`
+	}
+	type NegativeLength struct {
+		F []int `validate:"max=-1"`
+	}
+	for _, v := range []any{UnknownRule{}, BadBound{}, EmailOnInt{}, NegativeLength{}} {
+		if err := tinyreflect.Validate(v); !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+			t.Errorf("%T: got %v, want ErrInvalidArgument", v, err)
+		}
+	}
+	if err := tinyreflect.Validate(nil); !errors.Is(err, tinyreflect.ErrNilValue) {
+		t.Errorf("nil: got %v, want ErrNilValue", err)
+	}
+}
@@ -12,26 +12,32 @@ func (v Value) Index(i int) (Value, error) {
 	switch v.kind() {
 	case K.Array:
 		if v.typ_ == nil {
-			return Value{}, Err(D.Value, D.Type, D.Nil)
+			return Value{}, newValueError("Index", v.kind(), ErrNilValue)
 		}
-		arrayType := (*ArrayType)(unsafe.Pointer(v.typ_))
+		arrayType := v.typ_.ArrayType()
 		if uint(i) >= uint(arrayType.Len) {
-			return Value{}, Err(D.Index, D.Out, D.Of, D.Range)
+			return Value{}, newValueError("Index", v.kind(), ErrOutOfRange)
 		}
 
+		// Elements of an addressable array are addressable, elements of a
+		// copied array are not.
 		elemType := arrayType.Elem
+		fl := v.flag&(flagIndir|flagAddr|flagRO) | flag(elemType.Kind())
+		if v.flag&flagIndir == 0 {
+			// Single pointer-shaped element stored directly in v.ptr
+			return Value{elemType, v.ptr, fl}, nil
+		}
 		elemAddr := unsafe.Pointer(uintptr(v.ptr) + uintptr(i)*getElemSize(elemType))
-		fl := v.flag&^flagKindMask | flag(elemType.Kind()) | flagIndir | flagAddr
 		return Value{elemType, elemAddr, fl}, nil
 
 	case K.Slice:
 		sliceHeader := (*sliceHeader)(v.ptr)
 		if uint(i) >= uint(sliceHeader.Len) {
-			return Value{}, Err(D.Index, D.Out, D.Of, D.Range)
+			return Value{}, newValueError("Index", v.kind(), ErrOutOfRange)
 		}
 
 		if v.typ_ == nil {
-			return Value{}, Err(D.Value, D.Type, D.Nil)
+			return Value{}, newValueError("Index", v.kind(), ErrNilValue)
 		}
 		sliceType := (*SliceType)(unsafe.Pointer(v.typ_))
 		elemType := sliceType.Elem
@@ -42,7 +48,7 @@ func (v Value) Index(i int) (Value, error) {
 	case K.String:
 		stringHeader := (*stringHeader)(v.ptr)
 		if uint(i) >= uint(stringHeader.Len) {
-			return Value{}, Err(D.Index, D.Out, D.Of, D.Range)
+			return Value{}, newValueError("Index", v.kind(), ErrOutOfRange)
 		}
 
 		byteAddr := unsafe.Pointer(uintptr(stringHeader.Data) + uintptr(i))
@@ -51,19 +57,29 @@ func (v Value) Index(i int) (Value, error) {
 		return Value{uint8Type, byteAddr, fl}, nil
 	}
 
-	return Value{}, Err(D.Call, D.Of, "Index", D.Method, v.kind().String(), D.Value)
+	return Value{}, newValueError("Index", v.kind(), ErrUnsupportedKind)
 }
 
 // Len returns v's length.
-// It returns an error if v's Kind is not Array, Chan, Map, Slice, or String.
+// It returns an error if v's Kind is not Array, Map, Slice, String or pointer to Array.
 func (v Value) Len() (int, error) {
 	switch v.kind() {
+	case K.Map:
+		if m := v.pointer(); m != nil {
+			return maplen(m), nil
+		}
+		return 0, nil
+
 	case K.Array:
 		if v.typ_ == nil {
-			return 0, Err(D.Value, D.Type, D.Nil)
+			return 0, newValueError("Len", v.kind(), ErrNilValue)
+		}
+		return v.typ_.ArrayType().Length(), nil
+
+	case K.Pointer:
+		if at := v.typ_.Elem().ArrayType(); at != nil {
+			return at.Length(), nil
 		}
-		arrayType := (*ArrayType)(unsafe.Pointer(v.typ_))
-		return int(arrayType.Len), nil
 
 	case K.Slice:
 		sliceHeader := (*sliceHeader)(v.ptr)
@@ -74,7 +90,7 @@ func (v Value) Len() (int, error) {
 		return stringHeader.Len, nil
 	}
 
-	return 0, Err(D.Call, D.Of, "Len", D.Method, v.kind().String(), D.Value)
+	return 0, newValueError("Len", v.kind(), ErrUnsupportedKind)
 }
 
 // Cap returns v's capacity.
@@ -83,17 +99,88 @@ func (v Value) Cap() (int, error) {
 	switch v.kind() {
 	case K.Array:
 		if v.typ_ == nil {
-			return 0, Err(D.Value, D.Type, D.Nil)
+			return 0, newValueError("Cap", v.kind(), ErrNilValue)
+		}
+		return v.typ_.ArrayType().Length(), nil
+
+	case K.Pointer:
+		if at := v.typ_.Elem().ArrayType(); at != nil {
+			return at.Length(), nil
 		}
-		arrayType := (*ArrayType)(unsafe.Pointer(v.typ_))
-		return int(arrayType.Len), nil
 
 	case K.Slice:
 		sliceHeader := (*sliceHeader)(v.ptr)
 		return sliceHeader.Cap, nil
 	}
 
-	return 0, Err(D.Call, D.Of, "Cap", D.Method, v.kind().String(), D.Value)
+	return 0, newValueError("Cap", v.kind(), ErrUnsupportedKind)
+}
+
+// Slice returns v[i:j].
+// It returns an error if v's Kind is not Array, Slice or String, if v is an
+// unaddressable array, or if the indexes are out of bounds.
+func (v Value) Slice(i, j int) (Value, error) {
+	var (
+		cap   int
+		typ   *Type
+		base  unsafe.Pointer
+		elemT *Type
+	)
+
+	switch v.kind() {
+	case K.Array:
+		if v.flag&flagAddr == 0 {
+			return Value{}, newValueError("Slice", K.Array, ErrNotAddressable)
+		}
+		arrayType := v.typ_.ArrayType()
+		cap = arrayType.Length()
+		typ = arrayType.Slice
+		elemT = arrayType.Elem
+		base = v.ptr
+
+	case K.Slice:
+		header := (*sliceHeader)(v.ptr)
+		cap = header.Cap
+		typ = v.typ_
+		elemT = v.typ_.Elem()
+		base = header.Data
+
+	case K.String:
+		str := *(*string)(v.ptr)
+		if i < 0 || j < i || j > len(str) {
+			return Value{}, newValueError("Slice", v.kind(), ErrOutOfRange)
+		}
+		sub := str[i:j]
+		return Value{v.typ_, unsafe.Pointer(&sub), v.flag&flagRO | flagIndir | flag(K.String)}, nil
+
+	default:
+		return Value{}, newValueError("Slice", v.kind(), ErrUnsupportedKind)
+	}
+
+	if i < 0 || j < i || j > cap {
+		return Value{}, newValueError("Slice", v.kind(), ErrOutOfRange)
+	}
+
+	// The new header shares the backing memory of v
+	header := &sliceHeader{Data: base, Len: j - i, Cap: cap - i}
+	if cap-i > 0 {
+		header.Data = add(base, uintptr(i)*getElemSize(elemT), "i < cap")
+	}
+	return Value{typ, unsafe.Pointer(header), v.flag&flagRO | flagIndir | flag(K.Slice)}, nil
+}
+
+// SetIndex assigns x to v's i'th element.
+// It returns an error if v's Kind is not Array or Slice, if i is out of range
+// or if x is not assignable to the element type.
+func (v Value) SetIndex(i int, x Value) error {
+	if k := v.kind(); k != K.Array && k != K.Slice {
+		return newValueError("SetIndex", k, ErrUnsupportedKind)
+	}
+	elem, err := v.Index(i)
+	if err != nil {
+		return err
+	}
+	return elem.Set(x)
 }
 
 // IsNil reports whether its argument v is nil.
@@ -106,28 +193,39 @@ func (v Value) IsNil() (bool, error) {
 		}
 		return v.ptr == nil, nil
 
+	case K.Map:
+		return v.pointer() == nil, nil
+
 	case K.Slice:
 		sliceHeader := (*sliceHeader)(v.ptr)
 		return sliceHeader.Data == nil, nil
 
 	case K.Interface:
+		if v.flag&flagIndir != 0 {
+			return (*EmptyInterface)(v.ptr).Type == nil, nil
+		}
 		return v.ptr == nil, nil
 	}
 
-	return false, Err(D.Call, D.Of, "IsNil", D.Method, v.kind().String(), D.Value)
+	return false, newValueError("IsNil", v.kind(), ErrUnsupportedKind)
 }
 
 // Addr method is implemented in ValueMethods_stdlib.go and ValueMethods_tinygo.go
 
 // Set assigns x to the value v.
-// It returns an error if CanSet would return false.
+// It returns an error if CanSet would return false, or if x's type does
+// not match v's type. Any value can be assigned to an empty interface.
 func (v Value) Set(x Value) error {
-	if err := v.mustBeAssignable(); err != nil {
+	if err := v.mustBeAssignable("Set"); err != nil {
 		return err
 	}
 
 	if v.typ_ == nil || x.typ_ == nil {
-		return Err(D.Value, D.Type, D.Nil)
+		return newValueError("Set", v.kind(), ErrNilValue)
+	}
+
+	if v.kind() == K.Interface && x.kind() != K.Interface {
+		return v.setInterface(x)
 	}
 
 	// Allow assignment between compatible pointer types
@@ -135,29 +233,27 @@ func (v Value) Set(x Value) error {
 		// For pointers, allow assignment if pointing to compatible types
 		// This is more permissive than strict type equality
 	} else if v.typ_ != x.typ_ {
-		return Err(D.Value, D.Of, D.Type, x.typ_.String(), D.Not, "assignable", D.Type, v.typ_.String())
+		return newValueError("Set", v.kind(), ErrTypeMismatch)
 	}
 
-	size := getTypeSize(v.typ_)
-	if size == 0 {
+	if getTypeSize(v.typ_) == 0 {
 		return nil
 	}
+	typedmemmove(v.typ_, v.ptr, x.dataPointer())
+	return nil
+}
 
-	var srcPtr, dstPtr unsafe.Pointer
-	if x.flag&flagIndir != 0 {
-		srcPtr = x.ptr
-	} else {
-		srcPtr = unsafe.Pointer(&x.ptr)
+// setInterface stores a copy of x in the interface v.
+func (v Value) setInterface(x Value) error {
+	if !acceptsAny(v.typ_) {
+		return newValueError("Set", K.Interface, ErrTypeMismatch)
 	}
-	if v.flag&flagIndir != 0 {
-		dstPtr = v.ptr
-	} else {
-		dstPtr = unsafe.Pointer(&v.ptr)
+	if x.typ_.IfaceIndir() {
+		// The interface must own its data, not alias x's memory
+		c := unsafeNew(x.typ_)
+		typedmemmove(x.typ_, c, x.dataPointer())
+		x = Value{x.typ_, c, flag(x.kind()) | flagIndir}
 	}
-
-	for i := uintptr(0); i < size; i++ {
-		*(*byte)(unsafe.Pointer(uintptr(dstPtr) + i)) = *(*byte)(unsafe.Pointer(uintptr(srcPtr) + i))
-	}
-
+	*(*any)(v.ptr) = packEface(x)
 	return nil
 }
@@ -3,8 +3,6 @@
 package tinyreflect
 
 import (
-	"unsafe"
-
 	. "github.com/cdvelop/tinystring"
 )
 
@@ -12,28 +10,23 @@ import (
 // It returns an error if CanAddr returns false.
 func (v Value) Addr() (Value, error) {
 	if v.flag&flagAddr == 0 {
-		return Value{}, Err(D.Value, D.Not, "addressable")
+		return Value{}, newValueError("Addr", v.kind(), ErrNotAddressable)
 	}
 
 	if v.typ_ == nil {
-		return Value{}, Err(D.Value, D.Type, D.Nil)
-	}
-
-	ptrType := &PtrType{
-		Type: Type{Kind_: K.Pointer, Size: unsafe.Sizeof(uintptr(0))},
-		Elem: v.typ_,
+		return Value{}, newValueError("Addr", v.kind(), ErrNilValue)
 	}
 
 	fl := (v.flag & flagRO) | flag(K.Pointer)
-	return Value{&ptrType.Type, v.ptr, fl}, nil
+	return Value{ptrTo(v.typ_), v.ptr, fl}, nil
 }
 
 // getElemSize returns the size of an element type (stdlib version)
 func getElemSize(elemType *Type) uintptr {
-	return elemType.Size
+	return elemType.Size()
 }
 
 // getTypeSize returns the size of a type (stdlib version)
 func getTypeSize(typ *Type) uintptr {
-	return typ.Size
+	return typ.Size()
 }
@@ -10,22 +10,15 @@ import (
 // It returns an error if CanAddr returns false.
 func (v Value) Addr() (Value, error) {
 	if v.flag&flagAddr == 0 {
-		return Value{}, Err(D.Value, D.Not, "addressable")
+		return Value{}, newValueError("Addr", v.kind(), ErrNotAddressable)
 	}
 
 	if v.typ_ == nil {
-		return Value{}, Err(D.Value, D.Type, D.Nil)
-	}
-
-	// For TinyGo, we can't create Type literals
-	// We need to use TypeOf to get a proper pointer type
-	ptrType := &PtrType{
-		Type: Type{meta: uint8(K.Pointer)},
-		Elem: v.typ_,
+		return Value{}, newValueError("Addr", v.kind(), ErrNilValue)
 	}
 
 	fl := (v.flag & flagRO) | flag(K.Pointer)
-	return Value{&ptrType.Type, v.ptr, fl}, nil
+	return Value{ptrTo(v.typ_), v.ptr, fl}, nil
 }
 
 // getElemSize returns the size of an element type (TinyGo version)
@@ -59,16 +59,23 @@ type Value struct {
 	// in r's type's method table.
 }
 
-// Elem returns the value that the pointer v points to.
-// It panics if v's Kind is not Ptr.
-// It returns the zero Value if v is a nil pointer.
+// Elem returns the value that the interface v contains or that the pointer v points to.
+// It returns an error if v's Kind is not Interface or Pointer.
+// It returns the zero Value if v is nil.
 func (v Value) Elem() (Value, error) {
 	k := v.kind()
 	switch k {
 	case K.Interface:
-		// Interface handling - simplified version
-		// For now we'll return an error for interfaces
-		return Value{}, Err(ref, D.Not, D.Supported, "interface elem not yet implemented")
+		// The interface holds a (type, data) pair; a nil interface has no element
+		typ, data := ifaceElem(v.typ(), v.ptr)
+		if typ == nil {
+			return Value{}, nil
+		}
+		fl := v.flag&flagRO | flag(typ.Kind())
+		if typ.IfaceIndir() {
+			fl |= flagIndir
+		}
+		return Value{typ, data, fl}, nil
 
 	case K.Pointer:
 		ptr := v.ptr
@@ -82,27 +89,27 @@ func (v Value) Elem() (Value, error) {
 		// Use the Type.Elem() method to get the element type
 		typ := v.typ().Elem()
 		if typ == nil {
-			return Value{}, Err(ref, D.Value, D.Type, D.Nil)
+			return Value{}, newValueError("Elem", k, ErrNilValue)
 		}
 		fl := v.flag&flagRO | flagIndir | flagAddr
 		fl |= flag(typ.Kind())
 		return Value{typ, ptr, fl}, nil
 	}
-	return Value{}, Err(ref, D.Value, D.NotOfType, D.Pointer)
+	return Value{}, newValueError("Elem", k, ErrUnsupportedKind)
 }
 
 func (v Value) NumField() (int, error) {
 	if v.typ_ == nil {
-		return 0, Err(ref, D.Value, D.Nil)
+		return 0, newValueError("NumField", K.Invalid, ErrNilValue)
 	}
 	if v.kind() != K.Struct {
-		return 0, Err(ref, D.Numbers, D.Fields, D.NotOfType, "Struct")
+		return 0, newValueError("NumField", v.kind(), ErrNotStruct)
 	}
 
 	// Direct reflection approach (no caching)
 	st := v.typ_.StructType()
 	if st == nil {
-		return 0, Err(ref, D.Numbers, D.Fields, D.NotOfType, "Struct")
+		return 0, newValueError("NumField", v.kind(), ErrNotStruct)
 	}
 	return st.numFields(), nil
 }
@@ -110,41 +117,23 @@ func (v Value) NumField() (int, error) {
 // Field returns the i'th field of the struct v.
 // Returns an error if v is not a struct or i is out of range.
 func (v Value) Field(i int) (Value, error) {
+	if v.kind() != K.Struct {
+		return Value{}, traceErr("Field", v.kind(), i, newValueError("Field", v.kind(), ErrNotStruct))
+	}
 	// Get underlying type and cast to StructType
 	ut := v.typ().underlying()
 	tt := (*StructType)(unsafe.Pointer(ut))
 
-	if tt == nil {
-		println("DEBUG Value.Field: StructType is nil")
-		return Value{}, Err(ref, D.Value, D.NotOfType, "Struct")
-	}
-
-	numFields := tt.numFields()
-	println("DEBUG Value.Field: numFields =", numFields, "requested i =", i)
-
-	// If no fields, verify it's actually supposed to be a struct
-	if numFields == 0 {
-		kind := ut.Kind()
-		println("DEBUG Value.Field: numFields=0, checking kind =", kind)
-		if kind != K.Struct {
-			return Value{}, Err(ref, D.Value, D.NotOfType, "Struct")
-		}
-	}
-
-	if uint(i) >= uint(numFields) {
-		println("DEBUG Value.Field: Index out of range")
-		return Value{}, Err(ref, D.Value, D.Index, D.Out, D.Of, D.Range)
+	if uint(i) >= uint(tt.numFields()) {
+		return Value{}, traceErr("Field", K.Struct, i, newValueError("Field", K.Struct, ErrOutOfRange))
 	}
 
 	field := tt.getField(i)
-	println("DEBUG Value.Field: field =", field != nil)
 	if field == nil {
-		return Value{}, Err(ref, D.Field, "nil")
+		return Value{}, traceErr("Field", K.Struct, i, newValueError("Field", K.Struct, ErrOutOfRange))
 	}
 
 	typ := field.Typ
-	println("DEBUG Value.Field: field.Typ =", typ != nil)
-
 	fl := v.flag&(flagStickyRO|flagIndir|flagAddr) | flag(typ.Kind())
 	if !field.Name.IsExported() {
 		if field.Embedded() {
@@ -154,7 +143,7 @@ func (v Value) Field(i int) (Value, error) {
 		}
 	}
 	ptr := add(v.ptr, field.Off, "same as non-reflect &v.field")
-	println("DEBUG Value.Field: Returning field successfully")
+	trace("Field", K.Struct, i, nil)
 	return Value{typ, ptr, fl}, nil
 }
 
@@ -178,6 +167,15 @@ func (v Value) typ() *Type {
 	return v.typ_
 }
 
+// pointer returns the pointer held by a pointer-shaped value (pointer or map),
+// loading it first when v refers to the memory that holds it.
+func (v Value) pointer() unsafe.Pointer {
+	if v.flag&flagIndir != 0 {
+		return *(*unsafe.Pointer)(v.ptr)
+	}
+	return v.ptr
+}
+
 // add returns p+x.
 //
 // The whySafe string is ignored, so that the function still inlines
@@ -261,7 +259,7 @@ func (v Value) Int() (int64, error) {
 	case K.Int64:
 		return *(*int64)(p), nil
 	}
-	return 0, Err(ref, D.Value, D.NotOfType, "int")
+	return 0, newValueError("Int", k, ErrUnsupportedKind)
 }
 
 // Uint returns v's underlying value, as a uint64.
@@ -283,7 +281,7 @@ func (v Value) Uint() (uint64, error) {
 	case K.Uintptr:
 		return uint64(*(*uintptr)(p)), nil
 	}
-	return 0, Err(ref, D.Value, D.NotOfType, "uint")
+	return 0, newValueError("Uint", k, ErrUnsupportedKind)
 }
 
 // Float returns v's underlying value, as a float64.
@@ -296,14 +294,14 @@ func (v Value) Float() (float64, error) {
 	case K.Float64:
 		return *(*float64)(v.ptr), nil
 	}
-	return 0, Err(ref, D.Value, D.NotOfType, "float")
+	return 0, newValueError("Float", k, ErrUnsupportedKind)
 }
 
 // Bool returns v's underlying value.
 // It returns an error if v's Kind is not Bool.
 func (v Value) Bool() (bool, error) {
 	if v.kind() != K.Bool {
-		return false, Err(ref, D.Value, D.NotOfType, "bool")
+		return false, newValueError("Bool", v.kind(), ErrUnsupportedKind)
 	}
 	return *(*bool)(v.ptr), nil
 }
@@ -347,7 +345,13 @@ func (v Value) IsZero() bool {
 		return *(*float32)(v.ptr) == 0
 	case K.Float64:
 		return *(*float64)(v.ptr) == 0
-	case K.Pointer, K.Interface:
+	case K.Pointer, K.Map:
+		return v.pointer() == nil
+	case K.Interface:
+		if v.flag&flagIndir != 0 {
+			// Both eface and iface keep their type word first
+			return (*EmptyInterface)(v.ptr).Type == nil
+		}
 		return v.ptr == nil
 	case K.Slice:
 		// For slices, check if the data pointer is nil
@@ -358,8 +362,19 @@ func (v Value) IsZero() bool {
 		// First field is the data pointer
 		dataPtr := (*uintptr)(v.ptr)
 		return *dataPtr == 0
-	case K.Map:
-		return v.ptr == nil
+	case K.Array:
+		// Arrays are zero when every element is zero
+		n, err := v.Len()
+		if err != nil {
+			return false
+		}
+		for i := 0; i < n; i++ {
+			elem, err := v.Index(i)
+			if err != nil || !elem.IsZero() {
+				return false
+			}
+		}
+		return true
 	case K.Struct:
 		// Recursively check all fields
 		num, err := v.NumField()
@@ -399,17 +414,14 @@ func (v Value) InterfaceZeroAlloc(target *any) {
 
 	k := v.kind()
 
-	// For primitive types, use direct unsafe manipulation to avoid boxing
+	// For primitive types and arrays, use direct unsafe manipulation to avoid boxing
 	switch k {
 	case K.String, K.Int, K.Int8, K.Int16, K.Int32, K.Int64,
 		K.Uint, K.Uint8, K.Uint16, K.Uint32, K.Uint64, K.Uintptr,
-		K.Bool, K.Float32, K.Float64:
+		K.Bool, K.Float32, K.Float64, K.Array:
 
-		// Use packEface technique but directly modify the target
-		t := v.typ()
-		e := (*EmptyInterface)(unsafe.Pointer(target))
-		e.Type = t
-		e.Data = v.ptr
+		// packEface only rewrites the interface words, it never copies the data
+		*target = packEface(v)
 
 	default:
 		// For complex types (slice, map, struct, interface, etc.), use standard boxing
@@ -0,0 +1,204 @@
+package tinyreflect
+
+import . "github.com/cdvelop/tinystring"
+
+// WalkAction tells Walk how to go on after a node is entered.
+type WalkAction uint8
+
+const (
+	WalkContinue WalkAction = iota // visit the node's children
+	WalkSkip                       // leave out the node's children
+	WalkStop                       // end the walk without an error
+)
+
+// Node is one value visited by Walk. Pointers and interfaces have their
+// target as their only child, with the same path.
+type Node struct {
+	Value  Value
+	Path   string      // e.g. "Items[2].Price"; "" for the root
+	Parent *Node       // nil for the root
+	Field  StructField // the field for struct fields; zero otherwise
+	Depth  int
+	// Cycle reports a pointer, map or slice that is already being walked
+	// further up; its children are not visited again.
+	Cycle bool
+
+	owner Value // the map holding Value, if any
+	key   Value // the map key of Value
+	boxed bool  // Value is the content of the interface Parent
+}
+
+// Replace stores x in place of the node's value, and the walk goes on
+// into the stored value. Map entries and interface contents are written back to their
+// map or interface; other values must be settable. Values inside a map
+// entry or an interface are copies and cannot be replaced on their own.
+func (n *Node) Replace(x Value) error {
+	var err error
+	switch {
+	case n.boxed:
+		if err := n.Parent.Replace(x); err != nil {
+			return err // the parent's path is n.Path already
+		}
+		x, err = n.Parent.Value.Elem()
+	case n.owner.Kind() == K.Map:
+		if err = n.owner.SetMapIndex(n.key, x); err == nil {
+			x, err = n.owner.MapIndex(n.key)
+		}
+	default:
+		return withPath(n.Value.Set(x), n.Path)
+	}
+	if err != nil {
+		return withPath(err, n.Path)
+	}
+	// Walk on into the copy the interface or map now holds, not into x
+	n.Value = x
+	return nil
+}
+
+// Visitor holds the callbacks Walk calls for each node. Either may be nil.
+// Leave is called for every node Enter was called for, after its
+// children, unless the walk stopped or failed.
+type Visitor struct {
+	Enter func(n *Node) (WalkAction, error)
+	Leave func(n *Node) error
+}
+
+// Walk visits v and every value reachable from it depth first: struct
+// fields in declaration order, including unexported ones, slice and array
+// elements, map entries in key order, and the targets of pointers and
+// interfaces. Map keys must be scalars. An error returned by a callback
+// ends the walk and is returned.
+func Walk(v Value, visitor Visitor) error {
+	w := walker{Visitor: visitor, active: make(map[walkRef]bool)}
+	_, err := w.visit(&Node{Value: v})
+	return err
+}
+
+// walkRef identifies a pointer, map or slice being walked. The type is
+// part of it because a struct and its first field share an address.
+type walkRef struct {
+	p   uintptr
+	typ *Type
+}
+
+type walker struct {
+	Visitor
+	active map[walkRef]bool
+}
+
+// refOf returns the reference of a non-nil pointer, map or slice.
+func refOf(v Value) (walkRef, bool) {
+	switch v.Kind() {
+	case K.Pointer, K.Map, K.Slice:
+		if p, _ := v.Pointer(); p != 0 {
+			return walkRef{p, v.Type()}, true
+		}
+	}
+	return walkRef{}, false
+}
+
+// visit walks n and its children and reports whether the walk stopped.
+func (w *walker) visit(n *Node) (bool, error) {
+	ref, ok := refOf(n.Value)
+	n.Cycle = ok && w.active[ref]
+
+	action := WalkContinue
+	if w.Enter != nil {
+		var err error
+		if action, err = w.Enter(n); err != nil || action == WalkStop {
+			return true, err
+		}
+		// Replace may have changed the value
+		ref, ok = refOf(n.Value)
+		n.Cycle = ok && w.active[ref]
+	}
+
+	if action == WalkContinue && !n.Cycle {
+		if ok {
+			w.active[ref] = true
+		}
+		stop, err := w.children(n)
+		if ok {
+			delete(w.active, ref)
+		}
+		if stop || err != nil {
+			return true, err
+		}
+	}
+
+	if w.Leave != nil {
+		if err := w.Leave(n); err != nil {
+			return true, err
+		}
+	}
+	return false, nil
+}
+
+func (w *walker) children(n *Node) (bool, error) {
+	v := n.Value
+	child := func(c Node) (bool, error) {
+		c.Parent, c.Depth = n, n.Depth+1
+		return w.visit(&c)
+	}
+
+	switch k := v.Kind(); k {
+	case K.Pointer, K.Interface:
+		if isNil, _ := v.IsNil(); isNil {
+			return false, nil
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return true, withPath(err, n.Path)
+		}
+		return child(Node{Value: elem, Path: n.Path, boxed: k == K.Interface})
+
+	case K.Struct:
+		t := v.Type()
+		fields, _ := t.NumField()
+		for i := 0; i < fields; i++ {
+			sf, err := t.Field(i)
+			if err != nil {
+				return true, err
+			}
+			f, err := v.Field(i)
+			if err != nil {
+				return true, withPath(err, n.Path)
+			}
+			if stop, err := child(Node{Value: f, Path: joinPath(n.Path, sf.Name.String()), Field: sf}); stop || err != nil {
+				return true, err
+			}
+		}
+
+	case K.Slice, K.Array:
+		if k == K.Slice && v.Type().Elem().Kind() == K.Uint8 {
+			return false, nil // []byte is a leaf
+		}
+		length, _ := v.Len()
+		for i := 0; i < length; i++ {
+			elem, err := v.Index(i)
+			if err != nil {
+				return true, withPath(err, n.Path)
+			}
+			if stop, err := child(Node{Value: elem, Path: n.Path + indexSegment(i)}); stop || err != nil {
+				return true, err
+			}
+		}
+
+	case K.Map:
+		keys, err := sortedKeys(v, v)
+		if err != nil {
+			return true, withPath(err, n.Path)
+		}
+		for _, mk := range keys {
+			elem, err := v.MapIndex(mk.key)
+			if err != nil {
+				return true, withPath(err, n.Path)
+			}
+			c := Node{Value: elem, Path: keyPath(n.Path, mk.seg), owner: v, key: mk.key}
+			if stop, err := child(c); stop || err != nil {
+				return true, err
+			}
+		}
+	}
+	return false, nil
+}
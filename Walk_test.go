@@ -0,0 +1,254 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+type walkUser struct {
+	Name     string
+	Password string `redact:"true"`
+	Tags     []string
+	Meta     map[string]any
+	Friend   *walkUser
+	age      int
+}
+
+// walkTrace records "enter"/"leave" events as "+path@depth" and "-path".
+func walkTrace(t *testing.T, v tinyreflect.Value, enter func(n *tinyreflect.Node) tinyreflect.WalkAction) []string {
+	t.Helper()
+	var got []string
+	err := tinyreflect.Walk(v, tinyreflect.Visitor{
+		Enter: func(n *tinyreflect.Node) (tinyreflect.WalkAction, error) {
+			got = append(got, "+"+n.Path+"@"+string(rune('0'+n.Depth)))
+			if enter != nil {
+				return enter(n), nil
+			}
+			return tinyreflect.WalkContinue, nil
+		},
+		Leave: func(n *tinyreflect.Node) error {
+			got = append(got, "-"+n.Path)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	return got
+}
+
+func TestWalkOrder(t *testing.T) {
+	u := walkUser{Name: "a", Tags: []string{"x"}, Meta: map[string]any{"b": 1, "a": nil}}
+	got := walkTrace(t, tinyreflect.ValueOf(u), nil)
+	want := []string{
+		"+@0",
+		"+Name@1", "-Name",
+		"+Password@1", "-Password",
+		"+Tags@1", "+Tags[0]@2", "-Tags[0]", "-Tags",
+		"+Meta@1",
+		"+Meta.a@2", "-Meta.a",
+		"+Meta.b@2", "+Meta.b@3", "-Meta.b", "-Meta.b",
+		"-Meta",
+		"+Friend@1", "-Friend",
+		"+age@1", "-age",
+		"-",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestWalkNodes(t *testing.T) {
+	u := walkUser{Friend: &walkUser{Name: "f"}}
+	var seen bool
+	err := tinyreflect.Walk(tinyreflect.ValueOf(&u), tinyreflect.Visitor{
+		Enter: func(n *tinyreflect.Node) (tinyreflect.WalkAction, error) {
+			if n.Path != "Friend.Name" {
+				return tinyreflect.WalkContinue, nil
+			}
+			seen = true
+			if n.Field.Name.String() != "Name" || !n.Field.IsExported() || n.Depth != 4 {
+				t.Errorf("field %q exported %v depth %d", n.Field.Name.String(), n.Field.IsExported(), n.Depth)
+			}
+			// *walkUser -> walkUser -> Friend -> walkUser -> Name
+			if p := n.Parent; p == nil || p.Path != "Friend" || p.Value.Kind().String() != "struct" {
+				t.Errorf("parent %+v", p)
+			}
+			if s, _ := n.Value.Interface(); s != "f" {
+				t.Errorf("value %v", s)
+			}
+			return tinyreflect.WalkContinue, nil
+		},
+	})
+	if err != nil || !seen {
+		t.Fatalf("Walk: %v, seen %v", err, seen)
+	}
+}
+
+func TestWalkSkipAndStop(t *testing.T) {
+	u := walkUser{Name: "a", Tags: []string{"x", "y"}}
+
+	skip := walkTrace(t, tinyreflect.ValueOf(u), func(n *tinyreflect.Node) tinyreflect.WalkAction {
+		if n.Path == "Tags" {
+			return tinyreflect.WalkSkip
+		}
+		return tinyreflect.WalkContinue
+	})
+	for _, e := range skip {
+		if e == "+Tags[0]@2" {
+			t.Errorf("skipped subtree visited: %q", skip)
+		}
+	}
+
+	stop := walkTrace(t, tinyreflect.ValueOf(u), func(n *tinyreflect.Node) tinyreflect.WalkAction {
+		if n.Path == "Tags[0]" {
+			return tinyreflect.WalkStop
+		}
+		return tinyreflect.WalkContinue
+	})
+	want := []string{"+@0", "+Name@1", "-Name", "+Password@1", "-Password", "+Tags@1", "+Tags[0]@2"}
+	if !reflect.DeepEqual(stop, want) {
+		t.Errorf("got  %q\nwant %q", stop, want)
+	}
+
+	boom := errors.New("boom")
+	err := tinyreflect.Walk(tinyreflect.ValueOf(u), tinyreflect.Visitor{
+		Leave: func(n *tinyreflect.Node) error {
+			if n.Path == "Tags[1]" {
+				return boom
+			}
+			return nil
+		},
+	})
+	if err != boom {
+		t.Errorf("got %v, want the visitor error", err)
+	}
+}
+
+func TestWalkReplace(t *testing.T) {
+	u := walkUser{
+		Name:     "a",
+		Password: "secret",
+		Meta:     map[string]any{"token": "t", "n": 1},
+		Friend:   &walkUser{Password: "other"},
+	}
+	err := tinyreflect.Walk(tinyreflect.ValueOf(&u), tinyreflect.Visitor{
+		Enter: func(n *tinyreflect.Node) (tinyreflect.WalkAction, error) {
+			switch {
+			case n.Field.Tag().Get("redact") == "true":
+				return tinyreflect.WalkContinue, n.Replace(tinyreflect.ValueOf("***"))
+			case n.Path == "Meta.token" && n.Value.Kind().String() == "interface":
+				// Replace the map entry, then walk into the new value
+				return tinyreflect.WalkContinue, n.Replace(tinyreflect.ValueOf([]string{"r"}))
+			case n.Path == "Meta.n" && n.Value.Kind().String() == "int":
+				// Replace the contents of an interface
+				return tinyreflect.WalkContinue, n.Replace(tinyreflect.ValueOf(2))
+			}
+			return tinyreflect.WalkContinue, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	want := walkUser{
+		Name:     "a",
+		Password: "***",
+		Meta:     map[string]any{"token": []string{"r"}, "n": 2},
+		Friend:   &walkUser{Password: "***"},
+	}
+	if !reflect.DeepEqual(u, want) {
+		t.Errorf("got  %+v\nwant %+v", u, want)
+	}
+
+	// Unaddressable values cannot be replaced
+	err = tinyreflect.Walk(tinyreflect.ValueOf(u), tinyreflect.Visitor{
+		Enter: func(n *tinyreflect.Node) (tinyreflect.WalkAction, error) {
+			if n.Path == "Name" {
+				return tinyreflect.WalkContinue, n.Replace(tinyreflect.ValueOf("b"))
+			}
+			return tinyreflect.WalkContinue, nil
+		},
+	})
+	var ve *tinyreflect.ValueError
+	if !errors.Is(err, tinyreflect.ErrNotAssignable) || !errors.As(err, &ve) || ve.Path != "Name" {
+		t.Errorf("got %v, want ErrNotAssignable at Name", err)
+	}
+}
+
+func TestWalkReplaceBoxed(t *testing.T) {
+	type inner struct{ V int }
+	type doc struct{ X any }
+	d := doc{X: inner{1}}
+	repl := &inner{5}
+	rv, _ := tinyreflect.ValueOf(repl).Elem()
+	var seen int
+	err := tinyreflect.Walk(tinyreflect.ValueOf(&d), tinyreflect.Visitor{
+		Enter: func(n *tinyreflect.Node) (tinyreflect.WalkAction, error) {
+			switch {
+			case n.Path == "X" && n.Value.Kind().String() == "struct":
+				return tinyreflect.WalkContinue, n.Replace(rv)
+			case n.Path == "X.V":
+				i, _ := n.Value.Int()
+				seen = int(i)
+				return tinyreflect.WalkContinue, n.Replace(tinyreflect.ValueOf(9))
+			}
+			return tinyreflect.WalkContinue, nil
+		},
+	})
+	// The walk goes on into the interface's copy, whose fields are not settable
+	var ve *tinyreflect.ValueError
+	if !errors.Is(err, tinyreflect.ErrNotAssignable) || !errors.As(err, &ve) || ve.Path != "X.V" {
+		t.Errorf("got %v, want ErrNotAssignable at X.V", err)
+	}
+	if seen != 5 || d.X != (inner{5}) || *repl != (inner{5}) {
+		t.Errorf("seen %d, doc %+v, replacement %+v", seen, d, *repl)
+	}
+}
+
+func TestWalkCycle(t *testing.T) {
+	a := &walkUser{Name: "a"}
+	b := &walkUser{Name: "b", Friend: a}
+	a.Friend = b
+
+	var cycles []string
+	err := tinyreflect.Walk(tinyreflect.ValueOf(a), tinyreflect.Visitor{
+		Enter: func(n *tinyreflect.Node) (tinyreflect.WalkAction, error) {
+			if n.Cycle {
+				cycles = append(cycles, n.Path)
+			}
+			return tinyreflect.WalkContinue, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if !reflect.DeepEqual(cycles, []string{"Friend.Friend"}) {
+		t.Errorf("cycles at %q, want [Friend.Friend]", cycles)
+	}
+
+	// A pointer shared by two fields is not a cycle
+	shared := &walkUser{Name: "s"}
+	pair := struct{ A, B *walkUser }{shared, shared}
+	cycles = nil
+	_ = tinyreflect.Walk(tinyreflect.ValueOf(pair), tinyreflect.Visitor{
+		Enter: func(n *tinyreflect.Node) (tinyreflect.WalkAction, error) {
+			if n.Cycle {
+				cycles = append(cycles, n.Path)
+			}
+			return tinyreflect.WalkContinue, nil
+		},
+	})
+	if cycles != nil {
+		t.Errorf("shared pointer reported as cycle at %q", cycles)
+	}
+
+	// A slice holding itself
+	self := []any{nil}
+	self[0] = self
+	if err := tinyreflect.Walk(tinyreflect.ValueOf(self), tinyreflect.Visitor{}); err != nil {
+		t.Errorf("self slice: %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package tinyreflect_test
+
+import (
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+// ModelWithArrays mirrors models that store fixed-size IDs and vectors.
+type ModelWithArrays struct {
+	ID     [16]byte
+	Vector [3]float32
+	Name   string
+}
+
+func TestArrayIsZero(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected bool
+	}{
+		{"zero byte array", [16]byte{}, true},
+		{"non-zero byte array", [16]byte{15: 1}, false},
+		{"zero float array", [3]float32{}, true},
+		{"non-zero float array", [3]float32{0, 0, 1.5}, false},
+		{"empty array", [0]int{}, true},
+		{"zero string array", [2]string{}, true},
+		{"non-zero string array", [2]string{"", "x"}, false},
+		{"nil pointer array", [1]*int{}, true},
+		{"struct with zero arrays", ModelWithArrays{}, true},
+		{"struct with non-zero ID", ModelWithArrays{ID: [16]byte{0: 7}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := tinyreflect.ValueOf(tt.value)
+			if got := v.IsZero(); got != tt.expected {
+				t.Errorf("IsZero() = %v, expected %v for %#v", got, tt.expected, tt.value)
+			}
+		})
+	}
+}
+
+func TestArrayInStruct(t *testing.T) {
+	m := ModelWithArrays{ID: [16]byte{1, 2, 3}, Vector: [3]float32{1, 2, 3}}
+
+	v := tinyreflect.ValueOf(&m)
+	elem, _ := v.Elem()
+	idField, err := elem.Field(0)
+	if err != nil {
+		t.Fatalf("Field(0) failed: %v", err)
+	}
+	if idField.Kind().String() != "array" {
+		t.Fatalf("expected kind array, got %s", idField.Kind())
+	}
+	if n, _ := idField.Len(); n != 16 {
+		t.Errorf("Len() = %d, want 16", n)
+	}
+	if c, _ := idField.Cap(); c != 16 {
+		t.Errorf("Cap() = %d, want 16", c)
+	}
+
+	// Write through SetIndex and Index
+	if err := idField.SetIndex(15, tinyreflect.ValueOf(byte(0xff))); err != nil {
+		t.Fatalf("SetIndex failed: %v", err)
+	}
+	if m.ID[15] != 0xff {
+		t.Errorf("SetIndex did not update the array, got %v", m.ID)
+	}
+
+	vecField, _ := elem.Field(1)
+	second, err := vecField.Index(1)
+	if err != nil {
+		t.Fatalf("Index(1) failed: %v", err)
+	}
+	if !second.CanAddr() {
+		t.Error("element of an addressable array should be addressable")
+	}
+	if err := second.SetFloat(9.5); err != nil {
+		t.Fatalf("SetFloat failed: %v", err)
+	}
+	if m.Vector[1] != 9.5 {
+		t.Errorf("expected Vector[1] = 9.5, got %v", m.Vector[1])
+	}
+
+	// Replace the whole array through Set
+	if err := vecField.Set(tinyreflect.ValueOf([3]float32{4, 5, 6})); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if m.Vector != [3]float32{4, 5, 6} {
+		t.Errorf("Set did not copy the array, got %v", m.Vector)
+	}
+
+	if err := idField.SetIndex(16, tinyreflect.ValueOf(byte(1))); err == nil {
+		t.Error("SetIndex out of range: expected an error")
+	}
+	if err := idField.SetIndex(0, tinyreflect.ValueOf("x")); err == nil {
+		t.Error("SetIndex with wrong element type: expected an error")
+	}
+}
+
+func TestArrayInSlice(t *testing.T) {
+	vectors := [][3]float32{{1, 2, 3}, {4, 5, 6}}
+
+	v := tinyreflect.ValueOf(vectors)
+	second, err := v.Index(1)
+	if err != nil {
+		t.Fatalf("Index(1) failed: %v", err)
+	}
+	last, err := second.Index(2)
+	if err != nil {
+		t.Fatalf("Index(2) failed: %v", err)
+	}
+	if f, _ := last.Float(); f != 6 {
+		t.Errorf("expected 6, got %v", f)
+	}
+	if err := v.SetIndex(0, tinyreflect.ValueOf([3]float32{7, 8, 9})); err != nil {
+		t.Fatalf("SetIndex failed: %v", err)
+	}
+	if vectors[0] != [3]float32{7, 8, 9} {
+		t.Errorf("SetIndex did not update the slice, got %v", vectors[0])
+	}
+
+	ids := []ModelWithArrays{{ID: [16]byte{0: 1}}}
+	first, _ := tinyreflect.ValueOf(ids).Index(0)
+	id, _ := first.Field(0)
+	if id.IsZero() {
+		t.Error("expected non-zero ID inside slice of structs")
+	}
+}
+
+func TestArraySlice(t *testing.T) {
+	arr := [5]int{10, 20, 30, 40, 50}
+	v, _ := tinyreflect.ValueOf(&arr).Elem()
+
+	s, err := v.Slice(1, 4)
+	if err != nil {
+		t.Fatalf("Slice failed: %v", err)
+	}
+	if s.Kind().String() != "slice" {
+		t.Fatalf("expected kind slice, got %s", s.Kind())
+	}
+	if n, _ := s.Len(); n != 3 {
+		t.Errorf("Len() = %d, want 3", n)
+	}
+	if c, _ := s.Cap(); c != 4 {
+		t.Errorf("Cap() = %d, want 4", c)
+	}
+	got, _ := s.Interface()
+	if ints, ok := got.([]int); !ok || len(ints) != 3 || ints[0] != 20 || ints[2] != 40 {
+		t.Errorf("Slice(1, 4) = %v, want [20 30 40]", got)
+	}
+
+	// The slice shares memory with the array
+	first, _ := s.Index(0)
+	first.SetInt(99)
+	if arr[1] != 99 {
+		t.Errorf("expected arr[1] = 99, got %d", arr[1])
+	}
+
+	if _, err := v.Slice(3, 6); err == nil {
+		t.Error("Slice out of range: expected an error")
+	}
+	if _, err := tinyreflect.ValueOf(arr).Slice(0, 1); err == nil {
+		t.Error("Slice of unaddressable array: expected an error")
+	}
+
+	sub, err := tinyreflect.ValueOf([]int{1, 2, 3}).Slice(1, 3)
+	if err != nil {
+		t.Fatalf("Slice of slice failed: %v", err)
+	}
+	if n, _ := sub.Len(); n != 2 {
+		t.Errorf("Len() = %d, want 2", n)
+	}
+
+	str, err := tinyreflect.ValueOf("hello").Slice(1, 3)
+	if err != nil || str.String() != "el" {
+		t.Errorf("Slice of string = %q, %v, want \"el\"", str.String(), err)
+	}
+}
+
+func TestArrayLenThroughPointer(t *testing.T) {
+	arr := [4]int{}
+	v := tinyreflect.ValueOf(&arr)
+	if n, err := v.Len(); err != nil || n != 4 {
+		t.Errorf("Len() on *[4]int = %d, %v, want 4", n, err)
+	}
+	if c, err := v.Cap(); err != nil || c != 4 {
+		t.Errorf("Cap() on *[4]int = %d, %v, want 4", c, err)
+	}
+}
+
+func TestArrayTypeSize(t *testing.T) {
+	typ := tinyreflect.TypeOf([16]byte{})
+	if typ.Size() != 16 {
+		t.Errorf("Size() = %d, want 16", typ.Size())
+	}
+	typ = tinyreflect.TypeOf([3]float32{})
+	if typ.Size() != 12 {
+		t.Errorf("Size() = %d, want 12", typ.Size())
+	}
+	at := typ.ArrayType()
+	if at.SliceOf() == nil || at.SliceOf().Kind().String() != "slice" {
+		t.Error("SliceOf() should return the []float32 type")
+	}
+}
+
+func TestArrayInterfaceZeroAlloc(t *testing.T) {
+	id := [16]byte{0: 42}
+	v := tinyreflect.ValueOf(id)
+
+	var target any
+	v.InterfaceZeroAlloc(&target)
+	got, ok := target.([16]byte)
+	if !ok || got != id {
+		t.Fatalf("InterfaceZeroAlloc = %v, want %v", target, id)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		v.InterfaceZeroAlloc(&target)
+	})
+	if allocs != 0 {
+		t.Errorf("InterfaceZeroAlloc allocated %v times for an array", allocs)
+	}
+}
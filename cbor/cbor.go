@@ -0,0 +1,88 @@
+// Package cbor encodes and decodes CBOR (RFC 8949) through tinyreflect,
+// using the core deterministic encoding of section 4.2.1 so equal values
+// always give the same bytes, as needed for signed payloads:
+//
+//   - integers, lengths and tag numbers use their shortest form
+//   - floats use the shortest of half, single and double precision that
+//     holds the value exactly, and NaN is written as f97e00
+//   - strings, byte strings, arrays and maps have definite lengths
+//   - map keys are sorted by the bytes of their encoding
+//
+// Structs are maps keyed by field name, set by the `cbor` tag. The
+// keyasint option turns the name into an integer key for compact
+// payloads:
+//
+//	type Claims struct {
+//		Issuer  string `cbor:"1,keyasint"`
+//		Subject string `cbor:"2,keyasint,omitempty"`
+//		Nonce   []byte `cbor:"nonce"`
+//		Cache   string `cbor:"-"`
+//	}
+//
+// []byte is a byte string and nil pointers, slices and maps are null.
+// The decoder accepts any well-formed definite-length CBOR, skips tags
+// and stores values in the kinds the Value.Set methods support.
+package cbor
+
+import (
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	. "github.com/cdvelop/tinystring"
+)
+
+// Errors returned by the codec, wrapped in a *tinyreflect.ValueError with
+// the path of the value that failed.
+var (
+	// ErrInvalidData is returned for truncated or malformed input, indefinite lengths and trailing data.
+	ErrInvalidData = codec.ErrInvalidData
+	// ErrMaxDepth is returned for values nested too deeply, usually through
+	// a pointer cycle. All the codec packages share this value.
+	ErrMaxDepth = codec.ErrMaxDepth
+)
+
+// Major types.
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+)
+
+// Marshal returns the deterministic CBOR encoding of v.
+func Marshal(v any) ([]byte, error) {
+	return Append(nil, v)
+}
+
+// Append appends the deterministic CBOR encoding of v to dst and returns
+// the extended buffer.
+func Append(dst []byte, v any) ([]byte, error) {
+	return encodeValue(dst, tinyreflect.ValueOf(v), 0)
+}
+
+// Unmarshal decodes the CBOR data item in data into the value pointed to
+// by v, which must be a non-nil pointer. Nil pointers, maps and slices are
+// allocated as needed and map keys that match no struct field are
+// skipped. A value that does not fit its target returns ErrTypeMismatch
+// or ErrOverflow.
+func Unmarshal(data []byte, v any) error {
+	rv := tinyreflect.ValueOf(v)
+	if rv.Kind() != K.Pointer || rv.IsZero() {
+		return &tinyreflect.ValueError{Method: "cbor.Unmarshal", Kind: rv.Kind(), Err: tinyreflect.ErrInvalidArgument}
+	}
+	elem, err := rv.Elem()
+	if err != nil {
+		return err
+	}
+	d := decoder{data: data}
+	if err := d.value(elem, 0); err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return decodeError(elem.Kind(), ErrInvalidData)
+	}
+	return nil
+}
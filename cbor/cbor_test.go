@@ -0,0 +1,271 @@
+package cbor_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/cbor"
+)
+
+// TestRFC8949Examples checks the examples of RFC 8949 Appendix A that have
+// a single deterministic encoding.
+func TestRFC8949Examples(t *testing.T) {
+	seq := make([]int, 25)
+	for i := range seq {
+		seq[i] = i + 1
+	}
+
+	testCases := []struct {
+		value any
+		hex   string
+	}{
+		{0, "00"},
+		{1, "01"},
+		{10, "0a"},
+		{23, "17"},
+		{24, "1818"},
+		{25, "1819"},
+		{100, "1864"},
+		{1000, "1903e8"},
+		{1000000, "1a000f4240"},
+		{int64(1000000000000), "1b000000e8d4a51000"},
+		{uint64(math.MaxUint64), "1bffffffffffffffff"},
+		{-1, "20"},
+		{-10, "29"},
+		{-100, "3863"},
+		{-1000, "3903e7"},
+		{int64(math.MinInt64), "3b7fffffffffffffff"},
+		{0.0, "f90000"},
+		{math.Copysign(0, -1), "f98000"},
+		{1.0, "f93c00"},
+		{1.1, "fb3ff199999999999a"},
+		{1.5, "f93e00"},
+		{65504.0, "f97bff"},
+		{100000.0, "fa47c35000"},
+		{3.4028234663852886e+38, "fa7f7fffff"},
+		{1.0e+300, "fb7e37e43c8800759c"},
+		{5.960464477539063e-8, "f90001"},
+		{0.00006103515625, "f90400"},
+		{-4.0, "f9c400"},
+		{-4.1, "fbc010666666666666"},
+		{float32(0.1), "fa3dcccccd"},
+		{math.Inf(1), "f97c00"},
+		{math.NaN(), "f97e00"},
+		{math.Inf(-1), "f9fc00"},
+		{false, "f4"},
+		{true, "f5"},
+		{nil, "f6"},
+		{"", "60"},
+		{"a", "6161"},
+		{"IETF", "6449455446"},
+		{"\"\\", "62225c"},
+		{"ü", "62c3bc"},
+		{"水", "63e6b0b4"},
+		{[]byte{}, "40"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{[]int{}, "80"},
+		{[]int{1, 2, 3}, "83010203"},
+		{[]any{1, []int{2, 3}, [2]int{4, 5}}, "8301820203820405"},
+		{seq, "98190102030405060708090a0b0c0d0e0f101112131415161718181819"},
+		{map[int]int{}, "a0"},
+		{map[int]int{3: 4, 1: 2}, "a201020304"},
+		{map[string]any{"b": []int{2, 3}, "a": 1}, "a26161016162820203"},
+		{[]any{"a", map[string]string{"b": "c"}}, "826161a161626163"},
+		{map[string]string{"e": "E", "a": "A", "c": "C", "b": "B", "d": "D"}, "a56161614161626142616361436164614461656145"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.hex, func(t *testing.T) {
+			got, err := cbor.Marshal(tc.value)
+			if err != nil {
+				t.Fatalf("Marshal(%v): %v", tc.value, err)
+			}
+			if hex.EncodeToString(got) != tc.hex {
+				t.Errorf("Marshal(%v) = %x, want %s", tc.value, got, tc.hex)
+			}
+		})
+	}
+}
+
+type Claims struct {
+	Issuer  string   `cbor:"1,keyasint"`
+	Subject string   `cbor:"2,keyasint,omitempty"`
+	Expiry  int64    `cbor:"-3,keyasint"`
+	Nonce   []byte   `cbor:"nonce"`
+	Scopes  []string `cbor:"scopes"`
+	Ratio   float32  `cbor:"ratio"`
+	Extra   *Extra   `cbor:"extra"`
+	Cache   string   `cbor:"-"`
+	local   int
+}
+
+type Extra struct {
+	Level uint8
+	Tags  map[string]int8
+	Hash  [4]byte
+}
+
+func TestStructKeys(t *testing.T) {
+	c := Claims{
+		Issuer: "me",
+		Expiry: -1,
+		Nonce:  []byte{0xaa},
+		Scopes: []string{"r"},
+		Ratio:  0.5,
+		Extra:  &Extra{Level: 200, Tags: map[string]int8{"z": -1, "a": 1}, Hash: [4]byte{1, 2, 3, 4}},
+		Cache:  "x",
+	}
+	got, err := cbor.Marshal(c)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	// Integer keys sort before text keys, shorter text keys first.
+	// The byte array is an array of integers; only []byte is a byte string.
+	want := "a6" +
+		"01" + "626d65" + // 1: "me"
+		"22" + "20" + // -3: -1
+		"656578747261" + "a3" + // "extra": {
+		"644861736884" + "01020304" + //   "Hash": [1,2,3,4]
+		"6454616773" + "a2" + "6161" + "01" + "617a" + "20" + //   "Tags": {"a":1,"z":-1}
+		"654c6576656c" + "18c8" + //   "Level": 200
+		"656e6f6e6365" + "41aa" + // "nonce": h'aa'
+		"65726174696f" + "f93800" + // "ratio": 0.5
+		"6673636f706573" + "816172" // "scopes": ["r"]
+	if hex.EncodeToString(got) != want {
+		t.Errorf("Marshal:\ngot  %x\nwant %s", got, want)
+	}
+
+	var back Claims
+	back.local = 5
+	if err := cbor.Unmarshal(got, &back); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	c.Cache = ""
+	c.local = 5
+	if !reflect.DeepEqual(back, c) {
+		t.Errorf("got  %+v\nwant %+v", back, c)
+	}
+}
+
+func TestUnmarshalValues(t *testing.T) {
+	type Target struct {
+		I8   int8           `cbor:"1,keyasint"`
+		U    uint           `cbor:"2,keyasint"`
+		F32  float32        `cbor:"3,keyasint"`
+		F64  float64        `cbor:"4,keyasint"`
+		S    string         `cbor:"5,keyasint"`
+		B    []byte         `cbor:"6,keyasint"`
+		P    *bool          `cbor:"7,keyasint"`
+		Any  any            `cbor:"8,keyasint"`
+		List []int16        `cbor:"9,keyasint"`
+		M    map[int]string `cbor:"10,keyasint"`
+	}
+
+	testCases := []struct {
+		name string
+		hex  string
+		want Target
+	}{
+		{"Non-shortest integers", "a2" + "01" + "3b0000000000000004" + "1a00000002" + "1b0000000000000007", Target{I8: -5, U: 7}},
+		{"Half float", "a1" + "03" + "f93e00", Target{F32: 1.5}},
+		{"Integer into float", "a1" + "04" + "29", Target{F64: -10}},
+		{"Tagged value", "a1" + "08" + "c11a514b67b0", Target{Any: int64(1363896240)}},
+		{"Pointer", "a1" + "07" + "f5", Target{P: new(bool)}},
+		{"Any map", "a1" + "08" + "a2" + "01" + "61" + "61" + "6162" + "f6", Target{Any: map[any]any{int64(1): "a", "b": nil}}},
+		{"Any list", "a1" + "08" + "83" + "f4" + "fa3fc00000" + "42" + "0102", Target{Any: []any{false, 1.5, []byte{1, 2}}}},
+		{"Unknown keys", "a3" + "18ff" + "82" + "01" + "a0" + "63" + "786174" + "40" + "09" + "820102", Target{List: []int16{1, 2}}},
+		{"Undefined is null", "a1" + "07" + "f7", Target{}},
+		{"Integer map keys", "a1" + "0a" + "a1" + "20" + "61" + "78", Target{M: map[int]string{-1: "x"}}},
+	}
+	*testCases[4].want.P = true
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, _ := hex.DecodeString(tc.hex)
+			var got Target
+			if err := cbor.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got  %#v\nwant %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	type Target struct {
+		I8   int8    `cbor:"1,keyasint"`
+		U16  uint16  `cbor:"2,keyasint"`
+		F32  float32 `cbor:"3,keyasint"`
+		List []bool  `cbor:"list"`
+	}
+
+	testCases := []struct {
+		name    string
+		hex     string
+		wantErr error
+		path    string
+	}{
+		{"Int8 overflow", "a1" + "01" + "1880", tinyreflect.ErrOverflow, "1"},
+		{"Negative uint", "a1" + "02" + "20", tinyreflect.ErrOverflow, "2"},
+		{"Float32 overflow", "a1" + "03" + "fb47efffffffffffff", tinyreflect.ErrOverflow, "3"},
+		{"Text into int", "a1" + "01" + "6161", tinyreflect.ErrTypeMismatch, "1"},
+		{"Nested path", "a1" + "646c697374" + "82" + "f5" + "00", tinyreflect.ErrTypeMismatch, "list[1]"},
+		{"Indefinite array", "9fff", cbor.ErrInvalidData, ""},
+		{"Reserved info", "1c", cbor.ErrInvalidData, ""},
+		{"Truncated", "a1" + "01", cbor.ErrInvalidData, ""},
+		{"Trailing data", "a0" + "00", cbor.ErrInvalidData, ""},
+		{"Huge length", "9b00000000ffffffff", cbor.ErrInvalidData, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, _ := hex.DecodeString(tc.hex)
+			var got Target
+			err := cbor.Unmarshal(data, &got)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got %v, want %v", err, tc.wantErr)
+			}
+			var ve *tinyreflect.ValueError
+			if errors.As(err, &ve) && ve.Path != tc.path {
+				t.Errorf("got path %q, want %q", ve.Path, tc.path)
+			}
+		})
+	}
+
+	if err := cbor.Unmarshal([]byte{0}, 0); !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+		t.Errorf("non-pointer: got %v, want ErrInvalidArgument", err)
+	}
+	type BadKey struct {
+		F int `cbor:"x,keyasint"`
+	}
+	if _, err := cbor.Marshal(BadKey{}); !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+		t.Errorf("bad keyasint: got %v, want ErrInvalidArgument", err)
+	}
+}
+
+// TestHalfFloats round-trips every half-precision value except NaN
+// payloads, which are written as the canonical NaN.
+func TestHalfFloats(t *testing.T) {
+	for h := 0; h <= 0xffff; h++ {
+		if h&0x7c00 == 0x7c00 && h&0x3ff != 0 {
+			continue
+		}
+		data := []byte{0xf9, byte(h >> 8), byte(h)}
+		var f float64
+		if err := cbor.Unmarshal(data, &f); err != nil {
+			t.Fatalf("Unmarshal(%x): %v", data, err)
+		}
+		got, err := cbor.Marshal(f)
+		if err != nil {
+			t.Fatalf("Marshal(%v): %v", f, err)
+		}
+		if string(got) != string(data) {
+			t.Fatalf("half %#04x: Marshal(%v) = %x", h, f, got)
+		}
+	}
+}
@@ -0,0 +1,545 @@
+package cbor
+
+import (
+	"math"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	. "github.com/cdvelop/tinystring"
+)
+
+func decodeError(k Kind, err error) error {
+	return &tinyreflect.ValueError{Method: "cbor.Unmarshal", Kind: k, Err: err}
+}
+
+// Kinds of major type 7 items, as read by decoder.header.
+const (
+	simpleOther = iota
+	simpleBool
+	simpleNil // null and undefined
+	simpleFloat
+)
+
+// header is the initial byte of a data item with its argument.
+type header struct {
+	major  byte
+	arg    uint64  // integer value, length, or tag number
+	simple int     // kind of major type 7 items
+	f      float64 // simpleFloat value; simpleBool as 0 or 1
+}
+
+// decoder consumes CBOR from data[pos:].
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) read(n uint64) ([]byte, bool) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, false
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, true
+}
+
+// header reads the next item's head, skipping any tags before it.
+// Indefinite lengths and reserved values are reported as not ok.
+func (d *decoder) header() (header, bool) {
+	for {
+		b, ok := d.read(1)
+		if !ok {
+			return header{}, false
+		}
+		h := header{major: b[0] >> 5}
+		ai := b[0] & 0x1f
+		switch {
+		case ai < 24:
+			h.arg = uint64(ai)
+		case ai <= 27:
+			arg, ok := d.read(1 << (ai - 24))
+			if !ok {
+				return header{}, false
+			}
+			for _, c := range arg {
+				h.arg = h.arg<<8 | uint64(c)
+			}
+		default:
+			return header{}, false // reserved or indefinite length
+		}
+
+		switch h.major {
+		case majorTag:
+			continue // tags only add meaning to the item that follows
+		case majorBytes, majorText:
+			if h.arg > uint64(len(d.data)-d.pos) {
+				return header{}, false
+			}
+		case majorArray, majorMap:
+			// Every element takes at least one byte
+			need := h.arg
+			if h.major == majorMap {
+				need *= 2
+			}
+			if h.arg > math.MaxInt32 || need > uint64(len(d.data)-d.pos) {
+				return header{}, false
+			}
+		case majorSimple:
+			switch ai {
+			case 20, 21:
+				h.simple, h.f = simpleBool, float64(ai-20)
+			case 22, 23:
+				h.simple = simpleNil
+			case 25:
+				h.simple, h.f = simpleFloat, float16(uint16(h.arg))
+			case 26:
+				h.simple, h.f = simpleFloat, float64(math.Float32frombits(uint32(h.arg)))
+			case 27:
+				h.simple, h.f = simpleFloat, math.Float64frombits(h.arg)
+			}
+		}
+		return h, true
+	}
+}
+
+// float16 returns the value of half-precision bits.
+func float16(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	exp := int(h >> 10 & 0x1f)
+	mant := float64(h & 0x3ff)
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(mant, -24)
+	case 31:
+		if mant != 0 {
+			return math.NaN()
+		}
+		return math.Inf(int(sign))
+	}
+	return sign * math.Ldexp(mant+1024, exp-25)
+}
+
+// skip discards the rest of the item that starts with h.
+func (d *decoder) skip(h header, depth int) bool {
+	if depth > codec.MaxDepth {
+		return false
+	}
+	switch h.major {
+	case majorBytes, majorText:
+		_, ok := d.read(h.arg)
+		return ok
+	case majorArray, majorMap:
+		n := h.arg
+		if h.major == majorMap {
+			n *= 2
+		}
+		for i := uint64(0); i < n; i++ {
+			eh, ok := d.header()
+			if !ok || !d.skip(eh, depth+1) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// value decodes the next item into v.
+func (d *decoder) value(v tinyreflect.Value, depth int) error {
+	h, ok := d.header()
+	if !ok {
+		return decodeError(v.Kind(), ErrInvalidData)
+	}
+	return d.valueFrom(h, v, depth)
+}
+
+// valueFrom decodes the item that starts with h into v.
+func (d *decoder) valueFrom(h header, v tinyreflect.Value, depth int) error {
+	k := v.Kind()
+	if depth > codec.MaxDepth {
+		return decodeError(k, ErrMaxDepth)
+	}
+	if h.major == majorSimple && h.simple == simpleNil {
+		return v.SetZero()
+	}
+
+	switch k {
+	case K.Pointer:
+		if v.IsZero() {
+			if err := v.Set(tinyreflect.NewValue(v.Type().Elem())); err != nil {
+				return err
+			}
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return err
+		}
+		return d.valueFrom(h, elem, depth+1)
+
+	case K.Interface:
+		x, err := d.anyFrom(h, depth)
+		if err != nil {
+			return err
+		}
+		if err := v.Set(tinyreflect.ValueOf(x)); err != nil {
+			return decodeError(k, tinyreflect.ErrTypeMismatch)
+		}
+		return nil
+
+	case K.Bool:
+		if h.major == majorSimple && h.simple == simpleBool {
+			return v.SetBool(h.f == 1)
+		}
+
+	case K.Int, K.Int8, K.Int16, K.Int32, K.Int64:
+		if h.major != majorUint && h.major != majorNegInt {
+			break
+		}
+		if h.arg > math.MaxInt64 {
+			return decodeError(k, tinyreflect.ErrOverflow)
+		}
+		i := int64(h.arg)
+		if h.major == majorNegInt {
+			i = -1 - i
+		}
+		if v.OverflowInt(i) {
+			return decodeError(k, tinyreflect.ErrOverflow)
+		}
+		return v.SetInt(i)
+
+	case K.Uint, K.Uint8, K.Uint16, K.Uint32, K.Uint64, K.Uintptr:
+		switch h.major {
+		case majorUint:
+			if v.OverflowUint(h.arg) {
+				return decodeError(k, tinyreflect.ErrOverflow)
+			}
+			return v.SetUint(h.arg)
+		case majorNegInt:
+			return decodeError(k, tinyreflect.ErrOverflow)
+		}
+
+	case K.Float32, K.Float64:
+		var f float64
+		switch {
+		case h.major == majorSimple && h.simple == simpleFloat:
+			f = h.f
+		case h.major == majorUint:
+			f = float64(h.arg)
+		case h.major == majorNegInt:
+			f = -1 - float64(h.arg)
+		default:
+			return decodeError(k, tinyreflect.ErrTypeMismatch)
+		}
+		if v.OverflowFloat(f) {
+			return decodeError(k, tinyreflect.ErrOverflow)
+		}
+		return v.SetFloat(f)
+
+	case K.String:
+		if h.major != majorText {
+			break
+		}
+		b, _ := d.read(h.arg)
+		return v.SetString(string(b))
+
+	case K.Slice:
+		if h.major == majorBytes && v.Type().Elem().Kind() == K.Uint8 {
+			b, _ := d.read(h.arg)
+			return v.SetBytes(append([]byte{}, b...))
+		}
+		if h.major != majorArray {
+			break
+		}
+		return d.slice(int(h.arg), v, depth)
+
+	case K.Array:
+		if h.major == majorBytes && v.Type().Elem().Kind() == K.Uint8 {
+			return d.byteArray(h.arg, v)
+		}
+		if h.major != majorArray {
+			break
+		}
+		return d.array(int(h.arg), v, depth)
+
+	case K.Map:
+		if h.major != majorMap {
+			break
+		}
+		return d.mapValue(int(h.arg), v, depth)
+
+	case K.Struct:
+		if h.major != majorMap {
+			break
+		}
+		return d.structValue(int(h.arg), v, depth)
+
+	default:
+		return decodeError(k, tinyreflect.ErrUnsupportedKind)
+	}
+	return decodeError(k, tinyreflect.ErrTypeMismatch)
+}
+
+// slice decodes n array elements into the slice v, reusing its backing
+// array when it is large enough.
+func (d *decoder) slice(n int, v tinyreflect.Value, depth int) error {
+	if c, _ := v.Cap(); c >= n && !v.IsZero() {
+		if err := v.SetLen(n); err != nil {
+			return err
+		}
+	} else {
+		s, err := tinyreflect.MakeSlice(v.Type(), n, n)
+		if err != nil {
+			return err
+		}
+		if err := v.Set(s); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < n; i++ {
+		elem, err := v.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := elem.SetZero(); err != nil {
+			return err
+		}
+		if err := d.value(elem, depth+1); err != nil {
+			return codec.WithPath(err, codec.IndexSegment(i))
+		}
+	}
+	return nil
+}
+
+// array decodes n array elements into the Go array v. Extra elements
+// are skipped and missing ones set to zero.
+func (d *decoder) array(n int, v tinyreflect.Value, depth int) error {
+	size, _ := v.Len()
+	for i := 0; i < n; i++ {
+		if i >= size {
+			h, ok := d.header()
+			if !ok || !d.skip(h, depth+1) {
+				return decodeError(K.Array, ErrInvalidData)
+			}
+			continue
+		}
+		elem, err := v.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := d.value(elem, depth+1); err != nil {
+			return codec.WithPath(err, codec.IndexSegment(i))
+		}
+	}
+	for i := n; i < size; i++ {
+		elem, err := v.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := elem.SetZero(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// byteArray copies a byte string into a byte array such as [32]byte,
+// which must have the same length.
+func (d *decoder) byteArray(n uint64, v tinyreflect.Value) error {
+	size, _ := v.Len()
+	if n != uint64(size) {
+		return decodeError(K.Array, tinyreflect.ErrTypeMismatch)
+	}
+	b, _ := d.read(n)
+	for i, c := range b {
+		elem, err := v.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := elem.SetUint(uint64(c)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapValue decodes n entries into the map v, allocating it if nil.
+func (d *decoder) mapValue(n int, v tinyreflect.Value, depth int) error {
+	typ := v.Type()
+	if v.IsZero() {
+		m, err := tinyreflect.MakeMapWithSize(typ, n)
+		if err != nil {
+			return err
+		}
+		if err := v.Set(m); err != nil {
+			return err
+		}
+	}
+	keyType, elemType := typ.Key(), typ.Elem()
+	for i := 0; i < n; i++ {
+		key, err := tinyreflect.NewValue(keyType).Elem()
+		if err != nil {
+			return err
+		}
+		if err := d.value(key, depth+1); err != nil {
+			return codec.WithPath(err, codec.IndexSegment(i))
+		}
+		elem, err := tinyreflect.NewValue(elemType).Elem()
+		if err != nil {
+			return err
+		}
+		if err := d.value(elem, depth+1); err != nil {
+			if key.Kind() == K.String {
+				return codec.WithPath(err, key.String())
+			}
+			return codec.WithPath(err, codec.IndexSegment(i))
+		}
+		if err := v.SetMapIndex(key, elem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structValue decodes n map entries into the fields of v. Text keys match
+// field names and integer keys match keyasint fields; other keys are
+// skipped.
+func (d *decoder) structValue(n int, v tinyreflect.Value, depth int) error {
+	fields, err := cachedFields(v.Type())
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		h, ok := d.header()
+		if !ok {
+			return decodeError(K.Struct, ErrInvalidData)
+		}
+		fi, found := -1, false
+		switch h.major {
+		case majorText:
+			name, _ := d.read(h.arg)
+			fi, found = fields.byName[string(name)]
+		case majorUint, majorNegInt:
+			if h.arg <= math.MaxInt64 {
+				key := int64(h.arg)
+				if h.major == majorNegInt {
+					key = -1 - key
+				}
+				fi, found = fields.byInt[key]
+			}
+		default:
+			if !d.skip(h, depth+1) {
+				return decodeError(K.Struct, ErrInvalidData)
+			}
+		}
+		if !found {
+			vh, ok := d.header()
+			if !ok || !d.skip(vh, depth+1) {
+				return decodeError(K.Struct, ErrInvalidData)
+			}
+			continue
+		}
+		f := &fields.list[fi]
+		fv, err := codec.FieldByIndex(v, f.index)
+		if err != nil {
+			return codec.WithPath(err, f.name)
+		}
+		if err := d.value(fv, depth+1); err != nil {
+			return codec.WithPath(err, f.name)
+		}
+	}
+	return nil
+}
+
+// anyFrom decodes the item that starts with h into nil, bool, int64
+// (uint64 above math.MaxInt64), float64, string, []byte, []any, and
+// map[string]any, or map[any]any when some key is not a text string.
+func (d *decoder) anyFrom(h header, depth int) (any, error) {
+	if depth > codec.MaxDepth {
+		return nil, decodeError(K.Interface, ErrMaxDepth)
+	}
+	switch h.major {
+	case majorUint:
+		if h.arg > math.MaxInt64 {
+			return h.arg, nil
+		}
+		return int64(h.arg), nil
+	case majorNegInt:
+		if h.arg > math.MaxInt64 {
+			return nil, decodeError(K.Int64, tinyreflect.ErrOverflow)
+		}
+		return -1 - int64(h.arg), nil
+	case majorBytes:
+		b, _ := d.read(h.arg)
+		return append([]byte{}, b...), nil
+	case majorText:
+		b, _ := d.read(h.arg)
+		return string(b), nil
+
+	case majorArray:
+		list := make([]any, h.arg)
+		for i := range list {
+			x, err := d.anyNext(depth + 1)
+			if err != nil {
+				return nil, codec.WithPath(err, codec.IndexSegment(i))
+			}
+			list[i] = x
+		}
+		return list, nil
+
+	case majorMap:
+		keys := make([]any, h.arg)
+		vals := make([]any, h.arg)
+		allText := true
+		for i := range keys {
+			k, err := d.anyNext(depth + 1)
+			if err != nil {
+				return nil, codec.WithPath(err, codec.IndexSegment(i))
+			}
+			switch k.(type) {
+			case []byte, []any, map[string]any, map[any]any:
+				return nil, decodeError(K.Map, tinyreflect.ErrUnsupportedKind) // not comparable
+			case string:
+			default:
+				allText = false
+			}
+			if vals[i], err = d.anyNext(depth + 1); err != nil {
+				return nil, codec.WithPath(err, codec.IndexSegment(i))
+			}
+			keys[i] = k
+		}
+		if allText {
+			m := make(map[string]any, len(keys))
+			for i, k := range keys {
+				m[k.(string)] = vals[i]
+			}
+			return m, nil
+		}
+		m := make(map[any]any, len(keys))
+		for i, k := range keys {
+			m[k] = vals[i]
+		}
+		return m, nil
+
+	case majorSimple:
+		switch h.simple {
+		case simpleNil:
+			return nil, nil
+		case simpleBool:
+			return h.f == 1, nil
+		case simpleFloat:
+			return h.f, nil
+		}
+	}
+	return nil, decodeError(K.Interface, ErrInvalidData)
+}
+
+// anyNext decodes the next item with anyFrom.
+func (d *decoder) anyNext(depth int) (any, error) {
+	h, ok := d.header()
+	if !ok {
+		return nil, decodeError(K.Interface, ErrInvalidData)
+	}
+	return d.anyFrom(h, depth)
+}
@@ -0,0 +1,252 @@
+package cbor
+
+import (
+	"math"
+	"slices"
+	"unsafe"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	. "github.com/cdvelop/tinystring"
+)
+
+func encodeError(k Kind, err error) error {
+	return &tinyreflect.ValueError{Method: "cbor.Marshal", Kind: k, Err: err}
+}
+
+// Simple values and float heads of major type 7.
+const (
+	simpleFalse = 0xf4
+	simpleTrue  = 0xf5
+	simpleNull  = 0xf6
+	headFloat16 = 0xf9
+	headFloat32 = 0xfa
+	headFloat64 = 0xfb
+)
+
+// appendHead appends the initial byte for major and the argument n in
+// its shortest form.
+func appendHead(dst []byte, major byte, n uint64) []byte {
+	m := major << 5
+	switch {
+	case n < 24:
+		return append(dst, m|byte(n))
+	case n <= math.MaxUint8:
+		return append(dst, m|24, byte(n))
+	case n <= math.MaxUint16:
+		return append(dst, m|25, byte(n>>8), byte(n))
+	case n <= math.MaxUint32:
+		return append(dst, m|26, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(dst, m|27, byte(n>>56), byte(n>>48), byte(n>>40), byte(n>>32),
+		byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+// appendInt appends i as an unsigned or negative integer.
+func appendInt(dst []byte, i int64) []byte {
+	if i < 0 {
+		return appendHead(dst, majorNegInt, uint64(-1-i))
+	}
+	return appendHead(dst, majorUint, uint64(i))
+}
+
+// appendFloat appends f in the shortest precision that holds it exactly.
+func appendFloat(dst []byte, f float64) []byte {
+	if f != f {
+		return append(dst, headFloat16, 0x7e, 0x00) // canonical NaN
+	}
+	f32 := float32(f)
+	if float64(f32) != f {
+		b := math.Float64bits(f)
+		return append(dst, headFloat64, byte(b>>56), byte(b>>48), byte(b>>40), byte(b>>32),
+			byte(b>>24), byte(b>>16), byte(b>>8), byte(b))
+	}
+	b := math.Float32bits(f32)
+	if h, ok := float16Bits(b); ok {
+		return append(dst, headFloat16, byte(h>>8), byte(h))
+	}
+	return append(dst, headFloat32, byte(b>>24), byte(b>>16), byte(b>>8), byte(b))
+}
+
+// float16Bits converts the bits of a float32 to half precision, reporting
+// false if the value cannot be represented exactly.
+func float16Bits(b uint32) (uint16, bool) {
+	sign := uint16(b>>16) & 0x8000
+	exp := int(b>>23&0xff) - 127
+	mant := b & 0x7fffff
+	switch {
+	case exp == -127 && mant == 0:
+		return sign, true // ±0
+	case exp == 128:
+		return sign | 0x7c00, mant == 0 // ±Inf; NaN is handled by the caller
+	case exp >= -14 && exp <= 15:
+		// Normal half: the 13 low mantissa bits must be zero
+		if mant&0x1fff != 0 {
+			return 0, false
+		}
+		return sign | uint16(exp+15)<<10 | uint16(mant>>13), true
+	case exp >= -24 && exp < -14:
+		// Subnormal half: m * 2^-24 with the implicit bit made explicit
+		m := mant | 1<<23
+		shift := uint(-exp - 1)
+		if m&(1<<shift-1) != 0 {
+			return 0, false
+		}
+		return sign | uint16(m>>shift), true
+	}
+	return 0, false
+}
+
+// encodeValue appends the encoding of v to dst. The zero Value is null.
+func encodeValue(dst []byte, v tinyreflect.Value, depth int) ([]byte, error) {
+	if depth > codec.MaxDepth {
+		return dst, encodeError(v.Kind(), ErrMaxDepth)
+	}
+
+	switch k := v.Kind(); k {
+	case K.Invalid:
+		return append(dst, simpleNull), nil
+
+	case K.Bool:
+		if b, _ := v.Bool(); b {
+			return append(dst, simpleTrue), nil
+		}
+		return append(dst, simpleFalse), nil
+
+	case K.Int, K.Int8, K.Int16, K.Int32, K.Int64:
+		i, _ := v.Int()
+		return appendInt(dst, i), nil
+
+	case K.Uint, K.Uint8, K.Uint16, K.Uint32, K.Uint64, K.Uintptr:
+		u, _ := v.Uint()
+		return appendHead(dst, majorUint, u), nil
+
+	case K.Float32, K.Float64:
+		f, _ := v.Float()
+		return appendFloat(dst, f), nil
+
+	case K.String:
+		s := v.String()
+		return append(appendHead(dst, majorText, uint64(len(s))), s...), nil
+
+	case K.Slice:
+		if v.IsZero() {
+			return append(dst, simpleNull), nil
+		}
+		n, _ := v.Len()
+		if v.Type().Elem().Kind() == K.Uint8 {
+			p, _ := v.UnsafePointer()
+			return append(appendHead(dst, majorBytes, uint64(n)), unsafe.Slice((*byte)(p), n)...), nil
+		}
+		return encodeElems(dst, v, n, depth)
+
+	case K.Array:
+		n, _ := v.Len()
+		return encodeElems(dst, v, n, depth)
+
+	case K.Map:
+		if v.IsZero() {
+			return append(dst, simpleNull), nil
+		}
+		return encodeMap(dst, v, depth)
+
+	case K.Struct:
+		return encodeStruct(dst, v, depth)
+
+	case K.Pointer, K.Interface:
+		if v.IsZero() {
+			return append(dst, simpleNull), nil
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return dst, err
+		}
+		return encodeValue(dst, elem, depth+1)
+	}
+	return dst, encodeError(v.Kind(), tinyreflect.ErrUnsupportedKind)
+}
+
+// encodeElems appends the first n elements of the slice or array v as an array.
+func encodeElems(dst []byte, v tinyreflect.Value, n, depth int) ([]byte, error) {
+	dst = appendHead(dst, majorArray, uint64(n))
+	for i := 0; i < n; i++ {
+		elem, err := v.Index(i)
+		if err != nil {
+			return dst, err
+		}
+		if dst, err = encodeValue(dst, elem, depth+1); err != nil {
+			return dst, codec.WithPath(err, codec.IndexSegment(i))
+		}
+	}
+	return dst, nil
+}
+
+// encodeStruct appends v as a map, with fields already sorted by key.
+func encodeStruct(dst []byte, v tinyreflect.Value, depth int) ([]byte, error) {
+	fields, err := cachedFields(v.Type())
+	if err != nil {
+		return dst, err
+	}
+	values := make([]tinyreflect.Value, len(fields.list))
+	n := 0
+	for i := range fields.list {
+		f := &fields.list[i]
+		fv, err := codec.FieldByIndex(v, f.index)
+		if err != nil {
+			return dst, codec.WithPath(err, f.name)
+		}
+		if f.omitEmpty && codec.IsEmpty(fv) {
+			continue
+		}
+		values[i] = fv
+		n++
+	}
+
+	dst = appendHead(dst, majorMap, uint64(n))
+	for i := range fields.list {
+		if values[i].Kind() == K.Invalid {
+			continue
+		}
+		f := &fields.list[i]
+		dst = append(dst, f.key...)
+		if dst, err = encodeValue(dst, values[i], depth+1); err != nil {
+			return dst, codec.WithPath(err, f.name)
+		}
+	}
+	return dst, nil
+}
+
+// mapEntry is a map entry with its key already encoded.
+type mapEntry struct {
+	key []byte
+	val tinyreflect.Value
+}
+
+// encodeMap appends the entries of v sorted by their encoded keys.
+func encodeMap(dst []byte, v tinyreflect.Value, depth int) ([]byte, error) {
+	n, _ := v.Len()
+	iter, err := v.MapRange()
+	if err != nil {
+		return dst, err
+	}
+	entries := make([]mapEntry, 0, n)
+	for iter.Next() {
+		key, err := encodeValue(nil, iter.Key(), depth+1)
+		if err != nil {
+			return dst, err
+		}
+		entries = append(entries, mapEntry{key, iter.Value()})
+	}
+	slices.SortFunc(entries, func(a, b mapEntry) int {
+		return slices.Compare(a.key, b.key)
+	})
+
+	dst = appendHead(dst, majorMap, uint64(len(entries)))
+	for i, e := range entries {
+		dst = append(dst, e.key...)
+		if dst, err = encodeValue(dst, e.val, depth+1); err != nil {
+			return dst, codec.WithPath(err, codec.IndexSegment(i))
+		}
+	}
+	return dst, nil
+}
@@ -0,0 +1,78 @@
+package cbor
+
+import (
+	"slices"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/cache"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	"github.com/cdvelop/tinyreflect/internal/num"
+	. "github.com/cdvelop/tinystring"
+)
+
+// field describes how one struct field is encoded.
+type field struct {
+	name      string // field name or tag name, for error paths
+	key       []byte // encoded map key: a text string, or an integer with keyasint
+	index     []int  // field index, through embedded structs when promoted
+	omitEmpty bool
+}
+
+// structFields lists the encoded fields of a struct type sorted by
+// encoded key, the order they are written in.
+type structFields struct {
+	list   []field
+	byName map[string]int // text keys
+	byInt  map[int64]int  // keyasint keys
+}
+
+// fieldCache keeps the parsed fields of each struct type.
+var fieldCache cache.Cache[*tinyreflect.Type, *structFields]
+
+// cachedFields returns the encoded fields of the struct type t.
+func cachedFields(t *tinyreflect.Type) (*structFields, error) {
+	if sf, ok := fieldCache.Load(t); ok {
+		return sf, nil
+	}
+
+	all, err := codec.Fields(t, "cbor")
+	if err != nil {
+		return nil, err
+	}
+	// A field hides promoted fields with the same key further down
+	var kept []field
+	for _, cf := range all {
+		f := field{name: cf.Name, index: cf.Index, omitEmpty: cf.Opts.Has("omitempty")}
+		if cf.Opts.Has("keyasint") {
+			n, st := num.ParseInt(cf.Name, 64)
+			if st != num.OK {
+				return nil, &tinyreflect.ValueError{Method: "cbor.Marshal", Kind: K.Struct, Path: cf.Name, Err: tinyreflect.ErrInvalidArgument}
+			}
+			f.key = appendInt(nil, n)
+		} else {
+			f.key = append(appendHead(nil, majorText, uint64(len(cf.Name))), cf.Name...)
+		}
+		i := slices.IndexFunc(kept, func(g field) bool { return string(g.key) == string(f.key) })
+		switch {
+		case i < 0:
+			kept = append(kept, f)
+		case len(f.index) < len(kept[i].index):
+			kept[i] = f
+		}
+	}
+	slices.SortStableFunc(kept, func(a, b field) int {
+		return slices.Compare(a.key, b.key)
+	})
+
+	sf := &structFields{list: kept, byName: map[string]int{}, byInt: map[int64]int{}}
+	for i, f := range kept {
+		if f.key[0]>>5 == majorText {
+			sf.byName[f.name] = i
+		} else {
+			n, _ := num.ParseInt(f.name, 64)
+			sf.byInt[n] = i
+		}
+	}
+	fieldCache.Store(t, sf)
+	return sf, nil
+}
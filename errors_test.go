@@ -0,0 +1,89 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinystring"
+)
+
+func TestErrorsIs(t *testing.T) {
+	type Pair struct {
+		A int
+		B string
+	}
+	p := Pair{}
+	elem, _ := tinyreflect.ValueOf(&p).Elem()
+
+	testCases := []struct {
+		name   string
+		call   func() error
+		want   error
+		method string
+	}{
+		{"Index out of range", func() error { _, err := tinyreflect.ValueOf([]int{1}).Index(5); return err }, tinyreflect.ErrOutOfRange, "Index"},
+		{"Field out of range", func() error { _, err := elem.Field(9); return err }, tinyreflect.ErrOutOfRange, "Field"},
+		{"Field on non-struct", func() error { _, err := tinyreflect.ValueOf(1).Field(0); return err }, tinyreflect.ErrNotStruct, "Field"},
+		{"NumField on non-struct", func() error { _, err := tinyreflect.TypeOf(1).NumField(); return err }, tinyreflect.ErrNotStruct, "Type.NumField"},
+		{"SetInt on copy", func() error { return tinyreflect.ValueOf(1).SetInt(2) }, tinyreflect.ErrNotAssignable, "SetInt"},
+		{"SetInt on string", func() error { f, _ := elem.Field(1); return f.SetInt(2) }, tinyreflect.ErrUnsupportedKind, "SetInt"},
+		{"Set type mismatch", func() error { f, _ := elem.Field(0); return f.Set(tinyreflect.ValueOf("x")) }, tinyreflect.ErrTypeMismatch, "Set"},
+		{"Addr of copy", func() error { _, err := tinyreflect.ValueOf(1).Addr(); return err }, tinyreflect.ErrNotAddressable, "Addr"},
+		{"Len of int", func() error { _, err := tinyreflect.ValueOf(1).Len(); return err }, tinyreflect.ErrUnsupportedKind, "Len"},
+		{"Interface of nil", func() error { _, err := tinyreflect.ValueOf(nil).Interface(); return err }, tinyreflect.ErrNilValue, "Interface"},
+		{"MakeSlice bad len", func() error {
+			_, err := tinyreflect.MakeSlice(tinyreflect.TypeOf([]int{}), 3, 1)
+			return err
+		}, tinyreflect.ErrInvalidArgument, "MakeSlice"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.call()
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("errors.Is(%v, %v) = false", err, tc.want)
+			}
+			var ve *tinyreflect.ValueError
+			if !errors.As(err, &ve) {
+				t.Fatalf("errors.As(%v, *ValueError) = false", err)
+			}
+			if ve.Method != tc.method {
+				t.Errorf("Method = %q, want %q", ve.Method, tc.method)
+			}
+		})
+	}
+}
+
+func TestValueErrorKind(t *testing.T) {
+	_, err := tinyreflect.ValueOf("text").Int()
+	var ve *tinyreflect.ValueError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected *ValueError, got %T", err)
+	}
+	if ve.Kind.String() != "string" {
+		t.Errorf("Kind = %s, want string", ve.Kind)
+	}
+	if errors.Is(err, tinyreflect.ErrOutOfRange) {
+		t.Error("unsupported kind error should not match ErrOutOfRange")
+	}
+}
+
+func TestValueErrorMessage(t *testing.T) {
+	err := &tinyreflect.ValueError{
+		Method: "SetInt",
+		Kind:   tinystring.K.String,
+		Path:   "Items[2].Price",
+		Err:    tinyreflect.ErrUnsupportedKind,
+	}
+	if got, want := err.Error(), "Items[2].Price: reflect SetInt string: Type Not Supported"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	// Sentinels are translated when printed
+	defer tinystring.OutLang(tinystring.EN)
+	tinystring.OutLang(tinystring.ES)
+	if got := tinyreflect.ErrOutOfRange.Error(); got == "Index Out Of Range" || got == "" {
+		t.Errorf("expected a Spanish message, got %q", got)
+	}
+}
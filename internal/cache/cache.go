@@ -0,0 +1,31 @@
+// Package cache holds the concurrency-safe map in which tinyreflect and
+// the codec packages keep what they derive once per type, such as parsed
+// struct tags.
+package cache
+
+import "sync"
+
+// Cache maps keys to values derived from them. The zero value is empty
+// and ready to use.
+type Cache[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  map[K]V
+}
+
+// Load returns the value stored for k.
+func (c *Cache[K, V]) Load(k K) (V, bool) {
+	c.mu.RLock()
+	v, ok := c.m[k]
+	c.mu.RUnlock()
+	return v, ok
+}
+
+// Store sets the value for k.
+func (c *Cache[K, V]) Store(k K, v V) {
+	c.mu.Lock()
+	if c.m == nil {
+		c.m = make(map[K]V)
+	}
+	c.m[k] = v
+	c.mu.Unlock()
+}
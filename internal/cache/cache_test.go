@@ -0,0 +1,14 @@
+package cache
+
+import "testing"
+
+func TestCache(t *testing.T) {
+	var c Cache[string, int]
+	if _, ok := c.Load("a"); ok {
+		t.Fatal("empty cache hit")
+	}
+	c.Store("a", 7)
+	if v, ok := c.Load("a"); !ok || v != 7 {
+		t.Errorf("Load = %v, %v", v, ok)
+	}
+}
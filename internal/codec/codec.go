@@ -0,0 +1,45 @@
+// Package codec holds what the codec packages, such as json, share: the
+// nesting limit, the common errors, error paths and the walk over struct
+// fields.
+package codec
+
+import (
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/vpath"
+	. "github.com/cdvelop/tinystring"
+)
+
+// MaxDepth bounds the nesting of values so pointer cycles fail instead of
+// overflowing the stack.
+const MaxDepth = 1000
+
+var (
+	// ErrInvalidData is returned for truncated or malformed binary input.
+	ErrInvalidData = tinyreflect.NewError(D.Invalid, D.Binary, D.Content)
+	// ErrMaxDepth is returned when nesting exceeds MaxDepth, usually because of a pointer cycle.
+	ErrMaxDepth = tinyreflect.NewError(D.Maximum, D.Exceeds)
+)
+
+// WithPath prefixes the path of a *tinyreflect.ValueError with seg, which
+// is a key or an "[i]" index. Other errors are returned unchanged.
+//
+// An ErrMaxDepth error would gather a segment for every level it went
+// through, a thousand for a pointer cycle, so its path keeps only the
+// outermost segment: where the deep value starts.
+func WithPath(err error, seg string) error {
+	ve, ok := err.(*tinyreflect.ValueError)
+	if !ok {
+		return err
+	}
+	if ve.Err == ErrMaxDepth {
+		ve.Path = seg
+		return ve
+	}
+	ve.Path = vpath.Prefix(seg, ve.Path)
+	return ve
+}
+
+// IndexSegment returns the path segment "[i]".
+func IndexSegment(i int) string {
+	return vpath.Index(i)
+}
@@ -0,0 +1,73 @@
+package codec
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+type Base struct {
+	ID   int
+	Kind string `x:"kind,omitempty"`
+}
+
+type named struct{ Inner int }
+
+func TestFields(t *testing.T) {
+	type doc struct {
+		Base
+		Name   string `x:"name"`
+		Skip   int    `x:"-"`
+		hidden int
+		named  `x:"n"`
+		Other  named
+	}
+	list, err := Fields(tinyreflect.TypeOf(doc{}), "x")
+	if err != nil {
+		t.Fatalf("Fields: %v", err)
+	}
+	var got []string
+	for _, f := range list {
+		got = append(got, f.Name)
+	}
+	if want := []string{"ID", "kind", "name", "Other"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("names %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(list[1].Index, []int{0, 1}) || !list[1].Tagged || !list[1].Opts.Has("omitempty") {
+		t.Errorf("promoted field %+v", list[1])
+	}
+	if list[0].Tagged || list[3].Type != tinyreflect.TypeOf(named{}) {
+		t.Errorf("fields %+v", list)
+	}
+
+	v := tinyreflect.ValueOf(doc{Base: Base{Kind: "k"}})
+	fv, err := FieldByIndex(v, list[1].Index)
+	if err != nil || fv.String() != "k" {
+		t.Errorf("FieldByIndex = %v, %v", fv.String(), err)
+	}
+}
+
+func TestWithPath(t *testing.T) {
+	err := error(&tinyreflect.ValueError{Method: "m", Err: ErrInvalidData})
+	err = WithPath(err, "b")
+	err = WithPath(err, IndexSegment(2))
+	err = WithPath(err, "a")
+	var ve *tinyreflect.ValueError
+	if !errors.As(err, &ve) || ve.Path != "a[2].b" || !errors.Is(err, ErrInvalidData) {
+		t.Errorf("got %v", err)
+	}
+	if plain := errors.New("x"); WithPath(plain, "a") != plain {
+		t.Error("non-ValueError changed")
+	}
+
+	// A depth error keeps only the outermost segment
+	err = error(&tinyreflect.ValueError{Method: "m", Err: ErrMaxDepth})
+	for i := 0; i < MaxDepth; i++ {
+		err = WithPath(err, "Next")
+	}
+	if err = WithPath(err, "Head"); !errors.As(err, &ve) || ve.Path != "Head" {
+		t.Errorf("depth error path %q, want %q", ve.Path, "Head")
+	}
+}
@@ -0,0 +1,97 @@
+package codec
+
+import (
+	"github.com/cdvelop/tinyreflect"
+	. "github.com/cdvelop/tinystring"
+)
+
+// Field is an exported struct field as a codec sees it.
+type Field struct {
+	Name   string // tag name, or the Go name when the tag has none
+	Tagged bool   // Name comes from the tag
+	Index  []int  // field index, through embedded structs when promoted
+	Type   *tinyreflect.Type
+	Opts   tinyreflect.TagOptions
+}
+
+// Fields collects the exported fields of the struct type t, named by the
+// tagKey tag, in field order. Fields tagged "-" are left out, and the
+// fields of embedded structs without a tag name are promoted in their
+// place; choosing between fields of the same name is up to the caller.
+func Fields(t *tinyreflect.Type, tagKey string) ([]Field, error) {
+	return fields(t, tagKey, nil, nil)
+}
+
+// fields collects the fields of t. index is the path from the outer
+// struct to t and visited guards against embedding cycles.
+func fields(t *tinyreflect.Type, tagKey string, index []int, visited []*tinyreflect.Type) ([]Field, error) {
+	for _, v := range visited {
+		if v == t {
+			return nil, nil
+		}
+	}
+	visited = append(visited, t)
+
+	n, err := t.NumField()
+	if err != nil {
+		return nil, err
+	}
+	var list []Field
+	for i := 0; i < n; i++ {
+		sf, err := t.Field(i)
+		if err != nil {
+			return nil, err
+		}
+		tag := sf.Tag().Get(tagKey)
+		if tag == "-" {
+			continue
+		}
+		name, opts := tinyreflect.ParseTag(tag)
+
+		idx := make([]int, len(index)+1)
+		copy(idx, index)
+		idx[len(index)] = i
+
+		if sf.Embedded() && name == "" && sf.Typ.Kind() == K.Struct {
+			inner, err := fields(sf.Typ, tagKey, idx, visited)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, inner...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		f := Field{Name: name, Tagged: name != "", Index: idx, Type: sf.Typ, Opts: opts}
+		if f.Name == "" {
+			if f.Name, err = t.NameByIndex(i); err != nil {
+				return nil, err
+			}
+		}
+		list = append(list, f)
+	}
+	return list, nil
+}
+
+// FieldByIndex returns the field of v at the index path.
+func FieldByIndex(v tinyreflect.Value, index []int) (tinyreflect.Value, error) {
+	for _, i := range index {
+		var err error
+		if v, err = v.Field(i); err != nil {
+			return tinyreflect.Value{}, err
+		}
+	}
+	return v, nil
+}
+
+// IsEmpty reports whether v is empty for the binary codecs' ",omitempty":
+// zero values and empty strings, slices and maps.
+func IsEmpty(v tinyreflect.Value) bool {
+	switch v.Kind() {
+	case K.Map, K.Slice, K.String:
+		n, _ := v.Len()
+		return n == 0
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,249 @@
+// Package num formats and parses numbers without the strconv package.
+//
+// Floats are converted through an exact multiprecision decimal, adapted
+// from the slow path of the Go standard library, so formatting produces
+// the shortest representation that round-trips and parsing is correctly
+// rounded on both the stdlib and the TinyGo builds.
+package num
+
+// decimal is a multiprecision decimal number.
+type decimal struct {
+	d     [800]byte // digits, big-endian representation
+	nd    int       // number of digits used
+	dp    int       // decimal point
+	neg   bool      // negative flag
+	trunc bool      // discarded nonzero digits beyond d[:nd]
+}
+
+// uintSize is the size of uint in bits.
+const uintSize = 32 << (^uint(0) >> 63)
+
+// maxShift is the maximum shift that cannot overflow a uint in the
+// shift loops below.
+const maxShift = uintSize - 4
+
+// assign sets a to v.
+func (a *decimal) assign(v uint64) {
+	var buf [24]byte
+
+	// Write reversed decimal in buf.
+	n := 0
+	for v > 0 {
+		v1 := v / 10
+		v -= 10 * v1
+		buf[n] = byte(v + '0')
+		n++
+		v = v1
+	}
+
+	// Reverse again to produce forward decimal in a.d.
+	a.nd = 0
+	for n--; n >= 0; n-- {
+		a.d[a.nd] = buf[n]
+		a.nd++
+	}
+	a.dp = a.nd
+	trim(a)
+}
+
+// trim removes trailing zeros; they do not change the value.
+func trim(a *decimal) {
+	for a.nd > 0 && a.d[a.nd-1] == '0' {
+		a.nd--
+	}
+	if a.nd == 0 {
+		a.dp = 0
+	}
+}
+
+// rightShift divides a by 2^k. k must be at most maxShift.
+func rightShift(a *decimal, k uint) {
+	r := 0 // read pointer
+	w := 0 // write pointer
+
+	// Pick up enough leading digits to cover first shift.
+	var n uint
+	for ; n>>k == 0; r++ {
+		if r >= a.nd {
+			if n == 0 {
+				// a == 0; shouldn't get here, but handle anyway.
+				a.nd = 0
+				return
+			}
+			for n>>k == 0 {
+				n = n * 10
+				r++
+			}
+			break
+		}
+		c := uint(a.d[r])
+		n = n*10 + c - '0'
+	}
+	a.dp -= r - 1
+
+	var mask uint = (1 << k) - 1
+
+	// Pick up a digit, put down a digit.
+	for ; r < a.nd; r++ {
+		c := uint(a.d[r])
+		dig := n >> k
+		n &= mask
+		a.d[w] = byte(dig + '0')
+		w++
+		n = n*10 + c - '0'
+	}
+
+	// Put down extra digits.
+	for n > 0 {
+		dig := n >> k
+		n &= mask
+		if w < len(a.d) {
+			a.d[w] = byte(dig + '0')
+			w++
+		} else if dig > 0 {
+			a.trunc = true
+		}
+		n = n * 10
+	}
+
+	a.nd = w
+	trim(a)
+}
+
+// leftShift multiplies a by 2^k. k must be at most maxShift.
+// The product is built right to left in a scratch buffer, which has
+// room for the at most 20 digits a shift can add.
+func leftShift(a *decimal, k uint) {
+	var buf [len(a.d) + 20]byte
+	w := len(buf)
+
+	var n uint
+	for r := a.nd - 1; r >= 0; r-- {
+		n += (uint(a.d[r]) - '0') << k
+		quo := n / 10
+		w--
+		buf[w] = byte(n - 10*quo + '0')
+		n = quo
+	}
+	for n > 0 {
+		quo := n / 10
+		w--
+		buf[w] = byte(n - 10*quo + '0')
+		n = quo
+	}
+
+	nd := len(buf) - w
+	a.dp += nd - a.nd
+	if nd > len(a.d) {
+		for _, c := range buf[w+len(a.d):] {
+			if c != '0' {
+				a.trunc = true
+			}
+		}
+		nd = len(a.d)
+	}
+	copy(a.d[:], buf[w:w+nd])
+	a.nd = nd
+	trim(a)
+}
+
+// shift multiplies a by 2^k (or divides it when k is negative).
+func (a *decimal) shift(k int) {
+	switch {
+	case a.nd == 0:
+		// nothing to do: a == 0
+	case k > 0:
+		for k > maxShift {
+			leftShift(a, maxShift)
+			k -= maxShift
+		}
+		leftShift(a, uint(k))
+	case k < 0:
+		for k < -maxShift {
+			rightShift(a, maxShift)
+			k += maxShift
+		}
+		rightShift(a, uint(-k))
+	}
+}
+
+// shouldRoundUp reports whether a should be rounded up when it is
+// chopped to nd digits.
+func shouldRoundUp(a *decimal, nd int) bool {
+	if nd < 0 || nd >= a.nd {
+		return false
+	}
+	if a.d[nd] == '5' && nd+1 == a.nd { // exactly halfway - round to even
+		// if we truncated, a little higher than what's recorded - always round up
+		if a.trunc {
+			return true
+		}
+		return nd > 0 && (a.d[nd-1]-'0')%2 == 1
+	}
+	// not halfway - digit tells all
+	return a.d[nd] >= '5'
+}
+
+// round rounds a to nd digits (or fewer).
+func (a *decimal) round(nd int) {
+	if nd < 0 || nd >= a.nd {
+		return
+	}
+	if shouldRoundUp(a, nd) {
+		a.roundUp(nd)
+	} else {
+		a.roundDown(nd)
+	}
+}
+
+// roundDown rounds a down to nd digits (or fewer).
+func (a *decimal) roundDown(nd int) {
+	if nd < 0 || nd >= a.nd {
+		return
+	}
+	a.nd = nd
+	trim(a)
+}
+
+// roundUp rounds a up to nd digits (or fewer).
+func (a *decimal) roundUp(nd int) {
+	if nd < 0 || nd >= a.nd {
+		return
+	}
+
+	// round up
+	for i := nd - 1; i >= 0; i-- {
+		c := a.d[i]
+		if c < '9' { // can stop after this digit
+			a.d[i]++
+			a.nd = i + 1
+			return
+		}
+	}
+
+	// Number is all 9s.
+	// Change to single 1 with adjusted decimal point.
+	a.d[0] = '1'
+	a.nd = 1
+	a.dp++
+}
+
+// roundedInteger extracts the integer part of a, rounded appropriately.
+// No guarantees about overflow.
+func (a *decimal) roundedInteger() uint64 {
+	if a.dp > 20 {
+		return 0xFFFFFFFFFFFFFFFF
+	}
+	var i int
+	n := uint64(0)
+	for i = 0; i < a.dp && i < a.nd; i++ {
+		n = n*10 + uint64(a.d[i]-'0')
+	}
+	for ; i < a.dp; i++ {
+		n *= 10
+	}
+	if shouldRoundUp(a, a.dp) {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,144 @@
+package num
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// jsonFloat formats f the way encoding/json does.
+func jsonFloat(f float64, bits int) string {
+	abs := math.Abs(f)
+	fmt := byte('f')
+	if abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			fmt = 'e'
+		}
+	}
+	b := strconv.AppendFloat(nil, f, fmt, -1, bits)
+	if fmt == 'e' {
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return string(b)
+}
+
+func TestAppendFloat(t *testing.T) {
+	samples := []float64{0, 1, -1, 0.1, 0.2, 0.3, 123.45, 1e-6, 1e-7, 1e20, 1e21, 1.5e300,
+		5e-324, math.MaxFloat64, math.SmallestNonzeroFloat64, 1.0 / 3, 2.5e-8, 100, 123456789.125}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		samples = append(samples, math.Float64frombits(r.Uint64()))
+	}
+	for _, f := range samples {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			continue
+		}
+		if got, want := string(AppendFloat(nil, f, 64)), jsonFloat(f, 64); got != want {
+			t.Fatalf("AppendFloat(%v, 64) = %q, want %q", f, got, want)
+		}
+		f32 := float64(float32(f))
+		if math.IsInf(f32, 0) {
+			continue
+		}
+		if got, want := string(AppendFloat(nil, f32, 32)), jsonFloat(f32, 32); got != want {
+			t.Fatalf("AppendFloat(%v, 32) = %q, want %q", f32, got, want)
+		}
+	}
+}
+
+func TestParseFloat(t *testing.T) {
+	inputs := []string{"0", "-0", "1", "0.1", "1e10", "1E-7", "123.456e+2", ".5", "5.", "1e400", "-1e400",
+		"1e-400", "4.9406564584124654e-324", "179769313486231570000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"}
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 20000; i++ {
+		f := math.Float64frombits(r.Uint64())
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			continue
+		}
+		inputs = append(inputs, strconv.FormatFloat(f, 'g', -1, 64), strconv.FormatFloat(f, 'e', 20, 64))
+	}
+	for _, s := range inputs {
+		for _, bits := range []int{32, 64} {
+			want, err := strconv.ParseFloat(s, bits)
+			got, st := ParseFloat(s, bits)
+			if math.Float64bits(got) != math.Float64bits(want) {
+				t.Fatalf("ParseFloat(%q, %d) = %v, want %v", s, bits, got, want)
+			}
+			if (st == Range) != (err != nil) {
+				t.Fatalf("ParseFloat(%q, %d) status = %d, strconv error %v", s, bits, st, err)
+			}
+		}
+	}
+	for _, bad := range []string{"", "-", "e5", "1e", "1.2.3", "0x10", "1_000", "abc", "1e+"} {
+		if _, st := ParseFloat(bad, 64); st != Syntax {
+			t.Errorf("ParseFloat(%q) status = %d, want Syntax", bad, st)
+		}
+	}
+}
+
+func TestParseInt(t *testing.T) {
+	testCases := []struct {
+		in   string
+		bits int
+		want int64
+		st   Status
+	}{
+		{"0", 64, 0, OK},
+		{"-128", 8, -128, OK},
+		{"127", 8, 127, OK},
+		{"128", 8, 127, Range},
+		{"-129", 8, -128, Range},
+		{"+42", 32, 42, OK},
+		{"9223372036854775807", 64, math.MaxInt64, OK},
+		{"-9223372036854775808", 64, math.MinInt64, OK},
+		{"9223372036854775808", 64, math.MaxInt64, Range},
+		{"99999999999999999999999", 64, math.MaxInt64, Range},
+		{"", 64, 0, Syntax},
+		{"1.5", 64, 0, Syntax},
+		{"--1", 64, 0, Syntax},
+	}
+	for _, tc := range testCases {
+		got, st := ParseInt(tc.in, tc.bits)
+		if got != tc.want || st != tc.st {
+			t.Errorf("ParseInt(%q, %d) = %d, %d; want %d, %d", tc.in, tc.bits, got, st, tc.want, tc.st)
+		}
+	}
+}
+
+func TestParseUint(t *testing.T) {
+	testCases := []struct {
+		in   string
+		bits int
+		want uint64
+		st   Status
+	}{
+		{"255", 8, 255, OK},
+		{"256", 8, 255, Range},
+		{"18446744073709551615", 64, math.MaxUint64, OK},
+		{"18446744073709551616", 64, math.MaxUint64, Range},
+		{"-1", 64, 0, Syntax},
+		{"", 64, 0, Syntax},
+	}
+	for _, tc := range testCases {
+		got, st := ParseUint(tc.in, tc.bits)
+		if got != tc.want || st != tc.st {
+			t.Errorf("ParseUint(%q, %d) = %d, %d; want %d, %d", tc.in, tc.bits, got, st, tc.want, tc.st)
+		}
+	}
+}
+
+func TestAppendInt(t *testing.T) {
+	for _, i := range []int64{0, 1, -1, 42, -42, math.MaxInt64, math.MinInt64} {
+		if got, want := string(AppendInt(nil, i)), strconv.FormatInt(i, 10); got != want {
+			t.Errorf("AppendInt(%d) = %q, want %q", i, got, want)
+		}
+	}
+	if got := string(AppendUint(nil, math.MaxUint64)); got != "18446744073709551615" {
+		t.Errorf("AppendUint(MaxUint64) = %q", got)
+	}
+}
@@ -0,0 +1,286 @@
+package num
+
+import "math"
+
+// Status reports the outcome of a parse.
+type Status uint8
+
+const (
+	OK     Status = iota // the input was parsed
+	Syntax               // the input is not a number of the requested form
+	Range                // the number does not fit in the requested size
+)
+
+// ParseInt parses a base-10 signed integer that must fit in bitSize bits
+// (8, 16, 32 or 64; 0 means the size of int). An optional sign is accepted.
+func ParseInt(s string, bitSize int) (int64, Status) {
+	if bitSize == 0 {
+		bitSize = uintSize
+	}
+	neg := false
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	u, st := ParseUint(s, 64)
+	if st == Syntax {
+		return 0, Syntax
+	}
+	cutoff := uint64(1) << uint(bitSize-1)
+	if st == Range || !neg && u >= cutoff || neg && u > cutoff {
+		if neg {
+			return -int64(cutoff-1) - 1, Range
+		}
+		return int64(cutoff - 1), Range
+	}
+	if neg {
+		return -int64(u), OK
+	}
+	return int64(u), OK
+}
+
+// ParseUint parses a base-10 unsigned integer that must fit in bitSize bits
+// (8, 16, 32 or 64; 0 means the size of uint). A leading '+' is accepted.
+func ParseUint(s string, bitSize int) (uint64, Status) {
+	if bitSize == 0 {
+		bitSize = uintSize
+	}
+	if s != "" && s[0] == '+' {
+		s = s[1:]
+	}
+	if s == "" {
+		return 0, Syntax
+	}
+	maxVal := uint64(1)<<uint(bitSize) - 1
+	var n uint64
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < '0' || c > '9' {
+			return 0, Syntax
+		}
+		if n > math.MaxUint64/10 {
+			return maxVal, Range
+		}
+		n *= 10
+		n1 := n + uint64(c-'0')
+		if n1 < n || n1 > maxVal {
+			return maxVal, Range
+		}
+		n = n1
+	}
+	return n, OK
+}
+
+// ParseFloat parses a decimal number such as "-12.5e3" into the nearest
+// float of bitSize bits (32 or 64). The result is correctly rounded.
+// When the magnitude is too large it returns ±Inf with Range.
+func ParseFloat(s string, bitSize int) (float64, Status) {
+	var d decimal
+	if !d.set(s) {
+		return 0, Syntax
+	}
+	if bitSize == 32 {
+		b, ovf := d.floatBits(&float32info)
+		f := float64(math.Float32frombits(uint32(b)))
+		if ovf {
+			return f, Range
+		}
+		return f, OK
+	}
+	b, ovf := d.floatBits(&float64info)
+	f := math.Float64frombits(b)
+	if ovf {
+		return f, Range
+	}
+	return f, OK
+}
+
+// set reads s into b and reports whether s is a well-formed number.
+func (b *decimal) set(s string) (ok bool) {
+	i := 0
+	b.neg = false
+	b.trunc = false
+
+	// optional sign
+	if i >= len(s) {
+		return
+	}
+	switch s[i] {
+	case '+':
+		i++
+	case '-':
+		b.neg = true
+		i++
+	}
+
+	// digits
+	sawdot := false
+	sawdigits := false
+	for ; i < len(s); i++ {
+		switch {
+		case s[i] == '.':
+			if sawdot {
+				return
+			}
+			sawdot = true
+			b.dp = b.nd
+			continue
+
+		case '0' <= s[i] && s[i] <= '9':
+			sawdigits = true
+			if s[i] == '0' && b.nd == 0 { // ignore leading zeros
+				b.dp--
+				continue
+			}
+			if b.nd < len(b.d) {
+				b.d[b.nd] = s[i]
+				b.nd++
+			} else if s[i] != '0' {
+				b.trunc = true
+			}
+			continue
+		}
+		break
+	}
+	if !sawdigits {
+		return
+	}
+	if !sawdot {
+		b.dp = b.nd
+	}
+
+	// optional exponent moves decimal point.
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i >= len(s) {
+			return
+		}
+		esign := 1
+		if s[i] == '+' {
+			i++
+		} else if s[i] == '-' {
+			i++
+			esign = -1
+		}
+		if i >= len(s) || s[i] < '0' || s[i] > '9' {
+			return
+		}
+		e := 0
+		for ; i < len(s) && '0' <= s[i] && s[i] <= '9'; i++ {
+			if e < 10000 {
+				e = e*10 + int(s[i]) - '0'
+			}
+		}
+		b.dp += e * esign
+	}
+
+	if i != len(s) {
+		return
+	}
+
+	ok = true
+	return
+}
+
+// decimal power of ten to binary power of two.
+var powtab = []int{1, 3, 6, 9, 13, 16, 19, 23, 26}
+
+// floatBits converts d to the bits of a float described by flt and
+// reports whether the value overflowed.
+func (d *decimal) floatBits(flt *floatInfo) (b uint64, overflow bool) {
+	var exp int
+	var mant uint64
+
+	// Zero is always a special case.
+	if d.nd == 0 {
+		mant = 0
+		exp = flt.bias
+		goto out
+	}
+
+	// Obvious overflow/underflow.
+	// These bounds are for 64-bit floats.
+	// Will have to change if we want to support 80-bit floats in the future.
+	if d.dp > 310 {
+		goto overflow
+	}
+	if d.dp < -330 {
+		// zero
+		mant = 0
+		exp = flt.bias
+		goto out
+	}
+
+	// Scale by powers of two until in range [0.5, 1.0)
+	exp = 0
+	for d.dp > 0 {
+		var n int
+		if d.dp >= len(powtab) {
+			n = 27
+		} else {
+			n = powtab[d.dp]
+		}
+		d.shift(-n)
+		exp += n
+	}
+	for d.dp < 0 || d.dp == 0 && d.d[0] < '5' {
+		var n int
+		if -d.dp >= len(powtab) {
+			n = 27
+		} else {
+			n = powtab[-d.dp]
+		}
+		d.shift(n)
+		exp -= n
+	}
+
+	// Our range is [0.5,1) but floating point range is [1,2).
+	exp--
+
+	// Minimum representable exponent is flt.bias+1.
+	// If the exponent is smaller, move it up and
+	// adjust d accordingly.
+	if exp < flt.bias+1 {
+		n := flt.bias + 1 - exp
+		d.shift(-n)
+		exp += n
+	}
+
+	if exp-flt.bias >= 1<<flt.expbits-1 {
+		goto overflow
+	}
+
+	// Extract 1+flt.mantbits bits.
+	d.shift(int(1 + flt.mantbits))
+	mant = d.roundedInteger()
+
+	// Rounding might have added a bit; shift down.
+	if mant == 2<<flt.mantbits {
+		mant >>= 1
+		exp++
+		if exp-flt.bias >= 1<<flt.expbits-1 {
+			goto overflow
+		}
+	}
+
+	// Denormalized?
+	if mant&(1<<flt.mantbits) == 0 {
+		exp = flt.bias
+	}
+	goto out
+
+overflow:
+	// ±Inf
+	mant = 0
+	exp = 1<<flt.expbits - 1 + flt.bias
+	overflow = true
+
+out:
+	// Assemble bits.
+	bits := mant & (uint64(1)<<flt.mantbits - 1)
+	bits |= uint64((exp-flt.bias)&(1<<flt.expbits-1)) << flt.mantbits
+	if d.neg {
+		bits |= 1 << flt.mantbits << flt.expbits
+	}
+	return bits, overflow
+}
@@ -0,0 +1,21 @@
+// Package vpath builds the dotted value paths, such as "Items[2].Name",
+// that errors carry to name the value that failed.
+package vpath
+
+import "github.com/cdvelop/tinyreflect/internal/num"
+
+// Prefix returns path with seg, a name or an "[i]" index, in front of it.
+func Prefix(seg, path string) string {
+	switch {
+	case path == "":
+		return seg
+	case path[0] == '[':
+		return seg + path
+	}
+	return seg + "." + path
+}
+
+// Index returns the path segment "[i]".
+func Index(i int) string {
+	return "[" + string(num.AppendInt(nil, int64(i))) + "]"
+}
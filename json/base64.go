@@ -0,0 +1,87 @@
+package json
+
+import . "github.com/cdvelop/tinystring"
+
+// base64Alphabet is the standard base64 alphabet used by encoding/json for []byte.
+const base64Alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// writeBase64 writes src in padded standard base64.
+func writeBase64(c *Conv, src []byte) {
+	var quad [4]byte
+	for len(src) >= 3 {
+		v := uint(src[0])<<16 | uint(src[1])<<8 | uint(src[2])
+		quad[0] = base64Alphabet[v>>18&0x3F]
+		quad[1] = base64Alphabet[v>>12&0x3F]
+		quad[2] = base64Alphabet[v>>6&0x3F]
+		quad[3] = base64Alphabet[v&0x3F]
+		c.WrString(BuffOut, bytesString(quad[:]))
+		src = src[3:]
+	}
+	switch len(src) {
+	case 2:
+		v := uint(src[0])<<16 | uint(src[1])<<8
+		quad[0] = base64Alphabet[v>>18&0x3F]
+		quad[1] = base64Alphabet[v>>12&0x3F]
+		quad[2] = base64Alphabet[v>>6&0x3F]
+		quad[3] = '='
+		c.WrString(BuffOut, bytesString(quad[:]))
+	case 1:
+		v := uint(src[0]) << 16
+		quad[0] = base64Alphabet[v>>18&0x3F]
+		quad[1] = base64Alphabet[v>>12&0x3F]
+		quad[2] = '='
+		quad[3] = '='
+		c.WrString(BuffOut, bytesString(quad[:]))
+	}
+}
+
+// decodeBase64 decodes padded standard base64 like encoding/json, which
+// ignores '\r' and '\n'. It reports false for malformed input.
+func decodeBase64(src []byte) ([]byte, bool) {
+	out := make([]byte, 0, len(src)*3/4)
+	var quad [4]byte
+	n, pad := 0, 0
+	done := false // a padded quantum ends the input
+	for _, c := range src {
+		var v byte
+		switch {
+		case c == '\r' || c == '\n':
+			continue
+		case done:
+			return nil, false
+		case c == '=':
+			if n < 2 {
+				return nil, false
+			}
+			pad++
+		case pad > 0:
+			return nil, false
+		case c >= 'A' && c <= 'Z':
+			v = c - 'A'
+		case c >= 'a' && c <= 'z':
+			v = c - 'a' + 26
+		case c >= '0' && c <= '9':
+			v = c - '0' + 52
+		case c == '+':
+			v = 62
+		case c == '/':
+			v = 63
+		default:
+			return nil, false
+		}
+		quad[n] = v
+		n++
+		if n < 4 {
+			continue
+		}
+		x := uint(quad[0])<<18 | uint(quad[1])<<12 | uint(quad[2])<<6 | uint(quad[3])
+		out = append(out, byte(x>>16), byte(x>>8), byte(x))
+		out = out[:len(out)-pad]
+		n = 0
+		done = pad > 0
+	}
+	if n != 0 {
+		return nil, false
+	}
+	return out, true
+}
@@ -0,0 +1,479 @@
+package json
+
+import (
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	"github.com/cdvelop/tinyreflect/internal/num"
+	. "github.com/cdvelop/tinystring"
+)
+
+// ErrUnknownField is returned in strict mode for an object key that matches
+// no struct field, wrapped in a *tinyreflect.ValueError with its path.
+var ErrUnknownField = tinyreflect.NewError(D.Field, D.Unknown)
+
+// Decoder decodes JSON into Go values. The zero Decoder skips unknown
+// object keys like encoding/json.
+type Decoder struct {
+	// Strict rejects object keys that match no struct field with
+	// ErrUnknownField instead of skipping them.
+	Strict bool
+}
+
+// Unmarshal parses the JSON in data and stores the result in the value
+// pointed to by v, skipping object keys that match no struct field.
+func Unmarshal(data []byte, v any) error {
+	return Decoder{}.Decode(data, v)
+}
+
+// Decode parses the JSON in data and stores the result in the value
+// pointed to by v, which must be a non-nil pointer.
+//
+// Nil pointers are allocated as needed, slices grow to fit the array,
+// object keys match struct fields by json tag or, failing that, by name
+// ignoring case, and numbers are checked against the width of the target.
+// Malformed input returns a *SyntaxError; a value that does not fit its
+// target returns a *tinyreflect.ValueError with the JSON path of the value,
+// wrapping ErrTypeMismatch, ErrOverflow or ErrUnknownField.
+func (d Decoder) Decode(data []byte, v any) error {
+	rv := tinyreflect.ValueOf(v)
+	if rv.Kind() != K.Pointer || rv.IsZero() {
+		return &tinyreflect.ValueError{Method: "json.Decode", Kind: rv.Kind(), Err: tinyreflect.ErrInvalidArgument}
+	}
+	elem, err := rv.Elem()
+	if err != nil {
+		return err
+	}
+	return d.DecodeValue(data, elem)
+}
+
+// DecodeValue is like Decode but stores the result in v, which must be
+// settable, e.g. a struct field reached through a pointer.
+func (d Decoder) DecodeValue(data []byte, v tinyreflect.Value) error {
+	ds := decodeState{t: NewTokenizer(data), strict: d.Strict}
+	if err := ds.value(v, false); err != nil {
+		return err
+	}
+	tok, err := ds.t.Next()
+	if err != nil {
+		return err
+	}
+	if tok.Kind != EOF {
+		return ds.t.syntaxError()
+	}
+	return nil
+}
+
+// decodeState walks the tokens of one document.
+type decodeState struct {
+	t      *Tokenizer
+	strict bool
+}
+
+func decodeError(k Kind, err error) error {
+	return &tinyreflect.ValueError{Method: "json.Decode", Kind: k, Err: err}
+}
+
+// value decodes the next value into v; quoted unwraps a ",string" field.
+func (d *decodeState) value(v tinyreflect.Value, quoted bool) error {
+	tok, err := d.t.Next()
+	if err != nil {
+		return err
+	}
+	return d.valueFrom(tok, v, quoted)
+}
+
+// valueFrom decodes the value that starts with tok into v.
+func (d *decodeState) valueFrom(tok Token, v tinyreflect.Value, quoted bool) error {
+	switch v.Kind() {
+	case K.Pointer:
+		if tok.Kind == Null {
+			return v.SetZero()
+		}
+		if nil_, _ := v.IsNil(); nil_ {
+			if err := v.Set(tinyreflect.NewValue(v.Type().Elem())); err != nil {
+				return err
+			}
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return err
+		}
+		return d.valueFrom(tok, elem, quoted)
+
+	case K.Interface:
+		if tok.Kind == Null {
+			return v.SetZero()
+		}
+		x, err := d.anyFrom(tok)
+		if err != nil {
+			return err
+		}
+		if err := v.Set(tinyreflect.ValueOf(x)); err != nil {
+			return decodeError(K.Interface, tinyreflect.ErrTypeMismatch)
+		}
+		return nil
+	}
+
+	if quoted && tok.Kind != Null {
+		return d.quoted(tok, v)
+	}
+
+	switch tok.Kind {
+	case Null:
+		switch v.Kind() {
+		case K.Map, K.Slice:
+			return v.SetZero()
+		}
+		return nil // like encoding/json, null leaves other values unchanged
+
+	case True, False:
+		if v.Kind() != K.Bool {
+			return decodeError(v.Kind(), tinyreflect.ErrTypeMismatch)
+		}
+		return v.SetBool(tok.Kind == True)
+
+	case Number:
+		return d.number(tok.Value, v)
+
+	case String:
+		switch {
+		case v.Kind() == K.String:
+			return v.SetString(string(tok.Value))
+		case v.Kind() == K.Slice && v.Type().Elem().Kind() == K.Uint8:
+			b, ok := decodeBase64(tok.Value)
+			if !ok {
+				return decodeError(K.Slice, ErrSyntax)
+			}
+			return v.SetBytes(b)
+		}
+		return decodeError(v.Kind(), tinyreflect.ErrTypeMismatch)
+
+	case BeginObject:
+		switch v.Kind() {
+		case K.Struct:
+			return d.object(v)
+		case K.Map:
+			return d.mapObject(v)
+		}
+		return decodeError(v.Kind(), tinyreflect.ErrTypeMismatch)
+
+	case BeginArray:
+		switch v.Kind() {
+		case K.Slice:
+			return d.slice(v)
+		case K.Array:
+			return d.array(v)
+		}
+		return decodeError(v.Kind(), tinyreflect.ErrTypeMismatch)
+	}
+	return d.t.syntaxError()
+}
+
+// quoted decodes a ",string" field: the JSON string holds the literal
+// that is decoded into v.
+func (d *decodeState) quoted(tok Token, v tinyreflect.Value) error {
+	if tok.Kind != String {
+		return decodeError(v.Kind(), tinyreflect.ErrTypeMismatch)
+	}
+	inner := decodeState{t: NewTokenizer(tok.Value)}
+	lit, err := inner.t.Next()
+	if err != nil {
+		return decodeError(v.Kind(), ErrSyntax)
+	}
+	switch lit.Kind {
+	case String:
+		if v.Kind() != K.String {
+			return decodeError(v.Kind(), tinyreflect.ErrTypeMismatch)
+		}
+	case Number, True, False, Null:
+	default:
+		return decodeError(v.Kind(), tinyreflect.ErrTypeMismatch)
+	}
+	if err := inner.valueFrom(lit, v, false); err != nil {
+		return err
+	}
+	if end, err := inner.t.Next(); err != nil || end.Kind != EOF {
+		return decodeError(v.Kind(), ErrSyntax)
+	}
+	return nil
+}
+
+// number stores a JSON number in an integer or float of any width.
+func (d *decodeState) number(raw []byte, v tinyreflect.Value) error {
+	s := bytesString(raw)
+	switch k := v.Kind(); k {
+	case K.Int, K.Int8, K.Int16, K.Int32, K.Int64:
+		n, st := num.ParseInt(s, 64)
+		switch {
+		case st == num.Syntax:
+			return decodeError(k, tinyreflect.ErrTypeMismatch) // fraction or exponent
+		case st == num.Range || v.OverflowInt(n):
+			return decodeError(k, tinyreflect.ErrOverflow)
+		}
+		return v.SetInt(n)
+
+	case K.Uint, K.Uint8, K.Uint16, K.Uint32, K.Uint64, K.Uintptr:
+		n, st := num.ParseUint(s, 64)
+		switch {
+		case st == num.Syntax:
+			return decodeError(k, tinyreflect.ErrTypeMismatch) // sign, fraction or exponent
+		case st == num.Range || v.OverflowUint(n):
+			return decodeError(k, tinyreflect.ErrOverflow)
+		}
+		return v.SetUint(n)
+
+	case K.Float32, K.Float64:
+		f, st := num.ParseFloat(s, 64)
+		if st != num.OK || v.OverflowFloat(f) {
+			return decodeError(k, tinyreflect.ErrOverflow)
+		}
+		return v.SetFloat(f)
+	}
+	return decodeError(v.Kind(), tinyreflect.ErrTypeMismatch)
+}
+
+// object decodes the members of an object into the struct v.
+func (d *decodeState) object(v tinyreflect.Value) error {
+	fields, err := cachedFields(v.Type())
+	if err != nil {
+		return err
+	}
+	for {
+		tok, err := d.t.Next()
+		if err != nil {
+			return err
+		}
+		if tok.Kind == EndObject {
+			return nil
+		}
+
+		f := fields.lookup(tok.Value)
+		if f == nil {
+			if d.strict {
+				return &tinyreflect.ValueError{Method: "json.Decode", Kind: K.Struct, Path: string(tok.Value), Err: ErrUnknownField}
+			}
+			if err := d.t.Skip(); err != nil {
+				return err
+			}
+			continue
+		}
+		fv, err := codec.FieldByIndex(v, f.index)
+		if err != nil {
+			return codec.WithPath(err, f.name)
+		}
+		if err := d.value(fv, f.quoted); err != nil {
+			return codec.WithPath(err, f.name)
+		}
+	}
+}
+
+// mapObject decodes the members of an object into the map v, allocating
+// it if nil. Keys must be strings or integers.
+func (d *decodeState) mapObject(v tinyreflect.Value) error {
+	typ := v.Type()
+	if nil_, _ := v.IsNil(); nil_ {
+		m, err := tinyreflect.MakeMap(typ)
+		if err != nil {
+			return err
+		}
+		if err := v.Set(m); err != nil {
+			return err
+		}
+	}
+	keyType, elemType := typ.Key(), typ.Elem()
+	for {
+		tok, err := d.t.Next()
+		if err != nil {
+			return err
+		}
+		if tok.Kind == EndObject {
+			return nil
+		}
+
+		name := string(tok.Value)
+		key, err := tinyreflect.NewValue(keyType).Elem()
+		if err != nil {
+			return err
+		}
+		switch keyType.Kind() {
+		case K.String:
+			err = key.SetString(name)
+		case K.Int, K.Int8, K.Int16, K.Int32, K.Int64,
+			K.Uint, K.Uint8, K.Uint16, K.Uint32, K.Uint64, K.Uintptr:
+			err = d.number(tok.Value, key)
+		default:
+			err = decodeError(keyType.Kind(), tinyreflect.ErrUnsupportedKind)
+		}
+		if err != nil {
+			return codec.WithPath(err, name)
+		}
+
+		elem, err := tinyreflect.NewValue(elemType).Elem()
+		if err != nil {
+			return err
+		}
+		if err := d.value(elem, false); err != nil {
+			return codec.WithPath(err, name)
+		}
+		if err := v.SetMapIndex(key, elem); err != nil {
+			return codec.WithPath(err, name)
+		}
+	}
+}
+
+// slice decodes the elements of an array into the slice v, reusing its
+// backing array while it fits and growing it with MakeSlice otherwise.
+// An empty array yields an empty, non-nil slice.
+func (d *decodeState) slice(v tinyreflect.Value) error {
+	typ := v.Type()
+	if err := v.SetLen(0); err != nil {
+		return err
+	}
+	i := 0
+	for {
+		tok, err := d.t.Next()
+		if err != nil {
+			return err
+		}
+		if tok.Kind == EndArray {
+			break
+		}
+
+		if c, _ := v.Cap(); i >= c {
+			grown, err := tinyreflect.MakeSlice(typ, i, max(4, c*2))
+			if err != nil {
+				return err
+			}
+			if _, err := tinyreflect.Copy(grown, v); err != nil {
+				return err
+			}
+			if err := v.Set(grown); err != nil {
+				return err
+			}
+		}
+		if err := v.SetLen(i + 1); err != nil {
+			return err
+		}
+		elem, err := v.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := elem.SetZero(); err != nil {
+			return err
+		}
+		if err := d.valueFrom(tok, elem, false); err != nil {
+			return codec.WithPath(err, codec.IndexSegment(i))
+		}
+		i++
+	}
+
+	if nil_, _ := v.IsNil(); nil_ {
+		empty, err := tinyreflect.MakeSlice(typ, 0, 0)
+		if err != nil {
+			return err
+		}
+		return v.Set(empty)
+	}
+	return nil
+}
+
+// array decodes the elements of an array into the Go array v. Extra JSON
+// elements are skipped and missing ones are set to zero.
+func (d *decodeState) array(v tinyreflect.Value) error {
+	n, err := v.Len()
+	if err != nil {
+		return err
+	}
+	i := 0
+	for ; ; i++ {
+		tok, err := d.t.Next()
+		if err != nil {
+			return err
+		}
+		if tok.Kind == EndArray {
+			break
+		}
+		if i >= n {
+			if err := d.t.skipFrom(tok); err != nil {
+				return err
+			}
+			continue
+		}
+		elem, err := v.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := d.valueFrom(tok, elem, false); err != nil {
+			return codec.WithPath(err, codec.IndexSegment(i))
+		}
+	}
+	for ; i < n; i++ {
+		elem, err := v.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := elem.SetZero(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// anyFrom decodes the value that starts with tok into the types
+// encoding/json uses for interface values: map[string]any, []any,
+// float64, string, bool and nil.
+func (d *decodeState) anyFrom(tok Token) (any, error) {
+	switch tok.Kind {
+	case Null:
+		return nil, nil
+	case True, False:
+		return tok.Kind == True, nil
+	case String:
+		return string(tok.Value), nil
+	case Number:
+		f, st := num.ParseFloat(bytesString(tok.Value), 64)
+		if st != num.OK {
+			return nil, decodeError(K.Float64, tinyreflect.ErrOverflow)
+		}
+		return f, nil
+
+	case BeginObject:
+		m := make(map[string]any)
+		for {
+			tok, err := d.t.Next()
+			if err != nil {
+				return nil, err
+			}
+			if tok.Kind == EndObject {
+				return m, nil
+			}
+			name := string(tok.Value)
+			if tok, err = d.t.Next(); err != nil {
+				return nil, err
+			}
+			x, err := d.anyFrom(tok)
+			if err != nil {
+				return nil, codec.WithPath(err, name)
+			}
+			m[name] = x
+		}
+
+	case BeginArray:
+		list := []any{}
+		for {
+			tok, err := d.t.Next()
+			if err != nil {
+				return nil, err
+			}
+			if tok.Kind == EndArray {
+				return list, nil
+			}
+			x, err := d.anyFrom(tok)
+			if err != nil {
+				return nil, codec.WithPath(err, codec.IndexSegment(len(list)))
+			}
+			list = append(list, x)
+		}
+	}
+	return nil, d.t.syntaxError()
+}
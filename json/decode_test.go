@@ -0,0 +1,269 @@
+package json_test
+
+import (
+	stdjson "encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/json"
+)
+
+func TestUnmarshalMatchesEncodingJSON(t *testing.T) {
+	order := Order{
+		Base:     Base{ID: 42, Created: 1700000000},
+		Customer: "Ana <ana@example.com> & co\n\"quoted\"\t é\U0001F600",
+		Items:    []Item{{"A-1", 2, 9.99}, {"B-2", 1, 0}},
+		Tags:     map[string]int{"zeta": 1, "alpha": 2},
+		Ship:     &Address{Street: "Main 1", City: "Lima"},
+		Notes:    []string{},
+		Meta:     map[int]string{10: "ten", -1: "minus"},
+		Extra:    map[string]any{"n": 1.5, "list": []any{true, nil, "x"}},
+		Raw:      []byte("hello, world"),
+		Grid:     [2][2]int8{{1, -2}, {3, 4}},
+		Paid:     true,
+		Ratio:    0.1,
+		Total:    1e21,
+		Limits:   map[string]uint16{"b": 2, "a": 65535},
+	}
+	data, err := stdjson.Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got, want Order
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if err := stdjson.Unmarshal(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestUnmarshalValues(t *testing.T) {
+	type Inner struct {
+		N *int `json:"n"`
+	}
+	type Target struct {
+		Name    string
+		Count   int16            `json:"count"`
+		Ptr     **Inner          `json:"ptr"`
+		List    []int            `json:"list"`
+		Arr     [3]uint          `json:"arr"`
+		Any     any              `json:"any"`
+		Bytes   []byte           `json:"bytes"`
+		Keys    map[uint8]bool   `json:"keys"`
+		Nested  map[string][]any `json:"nested"`
+		Escaped string           `json:"escaped"`
+	}
+
+	testCases := []struct {
+		name  string
+		input string
+	}{
+		{"Case-insensitive name", `{"NAME":"x","name":"y"}`},
+		{"Pointer chain", `{"ptr":{"n":7}}`},
+		{"Null pointer", `{"ptr":null}`},
+		{"Slice growth", `{"list":[1,2,3,4,5,6,7,8,9,10]}`},
+		{"Empty slice", `{"list":[]}`},
+		{"Null slice", `{"list":null}`},
+		{"Short array", `{"arr":[1]}`},
+		{"Long array", `{"arr":[1,2,3,4,[5],{"a":6}]}`},
+		{"Any", `{"any":{"a":[1,"two",true,null,{"b":-0.5e3}]}}`},
+		{"Base64", `{"bytes":"AAEC/w=="}`},
+		{"Integer keys", `{"keys":{"1":true,"255":false}}`},
+		{"Nested", `{"nested":{"x":[1,{"y":[]}]}}`},
+		{"Escapes", `{"escaped":"a\"b\\c\/d\b\f\n\r\té😀\ud800"}`},
+		{"Unknown fields", `{"missing":{"deep":[1,2,{"x":null}]},"count":3}`},
+		{"Whitespace", " \n\t{ \"count\" : 1 , \"list\" : [ 1 , 2 ] } \r\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got, want Target
+			got.List = []int{9, 9}
+			want.List = []int{9, 9}
+			if err := json.Unmarshal([]byte(tc.input), &got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if err := stdjson.Unmarshal([]byte(tc.input), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got  %#v\nwant %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalNumberWidths(t *testing.T) {
+	type Numbers struct {
+		I8  int8    `json:"i8"`
+		I64 int64   `json:"i64"`
+		U16 uint16  `json:"u16"`
+		U64 uint64  `json:"u64"`
+		F32 float32 `json:"f32"`
+		F64 float64 `json:"f64"`
+	}
+
+	testCases := []struct {
+		name    string
+		input   string
+		wantErr error
+		path    string
+	}{
+		{"In range", `{"i8":-128,"i64":-9223372036854775808,"u16":65535,"u64":18446744073709551615,"f32":3.4e38,"f64":1e308}`, nil, ""},
+		{"Int8 overflow", `{"i8":128}`, tinyreflect.ErrOverflow, "i8"},
+		{"Int64 overflow", `{"i64":9223372036854775808}`, tinyreflect.ErrOverflow, "i64"},
+		{"Uint16 overflow", `{"u16":65536}`, tinyreflect.ErrOverflow, "u16"},
+		{"Negative uint", `{"u64":-1}`, tinyreflect.ErrTypeMismatch, "u64"},
+		{"Fraction into int", `{"i64":1.5}`, tinyreflect.ErrTypeMismatch, "i64"},
+		{"Float32 overflow", `{"f32":3.5e38}`, tinyreflect.ErrOverflow, "f32"},
+		{"Float64 overflow", `{"f64":1e309}`, tinyreflect.ErrOverflow, "f64"},
+		{"String into int", `{"i8":"1"}`, tinyreflect.ErrTypeMismatch, "i8"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got Numbers
+			err := json.Unmarshal([]byte(tc.input), &got)
+			if tc.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Unmarshal: %v", err)
+				}
+				var want Numbers
+				if err := stdjson.Unmarshal([]byte(tc.input), &want); err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Errorf("got %+v, want %+v", got, want)
+				}
+				return
+			}
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+			var ve *tinyreflect.ValueError
+			if !errors.As(err, &ve) || ve.Path != tc.path {
+				t.Errorf("got path %q, want %q", ve.Path, tc.path)
+			}
+		})
+	}
+}
+
+func TestDecoderStrict(t *testing.T) {
+	type Config struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+		DB   struct {
+			Name string `json:"name"`
+		} `json:"db"`
+	}
+	input := []byte(`{"host":"localhost","db":{"name":"app","user":"root"},"port":5432}`)
+
+	var lax Config
+	if err := json.Unmarshal(input, &lax); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if lax.Host != "localhost" || lax.Port != 5432 || lax.DB.Name != "app" {
+		t.Errorf("got %+v", lax)
+	}
+
+	var strict Config
+	err := json.Decoder{Strict: true}.Decode(input, &strict)
+	if !errors.Is(err, json.ErrUnknownField) {
+		t.Fatalf("got error %v, want ErrUnknownField", err)
+	}
+	var ve *tinyreflect.ValueError
+	if !errors.As(err, &ve) || ve.Path != "db.user" {
+		t.Errorf("got path %q, want %q", ve.Path, "db.user")
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	type Item struct {
+		Tags []string `json:"tags"`
+	}
+	type Doc struct {
+		Items []Item `json:"items"`
+	}
+
+	testCases := []struct {
+		name    string
+		input   string
+		target  any
+		wantErr error
+	}{
+		{"Nil pointer", `{}`, (*Doc)(nil), tinyreflect.ErrInvalidArgument},
+		{"Non-pointer", `{}`, Doc{}, tinyreflect.ErrInvalidArgument},
+		{"Empty input", ``, &Doc{}, json.ErrSyntax},
+		{"Trailing data", `{} {}`, &Doc{}, json.ErrSyntax},
+		{"Trailing comma", `{"items":[],}`, &Doc{}, json.ErrSyntax},
+		{"Missing colon", `{"items" []}`, &Doc{}, json.ErrSyntax},
+		{"Unclosed array", `{"items":[`, &Doc{}, json.ErrSyntax},
+		{"Mismatched close", `{"items":[}`, &Doc{}, json.ErrSyntax},
+		{"Leading zero", `[01]`, &[]int{}, json.ErrSyntax},
+		{"Bad literal", `[tru]`, &[]bool{}, json.ErrSyntax},
+		{"Bad escape", `["\x"]`, &[]string{}, json.ErrSyntax},
+		{"Control character", "[\"a\nb\"]", &[]string{}, json.ErrSyntax},
+		{"Bad base64", `"AA!="`, &[]byte{}, json.ErrSyntax},
+		{"Type mismatch", `{"items":[{"tags":[1]}]}`, &Doc{}, tinyreflect.ErrTypeMismatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := json.Unmarshal([]byte(tc.input), tc.target)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("got error %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+
+	var doc Doc
+	err := json.Unmarshal([]byte(`{"items":[{"tags":["a"]},{"tags":["b",2]}]}`), &doc)
+	var ve *tinyreflect.ValueError
+	if !errors.As(err, &ve) || ve.Path != "items[1].tags[1]" {
+		t.Errorf("got error %v, want path items[1].tags[1]", err)
+	}
+
+	var se *json.SyntaxError
+	if err := json.Unmarshal([]byte(`{"a" 1}`), &doc); !errors.As(err, &se) || se.Offset != 5 {
+		t.Errorf("got error %v, want *SyntaxError at offset 5", err)
+	}
+}
+
+func TestTokenizer(t *testing.T) {
+	tok := json.NewTokenizer([]byte(`{"a":[1,"x\n",true,false,null],"b":{}}`))
+	want := []struct {
+		kind  json.TokenKind
+		value string
+	}{
+		{json.BeginObject, ""},
+		{json.Key, "a"},
+		{json.BeginArray, ""},
+		{json.Number, "1"},
+		{json.String, "x\n"},
+		{json.True, ""},
+		{json.False, ""},
+		{json.Null, ""},
+		{json.EndArray, ""},
+		{json.Key, "b"},
+		{json.BeginObject, ""},
+		{json.EndObject, ""},
+		{json.EndObject, ""},
+		{json.EOF, ""},
+	}
+	for i, w := range want {
+		got, err := tok.Next()
+		if err != nil {
+			t.Fatalf("token %d: %v", i, err)
+		}
+		if got.Kind != w.kind || string(got.Value) != w.value {
+			t.Fatalf("token %d: got %v %q, want %v %q", i, got.Kind, got.Value, w.kind, w.value)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+// Package json encodes and decodes JSON through tinyreflect, without the
+// encoding/json, strconv or reflect packages, so it can be used in TinyGo
+// and WebAssembly builds.
+//
+// Struct fields are named by the `json` tag and support the options used
+// by encoding/json:
+//
+//	type User struct {
+//		ID    int64  `json:"id,string"`      // written as "42"
+//		Name  string `json:"name"`           // key "name"
+//		Email string `json:"email,omitempty"` // skipped when empty
+//		Token string `json:"-"`              // never written
+//	}
+//
+// Map keys are written in sorted order so the output is deterministic.
+package json
+
+import (
+	"cmp"
+	"slices"
+	"unicode/utf8"
+	"unsafe"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	"github.com/cdvelop/tinyreflect/internal/num"
+	. "github.com/cdvelop/tinystring"
+)
+
+// Errors reported by the encoder, wrapped in a *tinyreflect.ValueError
+// that carries the path of the value that failed.
+var (
+	// ErrUnsupportedValue is returned for NaN and infinite floats, which JSON cannot represent.
+	ErrUnsupportedValue = tinyreflect.NewError(D.Invalid, D.Number)
+	// ErrMaxDepth is returned for values nested too deeply, usually through
+	// a pointer cycle. All the codec packages share this value.
+	ErrMaxDepth = codec.ErrMaxDepth
+)
+
+// Marshal returns the JSON encoding of v.
+func Marshal(v any) ([]byte, error) {
+	c := GetConv()
+	defer c.PutConv()
+	if err := Encode(c, v); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), c.Bytes()...), nil
+}
+
+// Encode writes the JSON encoding of v to the output buffer of c.
+// Scalars and struct fields are written without allocating; read the
+// result with c.String() or c.Bytes().
+func Encode(c *Conv, v any) error {
+	return EncodeValue(c, tinyreflect.ValueOf(v))
+}
+
+// EncodeValue writes the JSON encoding of v to the output buffer of c.
+// The zero Value is written as null.
+func EncodeValue(c *Conv, v tinyreflect.Value) error {
+	return encodeValue(c, v, false, 0)
+}
+
+// encodeValue writes v; quoted wraps scalars in a JSON string (",string").
+func encodeValue(c *Conv, v tinyreflect.Value, quoted bool, depth int) error {
+	if depth > codec.MaxDepth {
+		return &tinyreflect.ValueError{Method: "json.Encode", Kind: v.Kind(), Err: ErrMaxDepth}
+	}
+
+	switch k := v.Kind(); k {
+	case K.Invalid:
+		c.WrString(BuffOut, "null")
+
+	case K.Bool:
+		b, _ := v.Bool()
+		if quoted {
+			c.WrString(BuffOut, `"`)
+		}
+		if b {
+			c.WrString(BuffOut, "true")
+		} else {
+			c.WrString(BuffOut, "false")
+		}
+		if quoted {
+			c.WrString(BuffOut, `"`)
+		}
+
+	case K.Int, K.Int8, K.Int16, K.Int32, K.Int64:
+		i, _ := v.Int()
+		var buf [24]byte
+		writeNumber(c, num.AppendInt(buf[:0], i), quoted)
+
+	case K.Uint, K.Uint8, K.Uint16, K.Uint32, K.Uint64, K.Uintptr:
+		u, _ := v.Uint()
+		var buf [24]byte
+		writeNumber(c, num.AppendUint(buf[:0], u), quoted)
+
+	case K.Float32, K.Float64:
+		f, _ := v.Float()
+		if f != f || f > maxFloat || f < -maxFloat {
+			return &tinyreflect.ValueError{Method: "json.Encode", Kind: k, Err: ErrUnsupportedValue}
+		}
+		bits := 64
+		if k == K.Float32 {
+			bits = 32
+		}
+		var buf [32]byte
+		writeNumber(c, num.AppendFloat(buf[:0], f, bits), quoted)
+
+	case K.String:
+		if quoted {
+			// ",string" on a string field encodes the JSON string again
+			writeString(c, string(appendString(nil, v.String())))
+			break
+		}
+		writeString(c, v.String())
+
+	case K.Struct:
+		return encodeStruct(c, v, depth)
+
+	case K.Map:
+		return encodeMap(c, v, depth)
+
+	case K.Slice:
+		if nil_, _ := v.IsNil(); nil_ {
+			c.WrString(BuffOut, "null")
+			break
+		}
+		if v.Type().Elem().Kind() == K.Uint8 {
+			// []byte is written as a base64 string
+			p, _ := v.UnsafePointer()
+			n, _ := v.Len()
+			c.WrString(BuffOut, `"`)
+			writeBase64(c, unsafe.Slice((*byte)(p), n))
+			c.WrString(BuffOut, `"`)
+			break
+		}
+		return encodeArray(c, v, depth)
+
+	case K.Array:
+		return encodeArray(c, v, depth)
+
+	case K.Pointer, K.Interface:
+		elem, err := v.Elem()
+		if err != nil {
+			return err
+		}
+		return encodeValue(c, elem, quoted, depth+1)
+
+	default:
+		return &tinyreflect.ValueError{Method: "json.Encode", Kind: k, Err: tinyreflect.ErrUnsupportedKind}
+	}
+	return nil
+}
+
+// maxFloat is the largest finite float64; anything beyond it is infinite.
+const maxFloat = 1.79769313486231570814527423731704356798070e+308
+
+// writeNumber writes the digits in b, inside quotes when quoted is set.
+func writeNumber(c *Conv, b []byte, quoted bool) {
+	if quoted {
+		c.WrString(BuffOut, `"`)
+	}
+	c.WrString(BuffOut, bytesString(b))
+	if quoted {
+		c.WrString(BuffOut, `"`)
+	}
+}
+
+// bytesString returns b as a string without copying.
+// The string must not be used after b changes.
+func bytesString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return unsafe.String(&b[0], len(b))
+}
+
+func encodeStruct(c *Conv, v tinyreflect.Value, depth int) error {
+	fields, err := cachedFields(v.Type())
+	if err != nil {
+		return err
+	}
+	c.WrString(BuffOut, "{")
+	first := true
+	for i := range fields.list {
+		f := &fields.list[i]
+		fv, err := codec.FieldByIndex(v, f.index)
+		if err != nil {
+			return codec.WithPath(err, f.name)
+		}
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		if !first {
+			c.WrString(BuffOut, ",")
+		}
+		first = false
+		c.WrString(BuffOut, f.key)
+		if err := encodeValue(c, fv, f.quoted, depth+1); err != nil {
+			return codec.WithPath(err, f.name)
+		}
+	}
+	c.WrString(BuffOut, "}")
+	return nil
+}
+
+func encodeArray(c *Conv, v tinyreflect.Value, depth int) error {
+	n, err := v.Len()
+	if err != nil {
+		return err
+	}
+	c.WrString(BuffOut, "[")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			c.WrString(BuffOut, ",")
+		}
+		elem, err := v.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := encodeValue(c, elem, false, depth+1); err != nil {
+			return codec.WithPath(err, codec.IndexSegment(i))
+		}
+	}
+	c.WrString(BuffOut, "]")
+	return nil
+}
+
+// mapEntry is a map element paired with its key as written in JSON.
+type mapEntry struct {
+	key string
+	val tinyreflect.Value
+}
+
+func encodeMap(c *Conv, v tinyreflect.Value, depth int) error {
+	if nil_, _ := v.IsNil(); nil_ {
+		c.WrString(BuffOut, "null")
+		return nil
+	}
+	iter, err := v.MapRange()
+	if err != nil {
+		return err
+	}
+	n, _ := v.Len()
+	entries := make([]mapEntry, 0, n)
+	for iter.Next() {
+		key, err := mapKeyString(iter.Key())
+		if err != nil {
+			return err
+		}
+		entries = append(entries, mapEntry{key, iter.Value()})
+	}
+	slices.SortFunc(entries, func(a, b mapEntry) int {
+		return cmp.Compare(a.key, b.key)
+	})
+
+	c.WrString(BuffOut, "{")
+	for i, e := range entries {
+		if i > 0 {
+			c.WrString(BuffOut, ",")
+		}
+		writeString(c, e.key)
+		c.WrString(BuffOut, ":")
+		if err := encodeValue(c, e.val, false, depth+1); err != nil {
+			return codec.WithPath(err, e.key)
+		}
+	}
+	c.WrString(BuffOut, "}")
+	return nil
+}
+
+// mapKeyString returns the JSON object key for a map key: strings are used
+// as is and integers are written in decimal, like encoding/json.
+func mapKeyString(k tinyreflect.Value) (string, error) {
+	switch k.Kind() {
+	case K.String:
+		return k.String(), nil
+	case K.Int, K.Int8, K.Int16, K.Int32, K.Int64:
+		i, _ := k.Int()
+		return string(num.AppendInt(nil, i)), nil
+	case K.Uint, K.Uint8, K.Uint16, K.Uint32, K.Uint64, K.Uintptr:
+		u, _ := k.Uint()
+		return string(num.AppendUint(nil, u)), nil
+	}
+	return "", &tinyreflect.ValueError{Method: "json.Encode", Kind: k.Kind(), Err: tinyreflect.ErrUnsupportedKind}
+}
+
+// isEmptyValue reports whether v is empty for ",omitempty":
+// false, 0, "", nil pointers and interfaces, and empty arrays, slices and maps.
+func isEmptyValue(v tinyreflect.Value) bool {
+	switch v.Kind() {
+	case K.Array, K.Map, K.Slice, K.String:
+		n, _ := v.Len()
+		return n == 0
+	case K.Bool,
+		K.Int, K.Int8, K.Int16, K.Int32, K.Int64,
+		K.Uint, K.Uint8, K.Uint16, K.Uint32, K.Uint64, K.Uintptr,
+		K.Float32, K.Float64,
+		K.Interface, K.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+const hex = "0123456789abcdef"
+
+// writeString writes s as a JSON string. Like encoding/json it escapes
+// control characters, <, > and & (so the output is safe inside HTML),
+// U+2028 and U+2029, and replaces invalid UTF-8 with U+FFFD.
+func writeString(c *Conv, s string) {
+	c.WrString(BuffOut, `"`)
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			c.WrString(BuffOut, s[start:i])
+			switch b {
+			case '\\':
+				c.WrString(BuffOut, `\\`)
+			case '"':
+				c.WrString(BuffOut, `\"`)
+			case '\n':
+				c.WrString(BuffOut, `\n`)
+			case '\r':
+				c.WrString(BuffOut, `\r`)
+			case '\t':
+				c.WrString(BuffOut, `\t`)
+			default:
+				var esc = [6]byte{'\\', 'u', '0', '0', hex[b>>4], hex[b&0xF]}
+				c.WrString(BuffOut, bytesString(esc[:]))
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			c.WrString(BuffOut, s[start:i])
+			c.WrString(BuffOut, "\ufffd")
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			c.WrString(BuffOut, s[start:i])
+			c.WrString(BuffOut, `\u202`)
+			c.WrString(BuffOut, hex[r&0xF:r&0xF+1])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	c.WrString(BuffOut, s[start:])
+	c.WrString(BuffOut, `"`)
+}
+
+// appendString appends s to dst as a JSON string, escaped like writeString.
+func appendString(dst []byte, s string) []byte {
+	c := GetConv()
+	writeString(c, s)
+	dst = append(dst, c.Bytes()...)
+	c.PutConv()
+	return dst
+}
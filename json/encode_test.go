@@ -0,0 +1,200 @@
+package json_test
+
+import (
+	stdjson "encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/json"
+	"github.com/cdvelop/tinystring"
+)
+
+type Address struct {
+	Street string `json:"street"`
+	City   string `json:"city,omitempty"`
+}
+
+type Base struct {
+	ID      int64 `json:"id,string"`
+	Created int64
+}
+
+type Order struct {
+	Base
+	Customer string            `json:"customer"`
+	Items    []Item            `json:"items"`
+	Tags     map[string]int    `json:"tags,omitempty"`
+	Ship     *Address          `json:"ship"`
+	Bill     *Address          `json:"bill,omitempty"`
+	Notes    []string          `json:"notes"`
+	Meta     map[int]string    `json:"meta"`
+	Extra    any               `json:"extra"`
+	Raw      []byte            `json:"raw"`
+	Grid     [2][2]int8        `json:"grid"`
+	Secret   string            `json:"-"`
+	Dash     string            `json:"-,"`
+	Paid     bool              `json:"paid,string"`
+	Ratio    float32           `json:"ratio"`
+	Total    float64           `json:"total"`
+	Limits   map[string]uint16 `json:"limits"`
+	internal int
+}
+
+type Item struct {
+	SKU   string  `json:"sku"`
+	Qty   uint8   `json:"qty"`
+	Price float64 `json:"price,omitempty"`
+}
+
+func TestMarshalMatchesEncodingJSON(t *testing.T) {
+	order := Order{
+		Base:     Base{ID: 42, Created: 1700000000},
+		Customer: "Ana <ana@example.com> & co\n\"quoted\"\t ",
+		Items:    []Item{{"A-1", 2, 9.99}, {"B-2", 1, 0}},
+		Tags:     map[string]int{"zeta": 1, "alpha": 2, "mid": 3},
+		Ship:     &Address{Street: "Main 1"},
+		Meta:     map[int]string{10: "ten", -1: "minus", 2: "two"},
+		Extra:    map[string]any{"n": 1.5, "list": []any{true, nil, "x"}},
+		Raw:      []byte("hello, world"),
+		Grid:     [2][2]int8{{1, -2}, {3, 4}},
+		Secret:   "hidden",
+		Dash:     "dash",
+		Paid:     true,
+		Ratio:    0.1,
+		Total:    1e21,
+		Limits:   map[string]uint16{"b": 2, "a": 65535},
+		internal: 7,
+	}
+
+	testCases := []struct {
+		name  string
+		value any
+	}{
+		{"Order", order},
+		{"Pointer to order", &order},
+		{"Zero order", Order{}},
+		{"Int", -12345},
+		{"Uint64", uint64(math.MaxUint64)},
+		{"Small float", 1e-7},
+		{"Float32", float32(3.14)},
+		{"String", "héllo \x00 \xff"},
+		{"Nil", nil},
+		{"Nil slice", []int(nil)},
+		{"Empty slice", []int{}},
+		{"Nil map", map[string]int(nil)},
+		{"Nested slices", [][]string{{"a"}, {}, nil}},
+		{"Bytes", []byte{0, 1, 2, 250}},
+		{"Interface slice", []any{1, "two", 3.5, false, nil, []int{4}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := json.Marshal(tc.value)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			want, err := stdjson.Marshal(tc.value)
+			if err != nil {
+				t.Fatalf("encoding/json failed: %v", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("Marshal mismatch\n got: %s\nwant: %s", got, want)
+			}
+		})
+	}
+}
+
+func TestEncodeIntoConv(t *testing.T) {
+	c := tinystring.GetConv()
+	defer c.PutConv()
+	c.WrString(tinystring.BuffOut, "data=")
+	if err := json.Encode(c, Item{SKU: "x", Qty: 3}); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if got, want := string(c.Bytes()), `data={"sku":"x","qty":3}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	type Box struct {
+		Items []struct {
+			Value float64 `json:"value"`
+		} `json:"items"`
+		Ch chan int `json:"-"`
+	}
+	box := Box{}
+	box.Items = append(box.Items, struct {
+		Value float64 `json:"value"`
+	}{1}, struct {
+		Value float64 `json:"value"`
+	}{math.NaN()})
+
+	_, err := json.Marshal(box)
+	if !errors.Is(err, json.ErrUnsupportedValue) {
+		t.Fatalf("expected ErrUnsupportedValue, got %v", err)
+	}
+	var ve *tinyreflect.ValueError
+	if !errors.As(err, &ve) || ve.Path != "items[1].value" {
+		t.Errorf("unexpected error path: %v", err)
+	}
+
+	_, err = json.Marshal(map[string]func(){"f": nil})
+	if !errors.Is(err, tinyreflect.ErrUnsupportedKind) {
+		t.Errorf("expected ErrUnsupportedKind for func values, got %v", err)
+	}
+
+	type Node struct {
+		Next *Node
+	}
+	n := &Node{}
+	n.Next = n
+	_, err = json.Marshal(n)
+	if !errors.Is(err, json.ErrMaxDepth) || !errors.As(err, &ve) || ve.Path != "Next" {
+		t.Errorf("expected ErrMaxDepth at Next for a pointer cycle, got %v", err)
+	}
+}
+
+func TestMapKeyOrderIsDeterministic(t *testing.T) {
+	m := map[string]int{}
+	for _, k := range []string{"q", "w", "e", "r", "t", "y", "u", "i", "o", "p"} {
+		m[k] = len(k)
+	}
+	first, _ := json.Marshal(m)
+	for i := 0; i < 20; i++ {
+		again, _ := json.Marshal(m)
+		if string(again) != string(first) {
+			t.Fatalf("output changed between runs:\n%s\n%s", first, again)
+		}
+	}
+	if want := `{"e":1,"i":1,"o":1,"p":1,"q":1,"r":1,"t":1,"u":1,"w":1,"y":1}`; string(first) != want {
+		t.Errorf("got %s, want %s", first, want)
+	}
+}
+
+func TestEncodeScalarFieldsDoNotAllocate(t *testing.T) {
+	type Sample struct {
+		ID     int64   `json:"id"`
+		Count  uint32  `json:"count,string"`
+		Price  float64 `json:"price"`
+		Active bool    `json:"active"`
+		Name   string  `json:"name,omitempty"`
+	}
+	var v any = &Sample{ID: -7, Count: 12, Price: 19.95, Active: true, Name: "pen"}
+
+	c := tinystring.GetConv()
+	defer c.PutConv()
+	if err := json.Encode(c, v); err != nil { // warm up the field cache and buffer
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		c.Reset()
+		json.Encode(c, v)
+	})
+	if allocs != 0 {
+		t.Errorf("Encode allocated %v times per run, want 0", allocs)
+	}
+}
@@ -0,0 +1,130 @@
+package json
+
+import (
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/cache"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	. "github.com/cdvelop/tinystring"
+)
+
+// field describes how one struct field is encoded.
+type field struct {
+	name      string // JSON object key
+	key       string // key already quoted and followed by ':', e.g. `"id":`
+	index     []int  // field index, through embedded structs when promoted
+	typ       *tinyreflect.Type
+	tagged    bool // name comes from the json tag
+	omitEmpty bool // ",omitempty": skip false, 0, "", nil and empty collections
+	quoted    bool // ",string": write scalars inside a JSON string
+}
+
+// structFields lists the encoded fields of a struct type in field order.
+type structFields struct {
+	list   []field
+	byName map[string]int // index into list by exact JSON name
+}
+
+// lookup returns the field for an object key: an exact match first, then
+// an ASCII case-insensitive one like encoding/json. It returns nil if no
+// field matches.
+func (sf *structFields) lookup(key []byte) *field {
+	if i, ok := sf.byName[string(key)]; ok {
+		return &sf.list[i]
+	}
+	for i := range sf.list {
+		if equalFoldASCII(sf.list[i].name, key) {
+			return &sf.list[i]
+		}
+	}
+	return nil
+}
+
+// equalFoldASCII reports whether s and b are equal ignoring ASCII case.
+func equalFoldASCII(s string, b []byte) bool {
+	if len(s) != len(b) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		x, y := s[i], b[i]
+		if 'A' <= x && x <= 'Z' {
+			x += 'a' - 'A'
+		}
+		if 'A' <= y && y <= 'Z' {
+			y += 'a' - 'A'
+		}
+		if x != y {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldCache keeps the parsed fields of each struct type.
+var fieldCache cache.Cache[*tinyreflect.Type, *structFields]
+
+// cachedFields returns the encoded fields of the struct type t.
+func cachedFields(t *tinyreflect.Type) (*structFields, error) {
+	if sf, ok := fieldCache.Load(t); ok {
+		return sf, nil
+	}
+
+	all, err := codec.Fields(t, "json")
+	if err != nil {
+		return nil, err
+	}
+	list := make([]field, len(all))
+	for i, cf := range all {
+		f := field{
+			name:      cf.Name,
+			index:     cf.Index,
+			typ:       cf.Type,
+			tagged:    cf.Tagged,
+			omitEmpty: cf.Opts.Has("omitempty"),
+		}
+		if cf.Opts.Has("string") {
+			switch cf.Type.Kind() {
+			case K.Bool, K.String,
+				K.Int, K.Int8, K.Int16, K.Int32, K.Int64,
+				K.Uint, K.Uint8, K.Uint16, K.Uint32, K.Uint64, K.Uintptr,
+				K.Float32, K.Float64:
+				f.quoted = true
+			}
+		}
+		f.key = string(appendString(nil, f.name)) + ":"
+		list[i] = f
+	}
+	sf := &structFields{list: dominantFields(list)}
+	sf.byName = make(map[string]int, len(sf.list))
+	for i, f := range sf.list {
+		sf.byName[f.name] = i
+	}
+	fieldCache.Store(t, sf)
+	return sf, nil
+}
+
+// dominantFields drops the fields hidden by Go's embedding rules: for each
+// name the shallowest field wins, a tagged field wins over an untagged one
+// at the same depth, and names that stay ambiguous are dropped.
+func dominantFields(list []field) []field {
+	out := list[:0:0]
+	for i, f := range list {
+		dominant := true
+		for j, g := range list {
+			if i == j || g.name != f.name {
+				continue
+			}
+			switch {
+			case len(g.index) < len(f.index):
+				dominant = false
+			case len(g.index) == len(f.index) && g.tagged && !f.tagged:
+				dominant = false
+			case len(g.index) == len(f.index) && g.tagged == f.tagged:
+				dominant = false // ambiguous
+			}
+		}
+		if dominant {
+			out = append(out, f)
+		}
+	}
+	return out
+}
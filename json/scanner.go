@@ -0,0 +1,429 @@
+package json
+
+import (
+	"unicode/utf8"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	. "github.com/cdvelop/tinystring"
+)
+
+// ErrSyntax is wrapped by SyntaxError when the input is not valid JSON.
+var ErrSyntax = tinyreflect.NewError(D.Invalid, D.Format)
+
+// SyntaxError reports malformed JSON and the byte offset where it was found.
+type SyntaxError struct {
+	Offset int // bytes read before the error
+}
+
+// Error returns "json: Invalid Format (offset N)" with the reason translated.
+func (e *SyntaxError) Error() string {
+	return Fmt("json: %s (offset %d)", ErrSyntax.Error(), e.Offset)
+}
+
+// Unwrap returns ErrSyntax.
+func (e *SyntaxError) Unwrap() error {
+	return ErrSyntax
+}
+
+// TokenKind identifies the kind of a JSON token.
+type TokenKind uint8
+
+const (
+	EOF         TokenKind = iota // end of input after a complete value
+	BeginObject                  // {
+	EndObject                    // }
+	BeginArray                   // [
+	EndArray                     // ]
+	Key                          // object key
+	String                       // string value
+	Number                       // number value
+	True                         // true
+	False                        // false
+	Null                         // null
+)
+
+// Token is a single JSON token.
+type Token struct {
+	Kind TokenKind
+	// Value holds the unescaped text of Key and String tokens and the
+	// literal text of Number tokens. It may point into the input or into
+	// the tokenizer's scratch space and is only valid until the next call
+	// to Next.
+	Value []byte
+}
+
+// what the tokenizer expects next
+const (
+	expValue      = iota // any value
+	expValueOrEnd        // a value or ']' (after '[')
+	expKeyOrEnd          // a key or '}' (after '{')
+	expKey               // a key (after ',' in an object)
+	expCommaOrEnd        // ',' or the end of the current container
+	expEOF               // only whitespace
+)
+
+// Tokenizer splits JSON input into tokens one at a time, without building
+// an intermediate tree. Commas and colons are checked and consumed, so the
+// caller only sees values, keys and container delimiters.
+type Tokenizer struct {
+	data    []byte
+	pos     int
+	expect  int
+	stack   []byte // open containers: '{' or '['
+	scratch []byte // unescaped strings
+}
+
+// NewTokenizer returns a tokenizer reading data.
+func NewTokenizer(data []byte) *Tokenizer {
+	return &Tokenizer{data: data}
+}
+
+// Offset returns the number of input bytes consumed so far.
+func (t *Tokenizer) Offset() int {
+	return t.pos
+}
+
+func (t *Tokenizer) syntaxError() error {
+	return &SyntaxError{Offset: t.pos}
+}
+
+func (t *Tokenizer) skipSpace() {
+	for t.pos < len(t.data) {
+		switch t.data[t.pos] {
+		case ' ', '\t', '\n', '\r':
+			t.pos++
+		default:
+			return
+		}
+	}
+}
+
+// Next returns the next token. After the last value it returns a token of
+// kind EOF; trailing data other than whitespace is a *SyntaxError.
+func (t *Tokenizer) Next() (Token, error) {
+	t.skipSpace()
+
+	switch t.expect {
+	case expEOF:
+		if t.pos != len(t.data) {
+			return Token{}, t.syntaxError()
+		}
+		return Token{Kind: EOF}, nil
+
+	case expCommaOrEnd:
+		if t.pos >= len(t.data) {
+			return Token{}, t.syntaxError()
+		}
+		top := t.stack[len(t.stack)-1]
+		switch c := t.data[t.pos]; {
+		case c == '}' && top == '{', c == ']' && top == '[':
+			return t.end(), nil
+		case c == ',':
+			t.pos++
+			t.skipSpace()
+			if top == '{' {
+				return t.key()
+			}
+		default:
+			return Token{}, t.syntaxError()
+		}
+
+	case expKeyOrEnd:
+		if t.pos < len(t.data) && t.data[t.pos] == '}' {
+			return t.end(), nil
+		}
+		return t.key()
+
+	case expKey:
+		return t.key()
+
+	case expValueOrEnd:
+		if t.pos < len(t.data) && t.data[t.pos] == ']' {
+			return t.end(), nil
+		}
+	}
+	return t.value()
+}
+
+// end consumes the closing delimiter of the current container.
+func (t *Tokenizer) end() Token {
+	kind := EndArray
+	if t.data[t.pos] == '}' {
+		kind = EndObject
+	}
+	t.pos++
+	t.stack = t.stack[:len(t.stack)-1]
+	t.afterValue()
+	return Token{Kind: kind}
+}
+
+// afterValue sets what may follow a complete value.
+func (t *Tokenizer) afterValue() {
+	if len(t.stack) == 0 {
+		t.expect = expEOF
+	} else {
+		t.expect = expCommaOrEnd
+	}
+}
+
+// key reads an object key and the colon after it.
+func (t *Tokenizer) key() (Token, error) {
+	if t.pos >= len(t.data) || t.data[t.pos] != '"' {
+		return Token{}, t.syntaxError()
+	}
+	s, err := t.str()
+	if err != nil {
+		return Token{}, err
+	}
+	t.skipSpace()
+	if t.pos >= len(t.data) || t.data[t.pos] != ':' {
+		return Token{}, t.syntaxError()
+	}
+	t.pos++
+	t.expect = expValue
+	return Token{Kind: Key, Value: s}, nil
+}
+
+// value reads a scalar or opens a container.
+func (t *Tokenizer) value() (Token, error) {
+	if t.pos >= len(t.data) {
+		return Token{}, t.syntaxError()
+	}
+	switch c := t.data[t.pos]; {
+	case c == '{' || c == '[':
+		if len(t.stack) >= codec.MaxDepth {
+			return Token{}, &tinyreflect.ValueError{Method: "json.Decode", Err: ErrMaxDepth}
+		}
+		t.pos++
+		t.stack = append(t.stack, c)
+		if c == '{' {
+			t.expect = expKeyOrEnd
+			return Token{Kind: BeginObject}, nil
+		}
+		t.expect = expValueOrEnd
+		return Token{Kind: BeginArray}, nil
+
+	case c == '"':
+		s, err := t.str()
+		if err != nil {
+			return Token{}, err
+		}
+		t.afterValue()
+		return Token{Kind: String, Value: s}, nil
+
+	case c == '-' || c >= '0' && c <= '9':
+		n, err := t.number()
+		if err != nil {
+			return Token{}, err
+		}
+		t.afterValue()
+		return Token{Kind: Number, Value: n}, nil
+
+	case c == 't':
+		return t.literal("true", True)
+	case c == 'f':
+		return t.literal("false", False)
+	case c == 'n':
+		return t.literal("null", Null)
+	}
+	return Token{}, t.syntaxError()
+}
+
+func (t *Tokenizer) literal(lit string, kind TokenKind) (Token, error) {
+	if len(t.data)-t.pos < len(lit) || string(t.data[t.pos:t.pos+len(lit)]) != lit {
+		return Token{}, t.syntaxError()
+	}
+	t.pos += len(lit)
+	t.afterValue()
+	return Token{Kind: kind}, nil
+}
+
+// number reads -?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?
+func (t *Tokenizer) number() ([]byte, error) {
+	start := t.pos
+	d := t.data
+	i := t.pos
+	if d[i] == '-' {
+		i++
+	}
+	digits := func() bool {
+		j := i
+		for i < len(d) && d[i] >= '0' && d[i] <= '9' {
+			i++
+		}
+		return i > j
+	}
+	switch {
+	case i < len(d) && d[i] == '0':
+		i++
+	case !digits():
+		t.pos = i
+		return nil, t.syntaxError()
+	}
+	if i < len(d) && d[i] == '.' {
+		i++
+		if !digits() {
+			t.pos = i
+			return nil, t.syntaxError()
+		}
+	}
+	if i < len(d) && (d[i] == 'e' || d[i] == 'E') {
+		i++
+		if i < len(d) && (d[i] == '+' || d[i] == '-') {
+			i++
+		}
+		if !digits() {
+			t.pos = i
+			return nil, t.syntaxError()
+		}
+	}
+	t.pos = i
+	return d[start:i], nil
+}
+
+// str reads a quoted string starting at t.pos and returns its unescaped
+// contents. Strings without escapes are returned as a slice of the input.
+func (t *Tokenizer) str() ([]byte, error) {
+	d := t.data
+	i := t.pos + 1
+	start := i
+	for i < len(d) {
+		switch c := d[i]; {
+		case c == '"':
+			t.pos = i + 1
+			return d[start:i], nil
+		case c == '\\':
+			return t.unescape(start, i)
+		case c < ' ':
+			t.pos = i
+			return nil, t.syntaxError()
+		}
+		i++
+	}
+	t.pos = i
+	return nil, t.syntaxError()
+}
+
+// unescape finishes reading a string whose first escape is at i.
+func (t *Tokenizer) unescape(start, i int) ([]byte, error) {
+	d := t.data
+	buf := append(t.scratch[:0], d[start:i]...)
+	for i < len(d) {
+		c := d[i]
+		switch {
+		case c == '"':
+			t.pos = i + 1
+			t.scratch = buf
+			return buf, nil
+		case c < ' ':
+			t.pos = i
+			return nil, t.syntaxError()
+		case c != '\\':
+			buf = append(buf, c)
+			i++
+			continue
+		}
+
+		i++
+		if i >= len(d) {
+			break
+		}
+		switch d[i] {
+		case '"', '\\', '/':
+			buf = append(buf, d[i])
+		case 'b':
+			buf = append(buf, '\b')
+		case 'f':
+			buf = append(buf, '\f')
+		case 'n':
+			buf = append(buf, '\n')
+		case 'r':
+			buf = append(buf, '\r')
+		case 't':
+			buf = append(buf, '\t')
+		case 'u':
+			r, ok := hex4(d, i+1)
+			if !ok {
+				t.pos = i
+				return nil, t.syntaxError()
+			}
+			i += 4
+			if r >= 0xD800 && r < 0xDC00 {
+				// High surrogate: combine with a following low surrogate
+				if i+6 < len(d) && d[i+1] == '\\' && d[i+2] == 'u' {
+					if lo, ok := hex4(d, i+3); ok && lo >= 0xDC00 && lo < 0xE000 {
+						r = (r-0xD800)<<10 | (lo - 0xDC00) + 0x10000
+						i += 6
+					} else {
+						r = utf8.RuneError
+					}
+				} else {
+					r = utf8.RuneError
+				}
+			} else if r >= 0xDC00 && r < 0xE000 {
+				r = utf8.RuneError
+			}
+			buf = utf8.AppendRune(buf, r)
+		default:
+			t.pos = i
+			return nil, t.syntaxError()
+		}
+		i++
+	}
+	t.pos = i
+	return nil, t.syntaxError()
+}
+
+// hex4 decodes the four hex digits at d[i:i+4].
+func hex4(d []byte, i int) (rune, bool) {
+	if i+4 > len(d) {
+		return 0, false
+	}
+	var r rune
+	for _, c := range d[i : i+4] {
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c = c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return 0, false
+		}
+		r = r<<4 | rune(c)
+	}
+	return r, true
+}
+
+// Skip reads and discards the next value, including everything inside
+// it when it is an object or an array.
+func (t *Tokenizer) Skip() error {
+	tok, err := t.Next()
+	if err != nil {
+		return err
+	}
+	return t.skipFrom(tok)
+}
+
+// skipFrom discards the rest of the value that starts with tok.
+func (t *Tokenizer) skipFrom(tok Token) error {
+	if tok.Kind != BeginObject && tok.Kind != BeginArray {
+		return nil
+	}
+	depth := 1
+	for depth > 0 {
+		tok, err := t.Next()
+		if err != nil {
+			return err
+		}
+		switch tok.Kind {
+		case BeginObject, BeginArray:
+			depth++
+		case EndObject, EndArray:
+			depth--
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+//go:build !tinygo
+
+package tinyreflect_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+func TestVerifyLayout(t *testing.T) {
+	if err := tinyreflect.VerifyLayout(); err != nil {
+		t.Fatalf("VerifyLayout: %v", err)
+	}
+}
+
+func TestLayoutErrorMessage(t *testing.T) {
+	err := &tinyreflect.LayoutError{Type: "layoutProbe", Field: "C", Check: "offset", Want: "16", Got: "24"}
+	if !errors.Is(err, tinyreflect.ErrLayoutMismatch) {
+		t.Error("LayoutError should wrap ErrLayoutMismatch")
+	}
+	want := "reflect layout: layoutProbe.C offset = 24, want 16 (Type Mismatch)"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+// layoutCorpus lists the shapes compared against the standard reflect package.
+type layoutCorpus struct {
+	Flag    bool
+	Small   int8
+	Count   int
+	Big     uint64
+	Ratio   float32
+	Label   string `json:"label,omitempty"`
+	IDs     []int64
+	ID      [16]byte
+	Vector  [3]float32
+	Parent  *layoutCorpus
+	Scores  map[string]int
+	Nested  NestedTestStruct
+	private uint16
+	Any     any
+}
+
+// TestLayoutMatchesReflect compares sizes, kinds, field offsets, names and tags
+// read by tinyreflect against the standard library reflect package.
+func TestLayoutMatchesReflect(t *testing.T) {
+	corpus := []any{
+		true, int8(0), int16(0), int32(0), int64(0), 0,
+		uint8(0), uint16(0), uint32(0), uint64(0), uint(0), uintptr(0),
+		float32(0), float64(0), "",
+		[]string{}, []byte{}, [4]int32{}, [0]string{}, (*int)(nil), map[string]bool{},
+		TestStruct{}, NestedTestStruct{}, layoutCorpus{}, struct{}{},
+	}
+
+	for _, sample := range corpus {
+		rt := reflect.TypeOf(sample)
+		tt := tinyreflect.TypeOf(sample)
+
+		t.Run(rt.String(), func(t *testing.T) {
+			if tt.Size() != rt.Size() {
+				t.Errorf("Size() = %d, reflect says %d", tt.Size(), rt.Size())
+			}
+			if uint(tt.Kind()) != uint(rt.Kind()) {
+				t.Errorf("Kind() = %s, reflect says %s", tt.Kind(), rt.Kind())
+			}
+
+			switch rt.Kind() {
+			case reflect.Array:
+				if tt.ArrayType().Length() != rt.Len() {
+					t.Errorf("Length() = %d, reflect says %d", tt.ArrayType().Length(), rt.Len())
+				}
+				fallthrough
+			case reflect.Slice, reflect.Pointer:
+				if uint(tt.Elem().Kind()) != uint(rt.Elem().Kind()) || tt.Elem().Size() != rt.Elem().Size() {
+					t.Errorf("Elem() = %s/%d, reflect says %s/%d", tt.Elem().Kind(), tt.Elem().Size(), rt.Elem().Kind(), rt.Elem().Size())
+				}
+			case reflect.Struct:
+				compareStructLayout(t, tt, rt)
+			}
+		})
+	}
+}
+
+func compareStructLayout(t *testing.T, tt *tinyreflect.Type, rt reflect.Type) {
+	t.Helper()
+	n, err := tt.NumField()
+	if err != nil || n != rt.NumField() {
+		t.Fatalf("NumField() = %d, %v, reflect says %d", n, err, rt.NumField())
+	}
+	for i := 0; i < n; i++ {
+		rf := rt.Field(i)
+		f, err := tt.Field(i)
+		if err != nil {
+			t.Fatalf("Field(%d) failed: %v", i, err)
+		}
+		name, _ := tt.NameByIndex(i)
+		if name != rf.Name {
+			t.Errorf("field %d name = %q, reflect says %q", i, name, rf.Name)
+		}
+		if f.Off != rf.Offset {
+			t.Errorf("field %s offset = %d, reflect says %d", rf.Name, f.Off, rf.Offset)
+		}
+		if uint(f.Typ.Kind()) != uint(rf.Type.Kind()) {
+			t.Errorf("field %s kind = %s, reflect says %s", rf.Name, f.Typ.Kind(), rf.Type.Kind())
+		}
+		if f.Typ.Size() != rf.Type.Size() {
+			t.Errorf("field %s size = %d, reflect says %d", rf.Name, f.Typ.Size(), rf.Type.Size())
+		}
+		if string(f.Tag()) != string(rf.Tag) {
+			t.Errorf("field %s tag = %q, reflect says %q", rf.Name, f.Tag(), rf.Tag)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package tinyreflect_test
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+func TestMapRange(t *testing.T) {
+	m := map[string]int{"a": 1, "b": 2, "c": 3}
+	v := tinyreflect.ValueOf(m)
+
+	if n, err := v.Len(); err != nil || n != 3 {
+		t.Fatalf("Len() = %d, %v; want 3", n, err)
+	}
+
+	iter, err := v.MapRange()
+	if err != nil {
+		t.Fatalf("MapRange failed: %v", err)
+	}
+	got := map[string]int{}
+	for iter.Next() {
+		k, _ := iter.Key().Interface()
+		e, _ := iter.Value().Interface()
+		got[k.(string)] = e.(int)
+	}
+	if len(got) != len(m) {
+		t.Fatalf("iterated %d entries, want %d", len(got), len(m))
+	}
+	for k, want := range m {
+		if got[k] != want {
+			t.Errorf("entry %q = %d, want %d", k, got[k], want)
+		}
+	}
+	if iter.Next() {
+		t.Error("Next after exhaustion returned true")
+	}
+
+	keys, err := v.MapKeys()
+	if err != nil {
+		t.Fatalf("MapKeys failed: %v", err)
+	}
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.String()
+	}
+	sort.Strings(names)
+	if len(names) != 3 || names[0] != "a" || names[2] != "c" {
+		t.Errorf("MapKeys() = %v", names)
+	}
+}
+
+func TestMapIndex(t *testing.T) {
+	type point struct{ X, Y int }
+	m := map[int]point{1: {1, 2}, 7: {3, 4}}
+	v := tinyreflect.ValueOf(m)
+
+	e, err := v.MapIndex(tinyreflect.ValueOf(7))
+	if err != nil {
+		t.Fatalf("MapIndex failed: %v", err)
+	}
+	x, _ := e.Field(0)
+	if i, _ := x.Int(); i != 3 {
+		t.Errorf("m[7].X = %d, want 3", i)
+	}
+	if e.CanSet() {
+		t.Error("map elements must not be settable")
+	}
+
+	missing, err := v.MapIndex(tinyreflect.ValueOf(99))
+	if err != nil || missing.Kind().String() != "invalid" {
+		t.Errorf("MapIndex(missing) = %v, %v; want zero Value", missing.Kind(), err)
+	}
+
+	if _, err := v.MapIndex(tinyreflect.ValueOf("7")); !errors.Is(err, tinyreflect.ErrTypeMismatch) {
+		t.Errorf("MapIndex with wrong key type: expected ErrTypeMismatch, got %v", err)
+	}
+}
+
+func TestNilMap(t *testing.T) {
+	var m map[string]bool
+	v := tinyreflect.ValueOf(m)
+	if isNil, err := v.IsNil(); err != nil || !isNil {
+		t.Errorf("IsNil() = %v, %v; want true", isNil, err)
+	}
+	if n, _ := v.Len(); n != 0 {
+		t.Errorf("Len() = %d, want 0", n)
+	}
+	iter, _ := v.MapRange()
+	if iter.Next() {
+		t.Error("Next on a nil map returned true")
+	}
+	if _, err := tinyreflect.ValueOf(1).MapRange(); !errors.Is(err, tinyreflect.ErrUnsupportedKind) {
+		t.Errorf("MapRange on int: expected ErrUnsupportedKind, got %v", err)
+	}
+}
+
+func TestMapTypeKeyElem(t *testing.T) {
+	typ := tinyreflect.TypeOf(map[string][]int{})
+	if k := typ.Key(); k == nil || k.Kind().String() != "string" {
+		t.Errorf("Key() = %v, want string", k)
+	}
+	if e := typ.Elem(); e == nil || e.Kind().String() != "slice" {
+		t.Errorf("Elem() = %v, want slice", e)
+	}
+	if tinyreflect.TypeOf(1).Key() != nil {
+		t.Error("Key() of int should be nil")
+	}
+}
+
+func TestInterfaceElem(t *testing.T) {
+	type holder struct {
+		Any any
+		Err error
+	}
+	h := holder{Any: 42, Err: errors.New("boom")}
+	v := tinyreflect.ValueOf(h)
+
+	anyField, _ := v.Field(0)
+	elem, err := anyField.Elem()
+	if err != nil {
+		t.Fatalf("Elem of any failed: %v", err)
+	}
+	if i, _ := elem.Int(); i != 42 {
+		t.Errorf("Elem() = %d, want 42", i)
+	}
+	if got, _ := anyField.Interface(); got != 42 {
+		t.Errorf("Interface() = %v, want 42", got)
+	}
+
+	errField, _ := v.Field(1)
+	elem, err = errField.Elem()
+	if err != nil || elem.Kind().String() != "ptr" {
+		t.Errorf("Elem of error = %v, %v; want ptr", elem.Kind(), err)
+	}
+
+	var empty holder
+	nilField, _ := tinyreflect.ValueOf(empty).Field(0)
+	if isNil, _ := nilField.IsNil(); !isNil {
+		t.Error("IsNil() of a nil interface field should be true")
+	}
+	if elem, err := nilField.Elem(); err != nil || elem.Kind().String() != "invalid" {
+		t.Errorf("Elem of nil interface = %v, %v; want zero Value", elem.Kind(), err)
+	}
+}
+
+func TestUnexportedFieldsAreReadOnly(t *testing.T) {
+	type sample struct {
+		Public  int
+		private int
+	}
+	s := sample{1, 2}
+	v, _ := tinyreflect.ValueOf(&s).Elem()
+
+	pub, _ := v.Field(0)
+	priv, _ := v.Field(1)
+	if !pub.CanSet() {
+		t.Error("exported field should be settable")
+	}
+	if priv.CanSet() {
+		t.Error("unexported field should not be settable")
+	}
+	if err := priv.SetInt(5); !errors.Is(err, tinyreflect.ErrNotAssignable) {
+		t.Errorf("SetInt on unexported field: expected ErrNotAssignable, got %v", err)
+	}
+	if got, _ := priv.Interface(); got != 2 {
+		t.Errorf("unexported field should stay readable, got %v", got)
+	}
+}
+
+func TestSetMapIndex(t *testing.T) {
+	typ := tinyreflect.TypeOf(map[string]int(nil))
+	v, err := tinyreflect.MakeMap(typ)
+	if err != nil {
+		t.Fatalf("MakeMap failed: %v", err)
+	}
+
+	for i, k := range []string{"a", "b", "c"} {
+		key := tinyreflect.ValueOf(k)
+		elem := tinyreflect.ValueOf(i + 1)
+		if err := v.SetMapIndex(key, elem); err != nil {
+			t.Fatalf("SetMapIndex(%q) failed: %v", k, err)
+		}
+	}
+	if err := v.SetMapIndex(tinyreflect.ValueOf("b"), tinyreflect.Value{}); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	got, _ := v.Interface()
+	m := got.(map[string]int)
+	if len(m) != 2 || m["a"] != 1 || m["c"] != 3 {
+		t.Errorf("map = %v, want map[a:1 c:3]", m)
+	}
+
+	if err := v.SetMapIndex(tinyreflect.ValueOf(1), tinyreflect.ValueOf(1)); !errors.Is(err, tinyreflect.ErrTypeMismatch) {
+		t.Errorf("wrong key type: got %v, want ErrTypeMismatch", err)
+	}
+	if err := v.SetMapIndex(tinyreflect.ValueOf("x"), tinyreflect.ValueOf("y")); !errors.Is(err, tinyreflect.ErrTypeMismatch) {
+		t.Errorf("wrong elem type: got %v, want ErrTypeMismatch", err)
+	}
+
+	var nilMap map[string]int
+	nv := tinyreflect.ValueOf(nilMap)
+	if err := nv.SetMapIndex(tinyreflect.ValueOf("a"), tinyreflect.ValueOf(1)); !errors.Is(err, tinyreflect.ErrNilValue) {
+		t.Errorf("nil map: got %v, want ErrNilValue", err)
+	}
+
+	anyMap := map[int]any{}
+	av := tinyreflect.ValueOf(anyMap)
+	if err := av.SetMapIndex(tinyreflect.ValueOf(7), tinyreflect.ValueOf("seven")); err != nil {
+		t.Fatalf("SetMapIndex into map[int]any failed: %v", err)
+	}
+	if anyMap[7] != "seven" {
+		t.Errorf("anyMap[7] = %v, want seven", anyMap[7])
+	}
+
+	if _, err := tinyreflect.MakeMap(tinyreflect.TypeOf(0)); !errors.Is(err, tinyreflect.ErrUnsupportedKind) {
+		t.Errorf("MakeMap(int): got %v, want ErrUnsupportedKind", err)
+	}
+}
@@ -0,0 +1,585 @@
+package msgpack
+
+import (
+	"math"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	. "github.com/cdvelop/tinystring"
+)
+
+func decodeError(k Kind, err error) error {
+	return &tinyreflect.ValueError{Method: "msgpack.Unmarshal", Kind: k, Err: err}
+}
+
+// Families of MessagePack formats, as read by decoder.header.
+const (
+	famNil = iota
+	famBool
+	famInt   // negative integer in header.i
+	famUint  // non-negative integer in header.u
+	famFloat // float in header.f
+	famStr
+	famBin
+	famArray
+	famMap
+	famExt
+)
+
+// header is a decoded format byte with its immediate value or length.
+type header struct {
+	fam  int
+	n    int     // length of str, bin and ext payloads; elements of arrays and maps
+	u    uint64  // famUint value, famBool as 0 or 1
+	i    int64   // famInt value
+	f    float64 // famFloat value
+	f32  bool    // famFloat was encoded as float32
+	code int8    // famExt type code
+}
+
+// decoder consumes MessagePack data from data[pos:].
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) read(n int) ([]byte, bool) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, false
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, true
+}
+
+// readBE reads an n-byte big-endian unsigned integer.
+func (d *decoder) readBE(n int) (uint64, bool) {
+	b, ok := d.read(n)
+	if !ok {
+		return 0, false
+	}
+	var x uint64
+	for _, c := range b {
+		x = x<<8 | uint64(c)
+	}
+	return x, true
+}
+
+// header reads the next format byte and the fixed-size fields after it.
+// The payload of str, bin and ext values and the elements of arrays and
+// maps are left for the caller.
+func (d *decoder) header() (header, bool) {
+	b, ok := d.read(1)
+	if !ok {
+		return header{}, false
+	}
+	c := b[0]
+	switch {
+	case c <= 0x7f:
+		return header{fam: famUint, u: uint64(c)}, true
+	case c >= 0xe0:
+		return header{fam: famInt, i: int64(int8(c))}, true
+	case c&0xe0 == 0xa0:
+		return d.sized(famStr, int(c&0x1f))
+	case c&0xf0 == 0x90:
+		return d.sized(famArray, int(c&0x0f))
+	case c&0xf0 == 0x80:
+		return d.sized(famMap, int(c&0x0f))
+	}
+
+	var h header
+	switch c {
+	case 0xc0:
+		return header{fam: famNil}, true
+	case 0xc2, 0xc3:
+		return header{fam: famBool, u: uint64(c - 0xc2)}, true
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		h.fam = famUint
+		h.u, ok = d.readBE(1 << (c - 0xcc))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		var u uint64
+		u, ok = d.readBE(size)
+		// Sign-extend from size bytes
+		shift := 64 - 8*size
+		h.i = int64(u<<shift) >> shift
+		h.fam = famInt
+		if h.i >= 0 {
+			h.fam, h.u = famUint, uint64(h.i)
+		}
+	case 0xca:
+		var u uint64
+		u, ok = d.readBE(4)
+		h.fam, h.f, h.f32 = famFloat, float64(math.Float32frombits(uint32(u))), true
+	case 0xcb:
+		var u uint64
+		u, ok = d.readBE(8)
+		h.fam, h.f = famFloat, math.Float64frombits(u)
+	case 0xd9, 0xda, 0xdb:
+		return d.length(famStr, 1<<(c-0xd9))
+	case 0xc4, 0xc5, 0xc6:
+		return d.length(famBin, 1<<(c-0xc4))
+	case 0xdc, 0xdd:
+		return d.length(famArray, 2<<(c-0xdc))
+	case 0xde, 0xdf:
+		return d.length(famMap, 2<<(c-0xde))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		h.fam, h.n = famExt, 1<<(c-0xd4)
+		h.code, ok = d.extCode()
+	case 0xc7, 0xc8, 0xc9:
+		if h, ok = d.length(famExt, 1<<(c-0xc7)); ok {
+			h.code, ok = d.extCode()
+		}
+	default:
+		return header{}, false // 0xc1 is never used
+	}
+	return h, ok
+}
+
+func (d *decoder) extCode() (int8, bool) {
+	b, ok := d.read(1)
+	if !ok {
+		return 0, false
+	}
+	return int8(b[0]), true
+}
+
+// length reads a size-byte length for fam and builds its header.
+func (d *decoder) length(fam, size int) (header, bool) {
+	n, ok := d.readBE(size)
+	if !ok || n > math.MaxInt32 {
+		return header{}, false
+	}
+	return d.sized(fam, int(n))
+}
+
+// sized builds the header of a value with n bytes or elements, rejecting
+// lengths that cannot fit in the remaining data before anything is
+// allocated: every element takes at least one byte.
+func (d *decoder) sized(fam, n int) (header, bool) {
+	need := n
+	if fam == famMap {
+		need = 2 * n
+	}
+	if need > len(d.data)-d.pos {
+		return header{}, false
+	}
+	return header{fam: fam, n: n}, true
+}
+
+// skip discards the rest of the value that starts with h.
+func (d *decoder) skip(h header, depth int) bool {
+	if depth > codec.MaxDepth {
+		return false
+	}
+	switch h.fam {
+	case famStr, famBin, famExt:
+		_, ok := d.read(h.n)
+		return ok
+	case famArray, famMap:
+		n := h.n
+		if h.fam == famMap {
+			n *= 2
+		}
+		for i := 0; i < n; i++ {
+			eh, ok := d.header()
+			if !ok || !d.skip(eh, depth+1) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// value decodes the next value into v.
+func (d *decoder) value(v tinyreflect.Value, depth int) error {
+	h, ok := d.header()
+	if !ok {
+		return decodeError(v.Kind(), ErrInvalidData)
+	}
+	return d.valueFrom(h, v, depth)
+}
+
+// valueFrom decodes the value that starts with h into v.
+func (d *decoder) valueFrom(h header, v tinyreflect.Value, depth int) error {
+	k := v.Kind()
+	if depth > codec.MaxDepth {
+		return decodeError(k, ErrMaxDepth)
+	}
+	if h.fam == famNil {
+		return v.SetZero()
+	}
+	if h.fam == famExt {
+		ext := extensionFor(v.Type())
+		if ext == nil && k != K.Pointer && k != K.Interface {
+			return decodeError(k, tinyreflect.ErrTypeMismatch)
+		}
+		if ext != nil {
+			data, ok := d.read(h.n)
+			if !ok {
+				return decodeError(k, ErrInvalidData)
+			}
+			if ext.Code != h.code {
+				return decodeError(k, tinyreflect.ErrTypeMismatch)
+			}
+			return ext.Decode(data, v)
+		}
+	}
+
+	switch k {
+	case K.Pointer:
+		if v.IsZero() {
+			if err := v.Set(tinyreflect.NewValue(v.Type().Elem())); err != nil {
+				return err
+			}
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return err
+		}
+		return d.valueFrom(h, elem, depth+1)
+
+	case K.Interface:
+		x, err := d.anyFrom(h, depth)
+		if err != nil {
+			return err
+		}
+		if x == nil {
+			return v.SetZero()
+		}
+		if err := v.Set(tinyreflect.ValueOf(x)); err != nil {
+			return decodeError(k, tinyreflect.ErrTypeMismatch)
+		}
+		return nil
+
+	case K.Bool:
+		if h.fam != famBool {
+			break
+		}
+		return v.SetBool(h.u == 1)
+
+	case K.Int, K.Int8, K.Int16, K.Int32, K.Int64:
+		i := h.i
+		switch {
+		case h.fam == famUint && h.u <= math.MaxInt64:
+			i = int64(h.u)
+		case h.fam != famInt:
+			if h.fam == famUint {
+				return decodeError(k, tinyreflect.ErrOverflow)
+			}
+			return decodeError(k, tinyreflect.ErrTypeMismatch)
+		}
+		if v.OverflowInt(i) {
+			return decodeError(k, tinyreflect.ErrOverflow)
+		}
+		return v.SetInt(i)
+
+	case K.Uint, K.Uint8, K.Uint16, K.Uint32, K.Uint64, K.Uintptr:
+		switch h.fam {
+		case famUint:
+			if v.OverflowUint(h.u) {
+				return decodeError(k, tinyreflect.ErrOverflow)
+			}
+			return v.SetUint(h.u)
+		case famInt:
+			return decodeError(k, tinyreflect.ErrOverflow)
+		}
+
+	case K.Float32, K.Float64:
+		f := h.f
+		switch h.fam {
+		case famFloat:
+		case famUint:
+			f = float64(h.u)
+		case famInt:
+			f = float64(h.i)
+		default:
+			return decodeError(k, tinyreflect.ErrTypeMismatch)
+		}
+		if v.OverflowFloat(f) {
+			return decodeError(k, tinyreflect.ErrOverflow)
+		}
+		return v.SetFloat(f)
+
+	case K.String:
+		if h.fam != famStr && h.fam != famBin {
+			break
+		}
+		b, ok := d.read(h.n)
+		if !ok {
+			return decodeError(k, ErrInvalidData)
+		}
+		return v.SetString(string(b))
+
+	case K.Slice:
+		if v.Type().Elem().Kind() == K.Uint8 && (h.fam == famBin || h.fam == famStr) {
+			b, ok := d.read(h.n)
+			if !ok {
+				return decodeError(k, ErrInvalidData)
+			}
+			return v.SetBytes(append([]byte{}, b...))
+		}
+		if h.fam != famArray {
+			break
+		}
+		return d.slice(h.n, v, depth)
+
+	case K.Array:
+		if h.fam != famArray {
+			break
+		}
+		return d.array(h.n, v, depth)
+
+	case K.Map:
+		if h.fam != famMap {
+			break
+		}
+		return d.mapValue(h.n, v, depth)
+
+	case K.Struct:
+		if h.fam != famMap {
+			break
+		}
+		return d.structValue(h.n, v, depth)
+
+	default:
+		return decodeError(k, tinyreflect.ErrUnsupportedKind)
+	}
+	return decodeError(k, tinyreflect.ErrTypeMismatch)
+}
+
+// slice decodes n array elements into the slice v, reusing its backing
+// array when it is large enough.
+func (d *decoder) slice(n int, v tinyreflect.Value, depth int) error {
+	if c, _ := v.Cap(); c >= n && !v.IsZero() {
+		if err := v.SetLen(n); err != nil {
+			return err
+		}
+	} else {
+		s, err := tinyreflect.MakeSlice(v.Type(), n, n)
+		if err != nil {
+			return err
+		}
+		if err := v.Set(s); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < n; i++ {
+		elem, err := v.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := elem.SetZero(); err != nil {
+			return err
+		}
+		if err := d.value(elem, depth+1); err != nil {
+			return codec.WithPath(err, codec.IndexSegment(i))
+		}
+	}
+	return nil
+}
+
+// array decodes n array elements into the Go array v. Extra elements
+// are skipped and missing ones set to zero.
+func (d *decoder) array(n int, v tinyreflect.Value, depth int) error {
+	size, _ := v.Len()
+	for i := 0; i < n; i++ {
+		if i >= size {
+			h, ok := d.header()
+			if !ok || !d.skip(h, depth+1) {
+				return decodeError(K.Array, ErrInvalidData)
+			}
+			continue
+		}
+		elem, err := v.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := d.value(elem, depth+1); err != nil {
+			return codec.WithPath(err, codec.IndexSegment(i))
+		}
+	}
+	for i := n; i < size; i++ {
+		elem, err := v.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := elem.SetZero(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapValue decodes n entries into the map v, allocating it if nil.
+func (d *decoder) mapValue(n int, v tinyreflect.Value, depth int) error {
+	typ := v.Type()
+	if v.IsZero() {
+		m, err := tinyreflect.MakeMapWithSize(typ, n)
+		if err != nil {
+			return err
+		}
+		if err := v.Set(m); err != nil {
+			return err
+		}
+	}
+	keyType, elemType := typ.Key(), typ.Elem()
+	for i := 0; i < n; i++ {
+		key, err := tinyreflect.NewValue(keyType).Elem()
+		if err != nil {
+			return err
+		}
+		if err := d.value(key, depth+1); err != nil {
+			return codec.WithPath(err, codec.IndexSegment(i))
+		}
+		elem, err := tinyreflect.NewValue(elemType).Elem()
+		if err != nil {
+			return err
+		}
+		if err := d.value(elem, depth+1); err != nil {
+			if key.Kind() == K.String {
+				return codec.WithPath(err, key.String())
+			}
+			return codec.WithPath(err, codec.IndexSegment(i))
+		}
+		if err := v.SetMapIndex(key, elem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structValue decodes n map entries into the fields of v, skipping keys
+// that match no field.
+func (d *decoder) structValue(n int, v tinyreflect.Value, depth int) error {
+	fields, err := cachedFields(v.Type())
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		h, ok := d.header()
+		if !ok || h.fam != famStr {
+			return decodeError(K.Struct, ErrInvalidData)
+		}
+		name, ok := d.read(h.n)
+		if !ok {
+			return decodeError(K.Struct, ErrInvalidData)
+		}
+		fi, found := fields.byName[string(name)]
+		if !found {
+			vh, ok := d.header()
+			if !ok || !d.skip(vh, depth+1) {
+				return decodeError(K.Struct, ErrInvalidData)
+			}
+			continue
+		}
+		f := &fields.list[fi]
+		fv, err := codec.FieldByIndex(v, f.index)
+		if err != nil {
+			return codec.WithPath(err, f.name)
+		}
+		if err := d.value(fv, depth+1); err != nil {
+			return codec.WithPath(err, f.name)
+		}
+	}
+	return nil
+}
+
+// anyFrom decodes the value that starts with h into nil, bool, int64
+// (uint64 above math.MaxInt64), float32, float64, string, []byte, []any,
+// map[string]any or a registered extension type.
+func (d *decoder) anyFrom(h header, depth int) (any, error) {
+	if depth > codec.MaxDepth {
+		return nil, decodeError(K.Interface, ErrMaxDepth)
+	}
+	switch h.fam {
+	case famNil:
+		return nil, nil
+	case famBool:
+		return h.u == 1, nil
+	case famInt:
+		return h.i, nil
+	case famUint:
+		if h.u > math.MaxInt64 {
+			return h.u, nil
+		}
+		return int64(h.u), nil
+	case famFloat:
+		if h.f32 {
+			return float32(h.f), nil
+		}
+		return h.f, nil
+
+	case famStr, famBin:
+		b, ok := d.read(h.n)
+		if !ok {
+			return nil, decodeError(K.Interface, ErrInvalidData)
+		}
+		if h.fam == famStr {
+			return string(b), nil
+		}
+		return append([]byte{}, b...), nil
+
+	case famArray:
+		list := make([]any, h.n)
+		for i := range list {
+			eh, ok := d.header()
+			if !ok {
+				return nil, decodeError(K.Interface, ErrInvalidData)
+			}
+			x, err := d.anyFrom(eh, depth+1)
+			if err != nil {
+				return nil, codec.WithPath(err, codec.IndexSegment(i))
+			}
+			list[i] = x
+		}
+		return list, nil
+
+	case famMap:
+		m := make(map[string]any, h.n)
+		for i := 0; i < h.n; i++ {
+			kh, ok := d.header()
+			if !ok {
+				return nil, decodeError(K.Interface, ErrInvalidData)
+			}
+			if kh.fam != famStr {
+				return nil, decodeError(K.Map, tinyreflect.ErrTypeMismatch)
+			}
+			kb, ok := d.read(kh.n)
+			if !ok {
+				return nil, decodeError(K.Interface, ErrInvalidData)
+			}
+			key := string(kb)
+			eh, ok := d.header()
+			if !ok {
+				return nil, decodeError(K.Interface, ErrInvalidData)
+			}
+			x, err := d.anyFrom(eh, depth+1)
+			if err != nil {
+				return nil, codec.WithPath(err, key)
+			}
+			m[key] = x
+		}
+		return m, nil
+
+	case famExt:
+		data, ok := d.read(h.n)
+		if !ok {
+			return nil, decodeError(K.Interface, ErrInvalidData)
+		}
+		t := extensionType(h.code)
+		if t == nil {
+			return nil, decodeError(K.Interface, tinyreflect.ErrUnsupportedKind)
+		}
+		ext := extensionFor(t)
+		elem, err := tinyreflect.NewValue(t).Elem()
+		if err != nil {
+			return nil, err
+		}
+		if err := ext.Decode(data, elem); err != nil {
+			return nil, err
+		}
+		return elem.Interface()
+	}
+	return nil, decodeError(K.Interface, ErrInvalidData)
+}
@@ -0,0 +1,288 @@
+package msgpack
+
+import (
+	"math"
+	"slices"
+	"unsafe"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	. "github.com/cdvelop/tinystring"
+)
+
+func encodeError(k Kind, err error) error {
+	return &tinyreflect.ValueError{Method: "msgpack.Marshal", Kind: k, Err: err}
+}
+
+// encodeValue appends the encoding of v to dst. The zero Value is nil.
+func encodeValue(dst []byte, v tinyreflect.Value, depth int) ([]byte, error) {
+	if depth > codec.MaxDepth {
+		return dst, encodeError(v.Kind(), ErrMaxDepth)
+	}
+	if v.Kind() != K.Invalid {
+		if ext := extensionFor(v.Type()); ext != nil {
+			data, err := ext.Encode(v)
+			if err != nil {
+				return dst, err
+			}
+			return appendExt(dst, ext.Code, data), nil
+		}
+	}
+
+	switch k := v.Kind(); k {
+	case K.Invalid:
+		return append(dst, 0xc0), nil
+
+	case K.Bool:
+		if b, _ := v.Bool(); b {
+			return append(dst, 0xc3), nil
+		}
+		return append(dst, 0xc2), nil
+
+	case K.Int, K.Int8, K.Int16, K.Int32, K.Int64:
+		i, _ := v.Int()
+		return appendInt(dst, i), nil
+
+	case K.Uint, K.Uint8, K.Uint16, K.Uint32, K.Uint64, K.Uintptr:
+		u, _ := v.Uint()
+		return appendUint(dst, u), nil
+
+	case K.Float32:
+		f, _ := v.Float()
+		return appendBE(append(dst, 0xca), uint64(math.Float32bits(float32(f))), 4), nil
+
+	case K.Float64:
+		f, _ := v.Float()
+		return appendBE(append(dst, 0xcb), math.Float64bits(f), 8), nil
+
+	case K.String:
+		s := v.String()
+		return append(appendStrHeader(dst, len(s)), s...), nil
+
+	case K.Slice:
+		if v.IsZero() {
+			return append(dst, 0xc0), nil
+		}
+		n, _ := v.Len()
+		if v.Type().Elem().Kind() == K.Uint8 {
+			p, _ := v.UnsafePointer()
+			return append(appendBinHeader(dst, n), unsafe.Slice((*byte)(p), n)...), nil
+		}
+		return encodeElems(dst, v, n, depth)
+
+	case K.Array:
+		n, _ := v.Len()
+		return encodeElems(dst, v, n, depth)
+
+	case K.Map:
+		if v.IsZero() {
+			return append(dst, 0xc0), nil
+		}
+		return encodeMap(dst, v, depth)
+
+	case K.Struct:
+		return encodeStruct(dst, v, depth)
+
+	case K.Pointer, K.Interface:
+		if v.IsZero() {
+			return append(dst, 0xc0), nil
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return dst, err
+		}
+		return encodeValue(dst, elem, depth+1)
+	}
+	return dst, encodeError(v.Kind(), tinyreflect.ErrUnsupportedKind)
+}
+
+// appendBE appends the low n bytes of x, most significant first.
+func appendBE(dst []byte, x uint64, n int) []byte {
+	for i := n - 1; i >= 0; i-- {
+		dst = append(dst, byte(x>>(8*i)))
+	}
+	return dst
+}
+
+// appendInt appends i in the shortest format; non-negative values use the
+// unsigned formats.
+func appendInt(dst []byte, i int64) []byte {
+	switch {
+	case i >= 0:
+		return appendUint(dst, uint64(i))
+	case i >= -32:
+		return append(dst, byte(i)) // negative fixint
+	case i >= math.MinInt8:
+		return append(dst, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return appendBE(append(dst, 0xd1), uint64(i), 2)
+	case i >= math.MinInt32:
+		return appendBE(append(dst, 0xd2), uint64(i), 4)
+	}
+	return appendBE(append(dst, 0xd3), uint64(i), 8)
+}
+
+// appendUint appends u in the shortest format.
+func appendUint(dst []byte, u uint64) []byte {
+	switch {
+	case u <= 0x7f:
+		return append(dst, byte(u)) // positive fixint
+	case u <= math.MaxUint8:
+		return append(dst, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		return appendBE(append(dst, 0xcd), u, 2)
+	case u <= math.MaxUint32:
+		return appendBE(append(dst, 0xce), u, 4)
+	}
+	return appendBE(append(dst, 0xcf), u, 8)
+}
+
+func appendStrHeader(dst []byte, n int) []byte {
+	switch {
+	case n < 32:
+		return append(dst, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		return append(dst, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		return appendBE(append(dst, 0xda), uint64(n), 2)
+	}
+	return appendBE(append(dst, 0xdb), uint64(n), 4)
+}
+
+func appendBinHeader(dst []byte, n int) []byte {
+	switch {
+	case n <= math.MaxUint8:
+		return append(dst, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		return appendBE(append(dst, 0xc5), uint64(n), 2)
+	}
+	return appendBE(append(dst, 0xc6), uint64(n), 4)
+}
+
+func appendArrayHeader(dst []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(dst, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return appendBE(append(dst, 0xdc), uint64(n), 2)
+	}
+	return appendBE(append(dst, 0xdd), uint64(n), 4)
+}
+
+func appendMapHeader(dst []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(dst, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return appendBE(append(dst, 0xde), uint64(n), 2)
+	}
+	return appendBE(append(dst, 0xdf), uint64(n), 4)
+}
+
+// appendExt appends an ext value, using fixext when the payload size allows.
+func appendExt(dst []byte, code int8, data []byte) []byte {
+	switch n := len(data); {
+	case n == 1:
+		dst = append(dst, 0xd4)
+	case n == 2:
+		dst = append(dst, 0xd5)
+	case n == 4:
+		dst = append(dst, 0xd6)
+	case n == 8:
+		dst = append(dst, 0xd7)
+	case n == 16:
+		dst = append(dst, 0xd8)
+	case n <= math.MaxUint8:
+		dst = append(dst, 0xc7, byte(n))
+	case n <= math.MaxUint16:
+		dst = appendBE(append(dst, 0xc8), uint64(n), 2)
+	default:
+		dst = appendBE(append(dst, 0xc9), uint64(n), 4)
+	}
+	return append(append(dst, byte(code)), data...)
+}
+
+// encodeElems appends the first n elements of the slice or array v.
+func encodeElems(dst []byte, v tinyreflect.Value, n, depth int) ([]byte, error) {
+	dst = appendArrayHeader(dst, n)
+	for i := 0; i < n; i++ {
+		elem, err := v.Index(i)
+		if err != nil {
+			return dst, err
+		}
+		if dst, err = encodeValue(dst, elem, depth+1); err != nil {
+			return dst, codec.WithPath(err, codec.IndexSegment(i))
+		}
+	}
+	return dst, nil
+}
+
+// encodeStruct appends v as a map from field names to values.
+func encodeStruct(dst []byte, v tinyreflect.Value, depth int) ([]byte, error) {
+	fields, err := cachedFields(v.Type())
+	if err != nil {
+		return dst, err
+	}
+	values := make([]tinyreflect.Value, len(fields.list))
+	n := 0
+	for i := range fields.list {
+		f := &fields.list[i]
+		fv, err := codec.FieldByIndex(v, f.index)
+		if err != nil {
+			return dst, codec.WithPath(err, f.name)
+		}
+		if f.omitEmpty && codec.IsEmpty(fv) {
+			continue
+		}
+		values[i] = fv
+		n++
+	}
+
+	dst = appendMapHeader(dst, n)
+	for i := range fields.list {
+		if values[i].Kind() == K.Invalid {
+			continue
+		}
+		f := &fields.list[i]
+		dst = append(appendStrHeader(dst, len(f.name)), f.name...)
+		if dst, err = encodeValue(dst, values[i], depth+1); err != nil {
+			return dst, codec.WithPath(err, f.name)
+		}
+	}
+	return dst, nil
+}
+
+// mapEntry is a map entry with its key already encoded.
+type mapEntry struct {
+	key []byte
+	val tinyreflect.Value
+}
+
+// encodeMap appends the entries of v sorted by their encoded keys.
+func encodeMap(dst []byte, v tinyreflect.Value, depth int) ([]byte, error) {
+	n, _ := v.Len()
+	iter, err := v.MapRange()
+	if err != nil {
+		return dst, err
+	}
+	entries := make([]mapEntry, 0, n)
+	for iter.Next() {
+		key, err := encodeValue(nil, iter.Key(), depth+1)
+		if err != nil {
+			return dst, err
+		}
+		entries = append(entries, mapEntry{key, iter.Value()})
+	}
+	slices.SortFunc(entries, func(a, b mapEntry) int {
+		return slices.Compare(a.key, b.key)
+	})
+
+	dst = appendMapHeader(dst, len(entries))
+	for i, e := range entries {
+		dst = append(dst, e.key...)
+		if dst, err = encodeValue(dst, e.val, depth+1); err != nil {
+			return dst, codec.WithPath(err, codec.IndexSegment(i))
+		}
+	}
+	return dst, nil
+}
@@ -0,0 +1,51 @@
+package msgpack
+
+import (
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/cache"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+)
+
+// field describes how one struct field is encoded.
+type field struct {
+	name      string // map key
+	index     []int  // field index, through embedded structs when promoted
+	omitEmpty bool
+}
+
+// structFields lists the encoded fields of a struct type in field order.
+type structFields struct {
+	list   []field
+	byName map[string]int
+}
+
+// fieldCache keeps the parsed fields of each struct type.
+var fieldCache cache.Cache[*tinyreflect.Type, *structFields]
+
+// cachedFields returns the encoded fields of the struct type t.
+func cachedFields(t *tinyreflect.Type) (*structFields, error) {
+	if sf, ok := fieldCache.Load(t); ok {
+		return sf, nil
+	}
+
+	all, err := codec.Fields(t, "msgpack")
+	if err != nil {
+		return nil, err
+	}
+	sf := &structFields{byName: make(map[string]int, len(all))}
+	for _, cf := range all {
+		f := field{name: cf.Name, index: cf.Index, omitEmpty: cf.Opts.Has("omitempty")}
+		// A field hides promoted fields of the same name further down
+		if i, ok := sf.byName[f.name]; ok {
+			if len(sf.list[i].index) <= len(f.index) {
+				continue
+			}
+			sf.list[i] = f
+			continue
+		}
+		sf.byName[f.name] = len(sf.list)
+		sf.list = append(sf.list, f)
+	}
+	fieldCache.Store(t, sf)
+	return sf, nil
+}
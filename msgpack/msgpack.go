@@ -0,0 +1,130 @@
+// Package msgpack encodes and decodes MessagePack through tinyreflect,
+// without pulling the reflect package into TinyGo and WebAssembly builds.
+//
+// Values are written in the shortest format that holds them: integers
+// use fixint, int8..int64 or uint8..uint64 depending on their value,
+// strings use fixstr or str8..str32, []byte uses bin8..bin32, and nil
+// pointers, slices and maps are written as nil. Structs are maps keyed
+// by field name, set by the `msgpack` tag:
+//
+//	type User struct {
+//		ID    int64  `msgpack:"id"`
+//		Email string `msgpack:"email,omitempty"` // skipped when empty
+//		Token string `msgpack:"-"`               // never written
+//	}
+//
+// Map entries are sorted by their encoded key, so equal values always
+// give the same bytes. Custom types are written as MessagePack ext
+// values through RegisterExtension.
+package msgpack
+
+import (
+	"sync"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	. "github.com/cdvelop/tinystring"
+)
+
+// Errors returned by the codec, wrapped in a *tinyreflect.ValueError with
+// the path of the value that failed.
+var (
+	// ErrInvalidData is returned for truncated input, unknown format bytes and trailing data.
+	ErrInvalidData = codec.ErrInvalidData
+	// ErrMaxDepth is returned for values nested too deeply, usually through
+	// a pointer cycle. All the codec packages share this value.
+	ErrMaxDepth = codec.ErrMaxDepth
+)
+
+// Extension converts values of a custom type to and from the payload of
+// a MessagePack ext value.
+type Extension struct {
+	// Code is the ext type code; negative codes are reserved by the spec.
+	Code int8
+	// Encode returns the payload for v, a value of the registered type.
+	Encode func(v tinyreflect.Value) ([]byte, error)
+	// Decode stores the value held in data into v, a settable value of
+	// the registered type.
+	Decode func(data []byte, v tinyreflect.Value) error
+}
+
+// extensions holds the registered extensions by type and by code.
+var extensions struct {
+	sync.RWMutex
+	byType map[*tinyreflect.Type]*Extension
+	byCode map[int8]*tinyreflect.Type
+}
+
+// RegisterExtension makes values of typ encode as ext values with
+// ext.Code, and ext values with that code decode into typ, including when
+// the target is an interface. Registering a type again replaces its
+// extension. It returns ErrInvalidArgument for a nil type or function or
+// a negative code.
+func RegisterExtension(typ *tinyreflect.Type, ext Extension) error {
+	if typ == nil || ext.Encode == nil || ext.Decode == nil || ext.Code < 0 {
+		return &tinyreflect.ValueError{Method: "msgpack.RegisterExtension", Err: tinyreflect.ErrInvalidArgument}
+	}
+	extensions.Lock()
+	defer extensions.Unlock()
+	if extensions.byType == nil {
+		extensions.byType = make(map[*tinyreflect.Type]*Extension)
+		extensions.byCode = make(map[int8]*tinyreflect.Type)
+	}
+	if old := extensions.byType[typ]; old != nil {
+		delete(extensions.byCode, old.Code)
+	}
+	extensions.byType[typ] = &ext
+	extensions.byCode[ext.Code] = typ
+	return nil
+}
+
+// extensionFor returns the extension registered for t, or nil.
+func extensionFor(t *tinyreflect.Type) *Extension {
+	extensions.RLock()
+	ext := extensions.byType[t]
+	extensions.RUnlock()
+	return ext
+}
+
+// extensionType returns the type registered for code, or nil.
+func extensionType(code int8) *tinyreflect.Type {
+	extensions.RLock()
+	t := extensions.byCode[code]
+	extensions.RUnlock()
+	return t
+}
+
+// Marshal returns the MessagePack encoding of v.
+func Marshal(v any) ([]byte, error) {
+	return Append(nil, v)
+}
+
+// Append appends the MessagePack encoding of v to dst and returns the
+// extended buffer.
+func Append(dst []byte, v any) ([]byte, error) {
+	return encodeValue(dst, tinyreflect.ValueOf(v), 0)
+}
+
+// Unmarshal decodes the MessagePack value in data into the value pointed
+// to by v, which must be a non-nil pointer. Nil pointers, maps and slices
+// are allocated as needed; map keys that match no struct field are
+// skipped. A value that does not fit its target returns
+// ErrTypeMismatch or ErrOverflow.
+func Unmarshal(data []byte, v any) error {
+	rv := tinyreflect.ValueOf(v)
+	if rv.Kind() != K.Pointer || rv.IsZero() {
+		return &tinyreflect.ValueError{Method: "msgpack.Unmarshal", Kind: rv.Kind(), Err: tinyreflect.ErrInvalidArgument}
+	}
+	elem, err := rv.Elem()
+	if err != nil {
+		return err
+	}
+	d := decoder{data: data}
+	if err := d.value(elem, 0); err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return decodeError(elem.Kind(), ErrInvalidData)
+	}
+	return nil
+}
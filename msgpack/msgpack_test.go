@@ -0,0 +1,234 @@
+package msgpack_test
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/msgpack"
+)
+
+type Scalars struct {
+	B   bool    `msgpack:"b"`
+	I   int     `msgpack:"i"`
+	I8  int8    `msgpack:"i8"`
+	I16 int16   `msgpack:"i16"`
+	I32 int32   `msgpack:"i32"`
+	I64 int64   `msgpack:"i64"`
+	U   uint    `msgpack:"u"`
+	U8  uint8   `msgpack:"u8"`
+	U16 uint16  `msgpack:"u16"`
+	U32 uint32  `msgpack:"u32"`
+	U64 uint64  `msgpack:"u64"`
+	F32 float32 `msgpack:"f32"`
+	F64 float64 `msgpack:"f64"`
+	S   string  `msgpack:"s"`
+}
+
+type Meta struct {
+	Version int `msgpack:"version"`
+}
+
+type Point struct {
+	X, Y int16
+}
+
+type Collections struct {
+	Meta
+	Names   []string          `msgpack:"names"`
+	Raw     []byte            `msgpack:"raw"`
+	Vec     [3]float32        `msgpack:"vec"`
+	Counts  map[string]int    `msgpack:"counts"`
+	Flags   []map[string]bool `msgpack:"flags"`
+	Origin  Point             `msgpack:"origin"`
+	Next    *Point            `msgpack:"next"`
+	Missing *Point            `msgpack:"missing"`
+	NilMap  map[int]string    `msgpack:"nil_map"`
+	NilList []uint16          `msgpack:"nil_list"`
+	Note    string            `msgpack:"note,omitempty"`
+	Opt     *int              `msgpack:"opt,omitempty"`
+	Secret  string            `msgpack:"-"`
+	private int
+}
+
+// Celsius is written as a 2-byte ext value holding tenths of a degree.
+type Celsius float64
+
+type Reading struct {
+	Sensor string   `msgpack:"sensor"`
+	Temp   Celsius  `msgpack:"temp"`
+	Prev   *Celsius `msgpack:"prev"`
+	Any    any      `msgpack:"any"`
+}
+
+func init() {
+	err := msgpack.RegisterExtension(tinyreflect.TypeOf(Celsius(0)), msgpack.Extension{
+		Code: 7,
+		Encode: func(v tinyreflect.Value) ([]byte, error) {
+			f, _ := v.Float()
+			t := int16(math.Round(f * 10))
+			return []byte{byte(t >> 8), byte(t)}, nil
+		},
+		Decode: func(data []byte, v tinyreflect.Value) error {
+			if len(data) != 2 {
+				return msgpack.ErrInvalidData
+			}
+			return v.SetFloat(float64(int16(data[0])<<8|int16(data[1])) / 10)
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// fixtures pairs each file in testdata with the value it encodes. The
+// files were produced by an independent encoder following the spec.
+func fixtures() map[string]any {
+	prev := Celsius(-4.5)
+	return map[string]any{
+		"nil":  (*Scalars)(nil),
+		"true": true,
+		"scalars_small": Scalars{
+			B: true, I: 1, I8: -1, I16: -32, I32: 127, I64: -33,
+			U: 0, U8: 128, U16: 255, U32: 256, U64: 65535,
+			F32: 1.5, F64: -0.25, S: "hi",
+		},
+		"scalars_min": Scalars{
+			I: math.MinInt64, I8: math.MinInt8, I16: math.MinInt16, I32: math.MinInt32, I64: math.MinInt64,
+			F32: -math.MaxFloat32, F64: -math.MaxFloat64,
+		},
+		"scalars_max": Scalars{
+			I: math.MaxInt64, I8: math.MaxInt8, I16: math.MaxInt16, I32: math.MaxInt32, I64: math.MaxInt64,
+			U: math.MaxUint64, U8: math.MaxUint8, U16: math.MaxUint16, U32: math.MaxUint32, U64: math.MaxUint64,
+			F32: math.MaxFloat32, F64: math.MaxFloat64, S: strings.Repeat("x", 32),
+		},
+		"long_string": strings.Repeat("ñ", 200),
+		"collections": Collections{
+			Meta:   Meta{Version: 2},
+			Names:  []string{"a", "", "ü"},
+			Raw:    []byte{0, 1, 254, 255},
+			Vec:    [3]float32{1, -2, 0.5},
+			Counts: map[string]int{"b": 300, "a": -1, "aa": 0},
+			Flags:  []map[string]bool{{"on": true}, {}, nil},
+			Origin: Point{-1, 1},
+			Next:   &Point{X: 1000},
+		},
+		"ext":       Reading{Sensor: "t1", Temp: 21.5, Prev: &prev, Any: Celsius(0.1)},
+		"int_keys":  map[int8][]int64{-1: {1 << 40}, 5: {}, 0: nil},
+		"long_list": make([]bool, 20),
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	for name, value := range fixtures() {
+		t.Run(name, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join("testdata", name+".msgpack"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := msgpack.Marshal(value)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if string(got) != string(want) {
+				t.Fatalf("Marshal:\ngot  % x\nwant % x", got, want)
+			}
+
+			// Decode into a new value of the same type and compare
+			ptr := reflect.New(reflect.TypeOf(value))
+			if err := msgpack.Unmarshal(want, ptr.Interface()); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			decoded := ptr.Elem().Interface()
+			again, err := msgpack.Marshal(decoded)
+			if err != nil {
+				t.Fatalf("Marshal decoded: %v", err)
+			}
+			if string(again) != string(want) {
+				t.Errorf("round trip:\ngot  % x\nwant % x", again, want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalValues(t *testing.T) {
+	data, _ := os.ReadFile(filepath.Join("testdata", "collections.msgpack"))
+	var got Collections
+	got.NilMap = map[int]string{1: "x"}
+	got.private = 3
+	if err := msgpack.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	want := fixtures()["collections"].(Collections)
+	want.private = 3
+	want.Flags[1] = map[string]bool{}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+
+	data, _ = os.ReadFile(filepath.Join("testdata", "ext.msgpack"))
+	var r Reading
+	if err := msgpack.Unmarshal(data, &r); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if r.Temp != 21.5 || r.Prev == nil || *r.Prev != -4.5 || r.Any != Celsius(0.1) {
+		t.Errorf("got %+v", r)
+	}
+
+	var dyn any
+	if err := msgpack.Unmarshal(data, &dyn); err != nil {
+		t.Fatalf("Unmarshal into any: %v", err)
+	}
+	m, _ := dyn.(map[string]any)
+	if m["sensor"] != "t1" || m["temp"] != Celsius(21.5) {
+		t.Errorf("got %#v", dyn)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var s Scalars
+	testCases := []struct {
+		name    string
+		data    []byte
+		target  any
+		wantErr error
+		path    string
+	}{
+		{"Int8 overflow", []byte{0x81, 0xa2, 'i', '8', 0xcc, 0x80}, &s, tinyreflect.ErrOverflow, "i8"},
+		{"Negative uint", []byte{0x81, 0xa1, 'u', 0xff}, &s, tinyreflect.ErrOverflow, "u"},
+		{"Float32 overflow", []byte{0x81, 0xa3, 'f', '3', '2', 0xcb, 0x7f, 0xef, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, &s, tinyreflect.ErrOverflow, "f32"},
+		{"String into bool", []byte{0x81, 0xa1, 'b', 0xa1, 'x'}, &s, tinyreflect.ErrTypeMismatch, "b"},
+		{"Truncated", []byte{0x81, 0xa1, 's', 0xa3, 'x'}, &s, msgpack.ErrInvalidData, "s"},
+		{"Trailing data", []byte{0xc3, 0xc3}, new(bool), msgpack.ErrInvalidData, ""},
+		{"Reserved byte", []byte{0xc1}, new(bool), msgpack.ErrInvalidData, ""},
+		{"Huge array", []byte{0xdd, 0xff, 0xff, 0xff, 0xff}, new([]int), msgpack.ErrInvalidData, ""},
+		{"Wrong ext code", []byte{0xd5, 0x08, 0x00, 0x01}, new(Celsius), tinyreflect.ErrTypeMismatch, ""},
+		{"Ext into int", []byte{0xd5, 0x07, 0x00, 0x01}, new(int), tinyreflect.ErrTypeMismatch, ""},
+		{"Nested path", []byte{0x81, 0xa5, 'n', 'a', 'm', 'e', 's', 0x92, 0xa1, 'a', 0x01}, &Collections{}, tinyreflect.ErrTypeMismatch, "names[1]"},
+		{"Non-pointer", []byte{0xc3}, true, tinyreflect.ErrInvalidArgument, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := msgpack.Unmarshal(tc.data, tc.target)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got %v, want %v", err, tc.wantErr)
+			}
+			var ve *tinyreflect.ValueError
+			if errors.As(err, &ve) && ve.Path != tc.path {
+				t.Errorf("got path %q, want %q", ve.Path, tc.path)
+			}
+		})
+	}
+
+	if err := msgpack.RegisterExtension(tinyreflect.TypeOf(0), msgpack.Extension{Code: -1}); !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+		t.Errorf("RegisterExtension: got %v, want ErrInvalidArgument", err)
+	}
+	if _, err := msgpack.Marshal(func() {}); !errors.Is(err, tinyreflect.ErrUnsupportedKind) {
+		t.Errorf("Marshal(func): got %v, want ErrUnsupportedKind", err)
+	}
+}
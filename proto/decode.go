@@ -0,0 +1,303 @@
+package proto
+
+import (
+	"math"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	. "github.com/cdvelop/tinystring"
+)
+
+func decodeError(k Kind, err error) error {
+	return &tinyreflect.ValueError{Method: "proto.Unmarshal", Kind: k, Err: err}
+}
+
+// decoder consumes one message from data[pos:].
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) uvarint() (uint64, bool) {
+	var x uint64
+	for i := 0; i < maxVarintLen64 && d.pos < len(d.data); i++ {
+		b := d.data[d.pos]
+		d.pos++
+		if i == maxVarintLen64-1 && b > 1 {
+			return 0, false // overflows 64 bits
+		}
+		x |= uint64(b&0x7f) << (7 * i)
+		if b < 0x80 {
+			return x, true
+		}
+	}
+	return 0, false
+}
+
+// item reads a value of wire type wt: varint and fixed values in u,
+// length-delimited ones in b, which aliases the input.
+func (d *decoder) item(wt uint64) (u uint64, b []byte, ok bool) {
+	switch wt {
+	case wireVarint:
+		u, ok = d.uvarint()
+		return u, nil, ok
+	case wireFixed32, wireFixed64:
+		n := 4
+		if wt == wireFixed64 {
+			n = 8
+		}
+		if len(d.data)-d.pos < n {
+			return 0, nil, false
+		}
+		for i := n - 1; i >= 0; i-- {
+			u = u<<8 | uint64(d.data[d.pos+i])
+		}
+		d.pos += n
+		return u, nil, true
+	case wireBytes:
+		n, ok := d.uvarint()
+		if !ok || n > uint64(len(d.data)-d.pos) {
+			return 0, nil, false
+		}
+		b = d.data[d.pos : d.pos+int(n)]
+		d.pos += int(n)
+		return 0, b, true
+	}
+	return 0, nil, false // groups and reserved wire types
+}
+
+// tag reads a field key and splits it into field number and wire type.
+func (d *decoder) tag() (num, wt uint64, ok bool) {
+	key, ok := d.uvarint()
+	if !ok || key>>3 == 0 {
+		return 0, 0, false
+	}
+	return key >> 3, key & 7, true
+}
+
+// decodeMessage decodes the fields in data into the struct v. Fields
+// already set are kept unless data sets them: scalars are replaced,
+// repeated fields and maps grow, and messages are merged.
+func decodeMessage(data []byte, v tinyreflect.Value, depth int) error {
+	if depth > codec.MaxDepth {
+		return decodeError(K.Struct, ErrMaxDepth)
+	}
+	msg, err := cachedMessage(v.Type())
+	if err != nil {
+		return err
+	}
+	d := decoder{data: data}
+	for d.pos < len(d.data) {
+		num, wt, ok := d.tag()
+		if !ok {
+			return decodeError(K.Struct, ErrInvalidData)
+		}
+		fi, found := msg.byNum[num]
+		if !found {
+			if _, _, ok := d.item(wt); !ok {
+				return decodeError(K.Struct, ErrInvalidData)
+			}
+			continue
+		}
+		f := &msg.fields[fi]
+		fv, err := v.Field(f.index)
+		if err != nil {
+			return codec.WithPath(err, f.name)
+		}
+		if err := d.field(f, wt, fv, depth); err != nil {
+			return codec.WithPath(err, f.name)
+		}
+	}
+	return nil
+}
+
+// field decodes one record of the field f with wire type wt into v.
+func (d *decoder) field(f *field, wt uint64, v tinyreflect.Value, depth int) error {
+	want := f.enc.wireType()
+	if f.shape == shapeMap {
+		want = wireBytes
+	}
+	packed := f.shape == shapeRepeated && wt == wireBytes && f.enc.packable()
+	if wt != want && !packed {
+		if wt == 3 || wt == 4 || wt > 5 {
+			return decodeError(v.Kind(), ErrInvalidData)
+		}
+		return decodeError(v.Kind(), tinyreflect.ErrTypeMismatch)
+	}
+	u, b, ok := d.item(wt)
+	if !ok {
+		return decodeError(v.Kind(), ErrInvalidData)
+	}
+
+	switch {
+	case f.shape == shapeSingular:
+		return setValue(v, f.enc, u, b, depth)
+
+	case f.shape == shapeMap:
+		return decodeEntry(v, f, b, depth)
+
+	case packed:
+		n, _ := v.Len()
+		elems := decoder{data: b}
+		for i := n; elems.pos < len(b); i++ {
+			u, _, ok := elems.item(want)
+			if !ok {
+				return codec.WithPath(decodeError(v.Kind(), ErrInvalidData), codec.IndexSegment(i))
+			}
+			elem, err := appendElem(v)
+			if err != nil {
+				return err
+			}
+			if err := setValue(elem, f.enc, u, nil, depth); err != nil {
+				return codec.WithPath(err, codec.IndexSegment(i))
+			}
+		}
+		return nil
+	}
+
+	n, _ := v.Len()
+	elem, err := appendElem(v)
+	if err != nil {
+		return err
+	}
+	if err := setValue(elem, f.enc, u, b, depth); err != nil {
+		return codec.WithPath(err, codec.IndexSegment(n))
+	}
+	return nil
+}
+
+// appendElem grows the slice v by one zero element and returns it.
+func appendElem(v tinyreflect.Value) (tinyreflect.Value, error) {
+	n, _ := v.Len()
+	if c, _ := v.Cap(); n >= c {
+		grown, err := tinyreflect.MakeSlice(v.Type(), n, max(4, c*2))
+		if err != nil {
+			return tinyreflect.Value{}, err
+		}
+		if _, err := tinyreflect.Copy(grown, v); err != nil {
+			return tinyreflect.Value{}, err
+		}
+		if err := v.Set(grown); err != nil {
+			return tinyreflect.Value{}, err
+		}
+	}
+	if err := v.SetLen(n + 1); err != nil {
+		return tinyreflect.Value{}, err
+	}
+	elem, err := v.Index(n)
+	if err != nil {
+		return tinyreflect.Value{}, err
+	}
+	return elem, elem.SetZero()
+}
+
+// decodeEntry decodes the entry message b and stores it in the map v,
+// allocating the map if nil. A missing key or value is the zero value.
+func decodeEntry(v tinyreflect.Value, f *field, b []byte, depth int) error {
+	typ := v.Type()
+	if v.IsZero() {
+		m, err := tinyreflect.MakeMap(typ)
+		if err != nil {
+			return err
+		}
+		if err := v.Set(m); err != nil {
+			return err
+		}
+	}
+	key, err := tinyreflect.NewValue(typ.Key()).Elem()
+	if err != nil {
+		return err
+	}
+	elem, err := tinyreflect.NewValue(typ.Elem()).Elem()
+	if err != nil {
+		return err
+	}
+
+	d := decoder{data: b}
+	for d.pos < len(b) {
+		num, wt, ok := d.tag()
+		if !ok {
+			return decodeError(K.Map, ErrInvalidData)
+		}
+		target, enc := key, f.keyEnc
+		switch num {
+		case 1:
+		case 2:
+			target, enc = elem, f.enc
+		default:
+			if _, _, ok := d.item(wt); !ok {
+				return decodeError(K.Map, ErrInvalidData)
+			}
+			continue
+		}
+		if wt != enc.wireType() {
+			return decodeError(target.Kind(), tinyreflect.ErrTypeMismatch)
+		}
+		u, data, ok := d.item(wt)
+		if !ok {
+			return decodeError(K.Map, ErrInvalidData)
+		}
+		if err := setValue(target, enc, u, data, depth); err != nil {
+			if num == 2 && key.Kind() == K.String {
+				return codec.WithPath(err, key.String())
+			}
+			return err
+		}
+	}
+	return v.SetMapIndex(key, elem)
+}
+
+// setValue stores a decoded value in v, allocating v first when it is a
+// nil pointer. Integers are checked against the width of v.
+func setValue(v tinyreflect.Value, enc encoding, u uint64, b []byte, depth int) error {
+	if v.Kind() == K.Pointer {
+		if v.IsZero() {
+			if err := v.Set(tinyreflect.NewValue(v.Type().Elem())); err != nil {
+				return err
+			}
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return err
+		}
+		v = elem
+	}
+
+	switch k := v.Kind(); {
+	case enc == encMessage:
+		return decodeMessage(b, v, depth+1)
+
+	case enc == encBytes:
+		if k == K.String {
+			return v.SetString(string(b))
+		}
+		return v.SetBytes(append([]byte{}, b...))
+
+	case k == K.Bool:
+		return v.SetBool(u != 0)
+
+	case k == K.Float32:
+		return v.SetFloat(float64(math.Float32frombits(uint32(u))))
+
+	case k == K.Float64:
+		return v.SetFloat(math.Float64frombits(u))
+
+	case k == K.Int || k == K.Int8 || k == K.Int16 || k == K.Int32 || k == K.Int64:
+		i := int64(u)
+		switch enc {
+		case encZigzag:
+			i = unzigzag(u)
+		case encFixed32:
+			i = int64(int32(uint32(u)))
+		}
+		if v.OverflowInt(i) {
+			return decodeError(k, tinyreflect.ErrOverflow)
+		}
+		return v.SetInt(i)
+	}
+
+	if v.OverflowUint(u) {
+		return decodeError(v.Kind(), tinyreflect.ErrOverflow)
+	}
+	return v.SetUint(u)
+}
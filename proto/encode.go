@@ -0,0 +1,249 @@
+package proto
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"unsafe"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	. "github.com/cdvelop/tinystring"
+)
+
+func encodeError(k Kind, err error) error {
+	return &tinyreflect.ValueError{Method: "proto.Marshal", Kind: k, Err: err}
+}
+
+// encodeMessage appends the fields of the struct v in field-number order.
+func encodeMessage(dst []byte, v tinyreflect.Value, depth int) ([]byte, error) {
+	if depth > codec.MaxDepth {
+		return dst, encodeError(K.Struct, ErrMaxDepth)
+	}
+	msg, err := cachedMessage(v.Type())
+	if err != nil {
+		return dst, err
+	}
+	for i := range msg.fields {
+		f := &msg.fields[i]
+		fv, err := v.Field(f.index)
+		if err != nil {
+			return dst, codec.WithPath(err, f.name)
+		}
+		switch f.shape {
+		case shapeSingular:
+			dst, err = encodeSingular(dst, f, fv, depth)
+		case shapeRepeated:
+			dst, err = encodeRepeated(dst, f, fv, depth)
+		case shapeMap:
+			dst, err = encodeMap(dst, f, fv, depth)
+		}
+		if err != nil {
+			return dst, codec.WithPath(err, f.name)
+		}
+	}
+	return dst, nil
+}
+
+// encodeSingular appends a non-repeated field unless it is unset. Pointers
+// are set when non-nil, other values when not zero or empty.
+func encodeSingular(dst []byte, f *field, v tinyreflect.Value, depth int) ([]byte, error) {
+	if v.Kind() == K.Pointer {
+		if v.IsZero() {
+			return dst, nil
+		}
+	} else if isEmpty(v) {
+		return dst, nil
+	}
+	return encodeValue(appendTag(dst, f.num, f.enc.wireType()), v, f.enc, depth)
+}
+
+// encodeRepeated appends the elements of the slice v, as a single packed
+// record or one record per element.
+func encodeRepeated(dst []byte, f *field, v tinyreflect.Value, depth int) ([]byte, error) {
+	n, _ := v.Len()
+	if n == 0 {
+		return dst, nil
+	}
+	if f.packed {
+		var payload []byte
+		for i := 0; i < n; i++ {
+			elem, err := v.Index(i)
+			if err != nil {
+				return dst, err
+			}
+			if payload, err = encodeValue(payload, elem, f.enc, depth); err != nil {
+				return dst, codec.WithPath(err, codec.IndexSegment(i))
+			}
+		}
+		dst = appendUvarint(appendTag(dst, f.num, wireBytes), uint64(len(payload)))
+		return append(dst, payload...), nil
+	}
+	for i := 0; i < n; i++ {
+		elem, err := v.Index(i)
+		if err != nil {
+			return dst, err
+		}
+		if dst, err = encodeValue(appendTag(dst, f.num, f.enc.wireType()), elem, f.enc, depth); err != nil {
+			return dst, codec.WithPath(err, codec.IndexSegment(i))
+		}
+	}
+	return dst, nil
+}
+
+// mapEntry is a map entry collected for sorting.
+type mapEntry struct {
+	key, val tinyreflect.Value
+}
+
+// compareKeys orders map keys of the same kind by value.
+func compareKeys(a, b tinyreflect.Value) int {
+	switch a.Kind() {
+	case K.String:
+		return cmp.Compare(a.String(), b.String())
+	case K.Bool:
+		x, _ := a.Bool()
+		y, _ := b.Bool()
+		if x == y {
+			return 0
+		} else if y {
+			return -1
+		}
+		return 1
+	case K.Int, K.Int8, K.Int16, K.Int32, K.Int64:
+		x, _ := a.Int()
+		y, _ := b.Int()
+		return cmp.Compare(x, y)
+	}
+	x, _ := a.Uint()
+	y, _ := b.Uint()
+	return cmp.Compare(x, y)
+}
+
+// encodeMap appends one entry message per map entry, sorted by key. The
+// key is field 1 and the value field 2 of each entry; both are always
+// written.
+func encodeMap(dst []byte, f *field, v tinyreflect.Value, depth int) ([]byte, error) {
+	if v.IsZero() {
+		return dst, nil
+	}
+	n, _ := v.Len()
+	iter, err := v.MapRange()
+	if err != nil {
+		return dst, err
+	}
+	entries := make([]mapEntry, 0, n)
+	for iter.Next() {
+		entries = append(entries, mapEntry{iter.Key(), iter.Value()})
+	}
+	slices.SortFunc(entries, func(a, b mapEntry) int {
+		return compareKeys(a.key, b.key)
+	})
+
+	var entry []byte
+	for i, e := range entries {
+		entry = encodeScalar(appendTag(entry[:0], 1, f.keyEnc.wireType()), e.key, f.keyEnc)
+		if entry, err = encodeValue(appendTag(entry, 2, f.enc.wireType()), e.val, f.enc, depth); err != nil {
+			if e.key.Kind() == K.String {
+				return dst, codec.WithPath(err, e.key.String())
+			}
+			return dst, codec.WithPath(err, codec.IndexSegment(i))
+		}
+		dst = appendUvarint(appendTag(dst, f.num, wireBytes), uint64(len(entry)))
+		dst = append(dst, entry...)
+	}
+	return dst, nil
+}
+
+// encodeValue appends v with encoding enc, without a tag. Pointers are
+// followed; a nil pointer is written as the zero value or an empty message.
+func encodeValue(dst []byte, v tinyreflect.Value, enc encoding, depth int) ([]byte, error) {
+	if v.Kind() == K.Pointer {
+		if v.IsZero() {
+			if enc == encMessage {
+				return append(dst, 0), nil
+			}
+			return encodeScalar(dst, tinyreflect.NewValue(v.Type().Elem()), enc), nil
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return dst, err
+		}
+		v = elem
+	}
+	if enc != encMessage {
+		return encodeScalar(dst, v, enc), nil
+	}
+
+	// The length comes first, so the message is encoded on its own
+	msg, err := encodeMessage(nil, v, depth+1)
+	if err != nil {
+		return dst, err
+	}
+	return append(appendUvarint(dst, uint64(len(msg))), msg...), nil
+}
+
+// encodeScalar appends the non-message value v with encoding enc. The
+// encoding was checked against the type when the tag was parsed.
+func encodeScalar(dst []byte, v tinyreflect.Value, enc encoding) []byte {
+	if v.Kind() == K.Pointer {
+		v, _ = v.Elem()
+	}
+	switch enc {
+	case encVarint:
+		return appendUvarint(dst, bits(v))
+	case encZigzag:
+		i, _ := v.Int()
+		return appendUvarint(dst, zigzag(i))
+	case encFixed32:
+		return appendFixed(dst, bits(v), 4)
+	case encFixed64:
+		return appendFixed(dst, bits(v), 8)
+	}
+	if v.Kind() == K.String {
+		s := v.String()
+		return append(appendUvarint(dst, uint64(len(s))), s...)
+	}
+	n, _ := v.Len()
+	dst = appendUvarint(dst, uint64(n))
+	if n == 0 {
+		return dst
+	}
+	p, _ := v.UnsafePointer()
+	return append(dst, unsafe.Slice((*byte)(p), n)...)
+}
+
+// bits returns a bool, integer or float as the unsigned integer written
+// on the wire. Negative integers are sign-extended to 64 bits, as proto
+// does for int32 varints.
+func bits(v tinyreflect.Value) uint64 {
+	switch v.Kind() {
+	case K.Bool:
+		if b, _ := v.Bool(); b {
+			return 1
+		}
+		return 0
+	case K.Int, K.Int8, K.Int16, K.Int32, K.Int64:
+		i, _ := v.Int()
+		return uint64(i) // fixed32 keeps the low 32 bits
+	case K.Float32:
+		f, _ := v.Float()
+		return uint64(math.Float32bits(float32(f)))
+	case K.Float64:
+		f, _ := v.Float()
+		return math.Float64bits(f)
+	}
+	u, _ := v.Uint()
+	return u
+}
+
+// isEmpty reports whether v is left out of the message: zero scalars,
+// empty strings and byte slices, and zero structs.
+func isEmpty(v tinyreflect.Value) bool {
+	switch v.Kind() {
+	case K.String, K.Slice:
+		n, _ := v.Len()
+		return n == 0
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,248 @@
+package proto
+
+import (
+	"slices"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/cache"
+	"github.com/cdvelop/tinyreflect/internal/num"
+	. "github.com/cdvelop/tinystring"
+)
+
+// encoding is how a scalar or an element is written.
+type encoding uint8
+
+const (
+	encVarint  encoding = iota + 1 // int32, int64, uint32, uint64, bool
+	encZigzag                      // sint32, sint64
+	encFixed32                     // fixed32, sfixed32, float
+	encFixed64                     // fixed64, sfixed64, double
+	encBytes                       // string, bytes
+	encMessage                     // embedded message
+)
+
+// Wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// wireType returns the wire type of values written with e.
+func (e encoding) wireType() uint64 {
+	switch e {
+	case encVarint, encZigzag:
+		return wireVarint
+	case encFixed32:
+		return wireFixed32
+	case encFixed64:
+		return wireFixed64
+	}
+	return wireBytes
+}
+
+// packable reports whether repeated values of e can be packed.
+func (e encoding) packable() bool {
+	return e != encBytes && e != encMessage
+}
+
+// Field shapes.
+const (
+	shapeSingular = iota
+	shapeRepeated
+	shapeMap
+)
+
+// field describes how one struct field is written.
+type field struct {
+	num    uint64
+	name   string // Go field name, for error paths
+	index  int
+	shape  int
+	enc    encoding // of the value, the elements, or the map values
+	keyEnc encoding // of map keys
+	packed bool
+}
+
+// message lists the fields of a struct type in field-number order.
+type message struct {
+	fields []field
+	byNum  map[uint64]int
+}
+
+// messages keeps the parsed tags of each struct type.
+var messages cache.Cache[*tinyreflect.Type, *message]
+
+func tagError(name string) error {
+	return &tinyreflect.ValueError{Method: "proto.Marshal", Kind: K.Struct, Path: name, Err: tinyreflect.ErrInvalidArgument}
+}
+
+// cachedMessage returns the fields of the struct type t.
+func cachedMessage(t *tinyreflect.Type) (*message, error) {
+	if m, ok := messages.Load(t); ok {
+		return m, nil
+	}
+
+	n, err := t.NumField()
+	if err != nil {
+		return nil, err
+	}
+	m := &message{byNum: make(map[uint64]int)}
+	for i := 0; i < n; i++ {
+		sf, err := t.Field(i)
+		if err != nil {
+			return nil, err
+		}
+		tag, ok := sf.Tag().Lookup("proto")
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+		name, err := t.NameByIndex(i)
+		if err != nil {
+			return nil, err
+		}
+		f, err := parseField(name, tag, sf.Typ)
+		if err != nil {
+			return nil, err
+		}
+		f.index = i
+		m.fields = append(m.fields, f)
+	}
+	slices.SortFunc(m.fields, func(a, b field) int {
+		return int(a.num) - int(b.num)
+	})
+	for i, f := range m.fields {
+		if _, dup := m.byNum[f.num]; dup {
+			return nil, tagError(f.name)
+		}
+		m.byNum[f.num] = i
+	}
+
+	messages.Store(t, m)
+	return m, nil
+}
+
+// parseField reads a tag such as "3,varint,zigzag" for a field of type t.
+func parseField(name, tag string, t *tinyreflect.Type) (field, error) {
+	numStr, opts := tinyreflect.ParseTag(tag)
+	n, st := num.ParseUint(numStr, 64)
+	if st != num.OK || n == 0 || n >= 1<<29 {
+		return field{}, tagError(name)
+	}
+	f := field{num: n, name: name, packed: opts.Has("packed")}
+
+	// The encoding is the first option when it names one
+	first, _ := tinyreflect.ParseTag(string(opts))
+	enc := parseEncoding(first)
+	if opts.Has("zigzag") {
+		if enc != 0 && enc != encVarint && enc != encZigzag {
+			return field{}, tagError(name)
+		}
+		enc = encZigzag
+	}
+
+	switch {
+	case t.Kind() == K.Map:
+		f.shape = shapeMap
+		key, _ := opts.Lookup("key")
+		val, _ := opts.Lookup("value")
+		if enc != 0 && enc != encBytes || f.packed {
+			return field{}, tagError(name)
+		}
+		if f.keyEnc = encodingFor(t.Key(), parseEncoding(key)); f.keyEnc == 0 || f.keyEnc == encMessage ||
+			t.Key().Kind() == K.Float32 || t.Key().Kind() == K.Float64 || t.Key().Kind() == K.Slice {
+			return field{}, tagError(name)
+		}
+		if f.enc = encodingFor(t.Elem(), parseEncoding(val)); f.enc == 0 {
+			return field{}, tagError(name)
+		}
+
+	case t.Kind() == K.Slice && t.Elem().Kind() != K.Uint8:
+		f.shape = shapeRepeated
+		if f.enc = encodingFor(t.Elem(), enc); f.enc == 0 || f.packed && !f.enc.packable() {
+			return field{}, tagError(name)
+		}
+
+	default:
+		f.shape = shapeSingular
+		if f.enc = encodingFor(t, enc); f.enc == 0 || f.packed {
+			return field{}, tagError(name)
+		}
+	}
+	return f, nil
+}
+
+// parseEncoding returns the encoding named s, or 0.
+func parseEncoding(s string) encoding {
+	switch s {
+	case "varint":
+		return encVarint
+	case "zigzag":
+		return encZigzag
+	case "fixed32":
+		return encFixed32
+	case "fixed64":
+		return encFixed64
+	case "bytes":
+		return encBytes
+	}
+	return 0
+}
+
+// encodingFor returns the encoding of values of type t, checking that an
+// explicit encoding fits the type. Pointers take the encoding of their
+// element. It returns 0 for unsupported combinations.
+func encodingFor(t *tinyreflect.Type, explicit encoding) encoding {
+	if t.Kind() == K.Pointer {
+		if t.Elem().Kind() == K.Pointer {
+			return 0
+		}
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case K.Bool:
+		if explicit == 0 || explicit == encVarint {
+			return encVarint
+		}
+	case K.Int, K.Int8, K.Int16, K.Int32, K.Int64, K.Uint, K.Uint8, K.Uint16, K.Uint32, K.Uint64:
+		signed := t.Kind() == K.Int || t.Kind() == K.Int8 || t.Kind() == K.Int16 || t.Kind() == K.Int32 || t.Kind() == K.Int64
+		switch explicit {
+		case 0, encVarint:
+			return encVarint
+		case encZigzag:
+			if signed {
+				return encZigzag
+			}
+		case encFixed32:
+			if t.Kind() == K.Int32 || t.Kind() == K.Uint32 {
+				return encFixed32
+			}
+		case encFixed64:
+			if t.Kind() == K.Int64 || t.Kind() == K.Uint64 || t.Kind() == K.Int || t.Kind() == K.Uint {
+				return encFixed64
+			}
+		}
+	case K.Float32:
+		if explicit == 0 || explicit == encFixed32 {
+			return encFixed32
+		}
+	case K.Float64:
+		if explicit == 0 || explicit == encFixed64 {
+			return encFixed64
+		}
+	case K.String:
+		if explicit == 0 || explicit == encBytes {
+			return encBytes
+		}
+	case K.Slice:
+		if t.Elem().Kind() == K.Uint8 && (explicit == 0 || explicit == encBytes) {
+			return encBytes
+		}
+	case K.Struct:
+		if explicit == 0 || explicit == encBytes {
+			return encMessage
+		}
+	}
+	return 0
+}
@@ -0,0 +1,91 @@
+// Package proto encodes and decodes plain Go structs in the Protocol
+// Buffers wire format, driven by struct tags instead of generated code,
+// so gRPC payloads can be read and written from TinyGo and WebAssembly
+// builds.
+//
+// The tag holds the field number, optionally followed by the encoding and
+// options:
+//
+//	type User struct {
+//		ID     uint64            `proto:"1"`                // varint
+//		Delta  int32             `proto:"2,varint,zigzag"`  // sint32
+//		Hash   uint32            `proto:"3,fixed32"`        // fixed32
+//		Name   string            `proto:"4"`                // length-delimited
+//		Scores []int64           `proto:"5,varint,packed"`  // packed repeated
+//		Tags   []string          `proto:"6"`                // repeated
+//		Home   *Address          `proto:"7"`                // nested message
+//		Attrs  map[string]int64  `proto:"8,bytes,value=zigzag"`
+//		Cache  string                                       // no tag: skipped
+//	}
+//
+// Encodings are varint, zigzag, fixed32, fixed64 and bytes; when omitted
+// they follow the Go type: varint for bools and integers, fixed32 and
+// fixed64 for float32 and float64, and bytes for strings, []byte, structs
+// and maps. Repeated scalars are unpacked unless tagged packed; the decoder
+// accepts both forms. Map key and value encodings are set with key= and
+// value= options.
+//
+// Like proto3, zero scalars and nil or empty fields are not written, while
+// pointers to scalars are written whenever they are set. Fields are written
+// in field-number order and map entries sorted by key, so equal values
+// give the same bytes.
+package proto
+
+import (
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	. "github.com/cdvelop/tinystring"
+)
+
+// Errors returned by the codec, wrapped in a *tinyreflect.ValueError with
+// the path of the field that failed.
+var (
+	// ErrInvalidData is returned for truncated input, malformed varints and unknown wire types.
+	ErrInvalidData = codec.ErrInvalidData
+	// ErrMaxDepth is returned for values nested too deeply, usually through
+	// a pointer cycle. All the codec packages share this value.
+	ErrMaxDepth = codec.ErrMaxDepth
+)
+
+// Marshal returns the wire encoding of the struct v or the struct v
+// points to.
+func Marshal(v any) ([]byte, error) {
+	return Append(nil, v)
+}
+
+// Append appends the wire encoding of the struct v, or the struct v points
+// to, to dst and returns the extended buffer.
+func Append(dst []byte, v any) ([]byte, error) {
+	rv := tinyreflect.ValueOf(v)
+	if rv.Kind() == K.Pointer && !rv.IsZero() {
+		var err error
+		if rv, err = rv.Elem(); err != nil {
+			return dst, err
+		}
+	}
+	if rv.Kind() != K.Struct {
+		return dst, &tinyreflect.ValueError{Method: "proto.Marshal", Kind: rv.Kind(), Err: tinyreflect.ErrNotStruct}
+	}
+	return encodeMessage(dst, rv, 0)
+}
+
+// Unmarshal resets the struct pointed to by v and decodes the message in
+// data into it. Nil pointers, maps and slices are allocated as needed and
+// fields with unknown numbers are skipped.
+func Unmarshal(data []byte, v any) error {
+	rv := tinyreflect.ValueOf(v)
+	if rv.Kind() != K.Pointer || rv.IsZero() {
+		return &tinyreflect.ValueError{Method: "proto.Unmarshal", Kind: rv.Kind(), Err: tinyreflect.ErrInvalidArgument}
+	}
+	elem, err := rv.Elem()
+	if err != nil {
+		return err
+	}
+	if elem.Kind() != K.Struct {
+		return &tinyreflect.ValueError{Method: "proto.Unmarshal", Kind: elem.Kind(), Err: tinyreflect.ErrNotStruct}
+	}
+	if err := elem.SetZero(); err != nil {
+		return err
+	}
+	return decodeMessage(data, elem, 0)
+}
@@ -0,0 +1,307 @@
+package proto_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/proto"
+)
+
+type Address struct {
+	Street string `proto:"1"`
+	Zip    uint32 `proto:"2,fixed32"`
+}
+
+type User struct {
+	ID     uint64           `proto:"1"`
+	Delta  int32            `proto:"2,zigzag"`
+	Hash   uint32           `proto:"3,fixed32"`
+	Name   string           `proto:"4"`
+	Scores []int64          `proto:"5,varint,packed"`
+	Tags   []string         `proto:"6"`
+	Home   *Address         `proto:"7"`
+	Attrs  map[string]int64 `proto:"8,bytes,value=zigzag"`
+	Ratio  float64          `proto:"9"`
+	Temp   float32          `proto:"10"`
+	Active bool             `proto:"11"`
+	Data   []byte           `proto:"12"`
+	Offset int32            `proto:"13"`
+	Nick   *string          `proto:"14"`
+	Past   []*Address       `proto:"15"`
+	Big    int64            `proto:"16,fixed64"`
+	Flags  []uint32         `proto:"17"`
+	Counts map[int32]string `proto:"18"`
+	Extra  uint8            `proto:"1000"`
+	Cache  string
+	Skip   int `proto:"-"`
+}
+
+// TestEncodingExamples checks the examples of the Protocol Buffers
+// encoding guide.
+func TestEncodingExamples(t *testing.T) {
+	type Test1 struct {
+		A int32 `proto:"1"`
+	}
+	type Test2 struct {
+		B string `proto:"2"`
+	}
+	type Test3 struct {
+		C Test1 `proto:"3"`
+	}
+	type Test4 struct {
+		D string  `proto:"1"`
+		E []int32 `proto:"4,packed"`
+	}
+	type Signed struct {
+		S32 int32 `proto:"1,zigzag"`
+		S64 int64 `proto:"2,varint,zigzag"`
+	}
+	type Fixed struct {
+		F32 int32   `proto:"1,fixed32"`
+		F64 uint64  `proto:"2,fixed64"`
+		Flt float32 `proto:"3"`
+		Dbl float64 `proto:"4"`
+	}
+
+	testCases := []struct {
+		name  string
+		value any
+		hex   string
+	}{
+		{"Varint", Test1{A: 150}, "089601"},
+		{"Negative int32", Test1{A: -2}, "08feffffffffffffffff01"},
+		{"String", Test2{B: "testing"}, "120774657374696e67"},
+		{"Embedded message", Test3{C: Test1{A: 150}}, "1a03089601"},
+		{"String and packed", Test4{D: "hello", E: []int32{1, 2, 3}}, "0a0568656c6c6f" + "2203010203"},
+		{"Packed", Test4{E: []int32{3, 270, 86942}}, "2206038e029ea705"},
+		{"Zigzag", Signed{S32: -1, S64: 1}, "08011002"},
+		{"Zigzag extremes", Signed{S32: math.MinInt32, S64: math.MaxInt64}, "08ffffffff0f" + "10feffffffffffffffff01"},
+		{"Fixed", Fixed{F32: -1, F64: 1, Flt: 1.5, Dbl: -2}, "0dffffffff" + "110100000000000000" + "1d0000c03f" + "2100000000000000c0"},
+		{"Zero values", Fixed{}, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := proto.Marshal(tc.value)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if hex.EncodeToString(got) != tc.hex {
+				t.Fatalf("got %x, want %s", got, tc.hex)
+			}
+
+			ptr := reflect.New(reflect.TypeOf(tc.value))
+			if err := proto.Unmarshal(got, ptr.Interface()); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if decoded := ptr.Elem().Interface(); !reflect.DeepEqual(decoded, tc.value) {
+				t.Errorf("got %+v, want %+v", decoded, tc.value)
+			}
+		})
+	}
+}
+
+// fixtures pairs each file in testdata with the value it encodes. The
+// files were produced by an independent encoder following the spec.
+func fixtures() map[string]User {
+	nick := ""
+	return map[string]User{
+		"user_full": {
+			ID:     1 << 40,
+			Delta:  -3,
+			Hash:   0xdeadbeef,
+			Name:   "Ana ñ",
+			Scores: []int64{1, -1, 300},
+			Tags:   []string{"a", "", "bc"},
+			Home:   &Address{Street: "Main 1", Zip: 12345},
+			Attrs:  map[string]int64{"x": -2, "a": 64},
+			Ratio:  0.1,
+			Temp:   -1.5,
+			Active: true,
+			Data:   []byte{0, 0xff},
+			Offset: -7,
+			Nick:   &nick,
+			Past:   []*Address{{Zip: 1}, {}},
+			Big:    -2,
+			Flags:  []uint32{7, 0, 1 << 31},
+			Counts: map[int32]string{5: "", -1: "neg"},
+			Extra:  255,
+		},
+		"user_zero_pointers": {Home: &Address{}, Nick: &nick},
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	for name, value := range fixtures() {
+		t.Run(name, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join("testdata", name+".pb"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := proto.Marshal(&value)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if string(got) != string(want) {
+				t.Fatalf("Marshal:\ngot  % x\nwant % x", got, want)
+			}
+
+			decoded := User{Cache: "kept?", Tags: []string{"old"}}
+			if err := proto.Unmarshal(want, &decoded); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, value) {
+				t.Errorf("Unmarshal:\ngot  %+v\nwant %+v", decoded, value)
+			}
+		})
+	}
+}
+
+func TestUnmarshalForms(t *testing.T) {
+	type Target struct {
+		Nums  []uint16         `proto:"1"`
+		Inner *Address         `proto:"2"`
+		Set   map[bool]float32 `proto:"3"`
+	}
+
+	testCases := []struct {
+		name string
+		hex  string
+		want Target
+	}{
+		{"Unpacked into repeated", "0801" + "0802", Target{Nums: []uint16{1, 2}}},
+		{"Packed into repeated", "0a03010203", Target{Nums: []uint16{1, 2, 3}}},
+		{"Mixed forms", "0801" + "0a020203" + "0804", Target{Nums: []uint16{1, 2, 3, 4}}},
+		{"Messages merge", "12030a0178" + "12051539300000", Target{Inner: &Address{Street: "x", Zip: 12345}}},
+		{"Last scalar wins", "12030a0179" + "12030a017a", Target{Inner: &Address{Street: "z"}}},
+		{"Unknown fields", "2096" + "01" + "290000000000000000" + "32026869" + "35ffffffff" + "0807", Target{Nums: []uint16{7}}},
+		{"Map entry defaults", "1a00" + "1a020801", Target{Set: map[bool]float32{false: 0, true: 0}}},
+		{"Map entry value first", "1a07" + "150000c03f" + "0801", Target{Set: map[bool]float32{true: 1.5}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, _ := hex.DecodeString(tc.hex)
+			var got Target
+			if err := proto.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	type Inner struct {
+		I8 int8 `proto:"1"`
+	}
+	type Target struct {
+		I8    int8             `proto:"1"`
+		U16   uint16           `proto:"2"`
+		S32   int32            `proto:"3,zigzag"`
+		Name  string           `proto:"4"`
+		List  []Inner          `proto:"5"`
+		Attrs map[string]int8  `proto:"6"`
+		Keys  map[uint8]string `proto:"7"`
+	}
+
+	testCases := []struct {
+		name    string
+		hex     string
+		wantErr error
+		path    string
+	}{
+		{"Int8 overflow", "08" + "8001", tinyreflect.ErrOverflow, "I8"},
+		{"Negative into int8", "08" + "ffffffffffffffff7f", tinyreflect.ErrOverflow, "I8"},
+		{"Uint16 overflow", "10" + "808004", tinyreflect.ErrOverflow, "U16"},
+		{"Sint32 overflow", "18" + "8080808020", tinyreflect.ErrOverflow, "S32"},
+		{"Wire type mismatch", "20" + "01", tinyreflect.ErrTypeMismatch, "Name"},
+		{"Nested path", "2a020801" + "2a03" + "088001", tinyreflect.ErrOverflow, "List[1].I8"},
+		{"Map value path", "3206" + "0a016b" + "10ff01", tinyreflect.ErrOverflow, "Attrs.k"},
+		{"Map key overflow", "3a03" + "088002", tinyreflect.ErrOverflow, "Keys"},
+		{"Truncated varint", "0880", proto.ErrInvalidData, "I8"},
+		{"Truncated bytes", "2205" + "6869", proto.ErrInvalidData, "Name"},
+		{"Truncated fixed", "4d" + "0000", proto.ErrInvalidData, ""},
+		{"Field number zero", "0001", proto.ErrInvalidData, ""},
+		{"Group", "4b" + "4c", proto.ErrInvalidData, ""},
+		{"Group on known field", "0b", proto.ErrInvalidData, "I8"},
+		{"Varint too long", "08" + "ffffffffffffffffff02", proto.ErrInvalidData, "I8"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, _ := hex.DecodeString(tc.hex)
+			var got Target
+			err := proto.Unmarshal(data, &got)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got %v, want %v", err, tc.wantErr)
+			}
+			var ve *tinyreflect.ValueError
+			if errors.As(err, &ve) && ve.Path != tc.path {
+				t.Errorf("got path %q, want %q", ve.Path, tc.path)
+			}
+		})
+	}
+
+	if err := proto.Unmarshal(nil, 0); !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+		t.Errorf("non-pointer: got %v, want ErrInvalidArgument", err)
+	}
+	if err := proto.Unmarshal(nil, new(int)); !errors.Is(err, tinyreflect.ErrNotStruct) {
+		t.Errorf("pointer to int: got %v, want ErrNotStruct", err)
+	}
+	if _, err := proto.Marshal([]int{1}); !errors.Is(err, tinyreflect.ErrNotStruct) {
+		t.Errorf("slice: got %v, want ErrNotStruct", err)
+	}
+}
+
+func TestTagErrors(t *testing.T) {
+	type BadNumber struct {
+		F int `proto:"x"`
+	}
+	type ZeroNumber struct {
+		F int `proto:"0"`
+	}
+	type Duplicate struct {
+		A int `proto:"1"`
+		B int `proto:"1"`
+	}
+	type ZigzagUnsigned struct {
+		F uint32 `proto:"1,zigzag"`
+	}
+	type Fixed32Int64 struct {
+		F int64 `proto:"1,fixed32"`
+	}
+	type PackedStrings struct {
+		F []string `proto:"1,packed"`
+	}
+	type FloatKey struct {
+		F map[float64]int `proto:"1"`
+	}
+	type Channel struct {
+		F chan int `proto:"1"`
+	}
+
+	for _, v := range []any{BadNumber{}, ZeroNumber{}, Duplicate{}, ZigzagUnsigned{}, Fixed32Int64{}, PackedStrings{}, FloatKey{}, Channel{}} {
+		t.Run(reflect.TypeOf(v).Name(), func(t *testing.T) {
+			if _, err := proto.Marshal(v); !errors.Is(err, tinyreflect.ErrInvalidArgument) {
+				t.Errorf("got %v, want ErrInvalidArgument", err)
+			}
+		})
+	}
+}
+
+func TestCycle(t *testing.T) {
+	type Node struct {
+		Next *Node `proto:"1"`
+	}
+	n := &Node{}
+	n.Next = n
+	if _, err := proto.Marshal(n); !errors.Is(err, proto.ErrMaxDepth) {
+		t.Errorf("got %v, want ErrMaxDepth", err)
+	}
+}
@@ -0,0 +1,36 @@
+package proto
+
+// appendUvarint appends x in the base-128 varint format.
+func appendUvarint(dst []byte, x uint64) []byte {
+	for x >= 0x80 {
+		dst = append(dst, byte(x)|0x80)
+		x >>= 7
+	}
+	return append(dst, byte(x))
+}
+
+// appendTag appends the key of field num with wire type wt.
+func appendTag(dst []byte, num, wt uint64) []byte {
+	return appendUvarint(dst, num<<3|wt)
+}
+
+// appendFixed appends the low n bytes of x in little-endian order.
+func appendFixed(dst []byte, x uint64, n int) []byte {
+	for i := 0; i < n; i++ {
+		dst = append(dst, byte(x>>(8*i)))
+	}
+	return dst
+}
+
+// zigzag maps signed integers to unsigned ones so small negative numbers
+// stay short: 0, -1, 1, -2 become 0, 1, 2, 3.
+func zigzag(x int64) uint64 {
+	return uint64(x<<1) ^ uint64(x>>63)
+}
+
+// unzigzag reverses zigzag.
+func unzigzag(x uint64) int64 {
+	return int64(x>>1) ^ -int64(x&1)
+}
+
+const maxVarintLen64 = 10 // maximum length of a varint64
@@ -93,3 +93,37 @@ func TestEmbedded(t *testing.T) {
 		t.Errorf("Embedded field should be int, got %s", embeddedField.Kind())
 	}
 }
+
+func TestStructFieldExported(t *testing.T) {
+	type inner struct{ N int }
+	type S struct {
+		Pub    int    `x:"1" json:"pub"`
+		priv   int    `x:"2"`
+		inner         // unexported embedded type
+		Tagged string `x:"x"`
+	}
+
+	typ := tinyreflect.TypeOf(S{})
+	want := []struct {
+		name     string
+		exported bool
+		embedded bool
+		tag      string
+	}{
+		{"Pub", true, false, `x:"1" json:"pub"`},
+		{"priv", false, false, `x:"2"`},
+		{"inner", false, true, ""},
+		{"Tagged", true, false, `x:"x"`},
+	}
+	for i, w := range want {
+		f, err := typ.Field(i)
+		if err != nil {
+			t.Fatalf("Field(%d): %v", i, err)
+		}
+		if f.Name.String() != w.name || f.IsExported() != w.exported || f.Embedded() != w.embedded || string(f.Tag()) != w.tag {
+			t.Errorf("field %d: name %q exported %v embedded %v tag %q, want %+v",
+				i, f.Name.String(), f.IsExported(), f.Embedded(), f.Tag(), w)
+		}
+	}
+
+}
@@ -70,19 +70,19 @@ func Indirect(v Value) Value {
 // for the specified slice type, length, and capacity.
 func MakeSlice(typ *Type, len, cap int) (Value, error) {
 	if typ == nil {
-		return Value{}, Err(D.Value, D.Type, D.Nil)
+		return Value{}, newValueError("MakeSlice", K.Invalid, ErrNilValue)
 	}
-	if typ.Kind().String() != "slice" {
-		return Value{}, Err("MakeSlice of non-slice type")
+	if typ.Kind() != K.Slice {
+		return Value{}, newValueError("MakeSlice", typ.Kind(), ErrUnsupportedKind)
 	}
 	if len < 0 || cap < 0 || len > cap {
-		return Value{}, Err("invalid slice length or capacity")
+		return Value{}, newValueError("MakeSlice", K.Slice, ErrInvalidArgument)
 	}
 
 	sliceType := (*SliceType)(unsafe.Pointer(typ))
 	elemType := sliceType.Elem
 	if elemType == nil {
-		return Value{}, Err("MakeSlice element type is nil")
+		return Value{}, newValueError("MakeSlice", K.Slice, ErrNilValue)
 	}
 
 	data := makeSliceData(elemType, cap)
@@ -91,4 +91,51 @@ func MakeSlice(typ *Type, len, cap int) (Value, error) {
 	return Value{typ, unsafe.Pointer(sliceHeader), flagIndir | flag(K.Slice)}, nil
 }
 
+// Copy copies the contents of src into dst until either dst has been filled
+// or src has been exhausted. It returns the number of elements copied.
+// dst must be a slice or an addressable array, src a slice or an array,
+// and both must have the same element type.
+func Copy(dst, src Value) (int, error) {
+	dk, sk := dst.kind(), src.kind()
+	if dk != K.Slice && dk != K.Array || sk != K.Slice && sk != K.Array {
+		return 0, newValueError("Copy", dk, ErrUnsupportedKind)
+	}
+	if dk == K.Array && dst.flag&flagAddr == 0 || dst.flag&flagRO != 0 {
+		return 0, newValueError("Copy", dk, ErrNotAssignable)
+	}
+	elem := dst.typ_.Elem()
+	if elem != src.typ_.Elem() {
+		return 0, newValueError("Copy", dk, ErrTypeMismatch)
+	}
+
+	dn, _ := dst.Len()
+	sn, _ := src.Len()
+	n := min(dn, sn)
+	if n == 0 {
+		return 0, nil
+	}
+	dp, sp := dst.ptr, src.ptr
+	if dk == K.Slice {
+		dp = (*sliceHeader)(dst.ptr).Data
+	}
+	if sk == K.Slice {
+		sp = (*sliceHeader)(src.ptr).Data
+	}
+
+	size := getElemSize(elem)
+	if uintptr(dp) > uintptr(sp) {
+		// Copy backwards so overlapping memory is not overwritten before it is read
+		for i := n - 1; i >= 0; i-- {
+			off := uintptr(i) * size
+			typedmemmove(elem, add(dp, off, "i < n"), add(sp, off, "i < n"))
+		}
+		return n, nil
+	}
+	for i := 0; i < n; i++ {
+		off := uintptr(i) * size
+		typedmemmove(elem, add(dp, off, "i < n"), add(sp, off, "i < n"))
+	}
+	return n, nil
+}
+
 // NewValue is implemented in tinyreflect_stdlib.go and tinyreflect_tinygo.go
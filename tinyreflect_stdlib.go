@@ -15,49 +15,62 @@ func NewValue(typ *Type) Value {
 		return Value{}
 	}
 
-	// Create properly aligned memory for the target value
-	size := typ.Size
-	if size == 0 {
-		size = 1 // Ensure minimum size for zero-sized types
+	// The runtime allocator knows typ's pointer layout, so the new value
+	// is scanned by the garbage collector; the pointer itself lives in its
+	// own heap cell so the Value can be addressed like any other pointer.
+	cell := new(unsafe.Pointer)
+	*cell = unsafeNew(typ)
+	return Value{ptrTo(typ), unsafe.Pointer(cell), flag(K.Pointer) | flagIndir}
+}
+
+// ptrTo returns a pointer type whose element type is typ.
+func ptrTo(typ *Type) *Type {
+	ptrTypeSize := unsafe.Sizeof(uintptr(0))
+	ptrType := &PtrType{
+		Type: Type{
+			Kind_:    K.Pointer,
+			Size_:    ptrTypeSize,
+			PtrBytes: ptrTypeSize,
+			TFlag:    tflagDirectIface,
+			Hash:     typ.Hash ^ 0x12345678, // Simple hash derivation
+		},
+		Elem: typ,
 	}
+	return &ptrType.Type
+}
+
+// makeSliceData allocates memory for slice data (stdlib version)
+func makeSliceData(elemType *Type, cap int) unsafe.Pointer {
+	return unsafe_NewArray(elemType, cap)
+}
 
-	// Allocate aligned memory for the value
-	valuePtr := make([]byte, size+7) // Add padding for alignment
-	alignedValuePtr := unsafe.Pointer((uintptr(unsafe.Pointer(&valuePtr[0])) + 7) &^ 7)
+//go:linkname unsafe_NewArray reflect.unsafe_NewArray
+func unsafe_NewArray(typ *Type, n int) unsafe.Pointer
 
-	// Allocate aligned memory for the pointer to the value
-	ptrStorage := make([]uintptr, 1) // Use uintptr slice for proper alignment
-	ptrStorage[0] = uintptr(alignedValuePtr)
+//go:linkname typedmemclr_ reflect.typedmemclr
+func typedmemclr_(typ *Type, ptr unsafe.Pointer)
 
-	// Create a proper pointer type that won't cause alignment issues
-	// We'll use typ.Elem() construction pattern to avoid creating local PtrType
-	ptrTypeSize := unsafe.Sizeof(uintptr(0))
+//go:linkname unsafe_New reflect.unsafe_New
+func unsafe_New(typ *Type) unsafe.Pointer
 
-	// Create type info for pointer type - allocate it properly aligned
-	ptrTypeStorage := make([]byte, unsafe.Sizeof(PtrType{})+7)
-	alignedPtrType := (*PtrType)(unsafe.Pointer((uintptr(unsafe.Pointer(&ptrTypeStorage[0])) + 7) &^ 7))
+//go:linkname typedmemmove_ reflect.typedmemmove
+func typedmemmove_(typ *Type, dst, src unsafe.Pointer)
 
-	// Initialize the pointer type properly
-	alignedPtrType.Type = Type{
-		Kind_:    K.Pointer,
-		Size:     ptrTypeSize,
-		PtrBytes: ptrTypeSize,
-		Hash:     typ.Hash ^ 0x12345678, // Simple hash derivation
-	}
-	alignedPtrType.Elem = typ
+// unsafeNew allocates zeroed memory for a value of type typ.
+// The runtime allocator knows typ's pointer layout, so the memory is scanned
+// correctly by the garbage collector.
+func unsafeNew(typ *Type) unsafe.Pointer {
+	return unsafe_New(typ)
+}
 
-	// Create the Value with proper flags for addressability
-	// The pointer should be flagIndir, and the pointed-to value should have flagAddr
-	ptrFlags := flag(K.Pointer) | flagIndir
-	return Value{(*Type)(unsafe.Pointer(alignedPtrType)), unsafe.Pointer(&ptrStorage[0]), ptrFlags}
+// typedmemmove copies a value of type typ from src to dst with the write
+// barriers the garbage collector needs for pointer fields.
+func typedmemmove(typ *Type, dst, src unsafe.Pointer) {
+	typedmemmove_(typ, dst, src)
 }
 
-// makeSliceData allocates memory for slice data (stdlib version)
-func makeSliceData(elemType *Type, cap int) unsafe.Pointer {
-	var data unsafe.Pointer
-	if elemType.Size != 0 {
-		mem := make([]byte, uintptr(cap)*elemType.Size)
-		data = unsafe.Pointer(&mem[0])
-	}
-	return data
+// typedmemclr zeroes the value of type typ at ptr with the write barriers
+// the garbage collector needs for pointer fields.
+func typedmemclr(typ *Type, ptr unsafe.Pointer) {
+	typedmemclr_(typ, ptr)
 }
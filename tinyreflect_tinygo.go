@@ -15,42 +15,56 @@ func NewValue(typ *Type) Value {
 		return Value{}
 	}
 
-	// Create properly aligned memory for the target value
-	size := typ.Size()
-	if size == 0 {
-		size = 1 // Ensure minimum size for zero-sized types
+	// The pointer lives in its own heap cell so the Value can be
+	// addressed like any other pointer.
+	cell := new(unsafe.Pointer)
+	*cell = unsafeNew(typ)
+	return Value{ptrTo(typ), unsafe.Pointer(cell), flag(K.Pointer) | flagIndir}
+}
+
+// ptrTo returns a pointer type whose element type is typ.
+// For TinyGo, we can't create Type literals with stdlib fields
+func ptrTo(typ *Type) *Type {
+	ptrType := &PtrType{
+		Type: Type{meta: uint8(K.Pointer)},
+		Elem: typ,
 	}
+	return &ptrType.Type
+}
 
-	// Allocate aligned memory for the value
-	valuePtr := make([]byte, size+7) // Add padding for alignment
-	alignedValuePtr := unsafe.Pointer((uintptr(unsafe.Pointer(&valuePtr[0])) + 7) &^ 7)
+// zeroSliceData backs slices with no capacity, so they are empty but not nil.
+var zeroSliceData uintptr
 
-	// Allocate aligned memory for the pointer to the value
-	ptrStorage := make([]uintptr, 1) // Use uintptr slice for proper alignment
-	ptrStorage[0] = uintptr(alignedValuePtr)
+// makeSliceData allocates memory for slice data (TinyGo version)
+func makeSliceData(elemType *Type, cap int) unsafe.Pointer {
+	size := elemType.Size() * uintptr(cap)
+	if size == 0 {
+		return unsafe.Pointer(&zeroSliceData)
+	}
+	return runtimeAlloc(size, nil)
+}
 
-	// Create type info for pointer type - allocate it properly aligned
-	ptrTypeStorage := make([]byte, unsafe.Sizeof(PtrType{})+7)
-	alignedPtrType := (*PtrType)(unsafe.Pointer((uintptr(unsafe.Pointer(&ptrTypeStorage[0])) + 7) &^ 7))
+//go:linkname runtimeAlloc runtime.alloc
+func runtimeAlloc(size uintptr, layout unsafe.Pointer) unsafe.Pointer
 
-	// Initialize the pointer type properly
-	// For TinyGo, we can't create Type literals with stdlib fields
-	alignedPtrType.Type = Type{meta: uint8(K.Pointer)}
-	alignedPtrType.Elem = typ
+// unsafeNew allocates zeroed memory for a value of type typ.
+// A nil layout makes TinyGo's collector scan the object conservatively.
+func unsafeNew(typ *Type) unsafe.Pointer {
+	size := typ.Size()
+	if size == 0 {
+		size = 1
+	}
+	return runtimeAlloc(size, nil)
+}
 
-	// Create the Value with proper flags for addressability
-	// The pointer should be flagIndir, and the pointed-to value should have flagAddr
-	ptrFlags := flag(K.Pointer) | flagIndir
-	return Value{(*Type)(unsafe.Pointer(alignedPtrType)), unsafe.Pointer(&ptrStorage[0]), ptrFlags}
+// typedmemmove copies a value of type typ from src to dst.
+// TinyGo's collector is conservative, so a plain byte copy is enough.
+func typedmemmove(typ *Type, dst, src unsafe.Pointer) {
+	size := typ.Size()
+	copy(unsafe.Slice((*byte)(dst), size), unsafe.Slice((*byte)(src), size))
 }
 
-// makeSliceData allocates memory for slice data (TinyGo version)
-func makeSliceData(elemType *Type, cap int) unsafe.Pointer {
-	var data unsafe.Pointer
-	size := elemType.Size()
-	if size != 0 {
-		mem := make([]byte, uintptr(cap)*size)
-		data = unsafe.Pointer(&mem[0])
-	}
-	return data
+// typedmemclr zeroes the value of type typ at ptr.
+func typedmemclr(typ *Type, ptr unsafe.Pointer) {
+	clear(unsafe.Slice((*byte)(ptr), typ.Size()))
 }
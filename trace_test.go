@@ -0,0 +1,72 @@
+//go:build !tinyreflect_notrace
+
+package tinyreflect_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+func TestTraceCollector(t *testing.T) {
+	type Item struct {
+		Name  string
+		Price float64
+	}
+
+	collector := &tinyreflect.TraceCollector{}
+	prev := tinyreflect.SetTracer(collector)
+	defer tinyreflect.SetTracer(prev)
+
+	v := tinyreflect.ValueOf(Item{Name: "pen", Price: 1.5})
+	if _, err := v.Field(1); err != nil {
+		t.Fatalf("Field(1) failed: %v", err)
+	}
+	if _, err := v.Field(5); err == nil {
+		t.Fatal("Field(5): expected an error")
+	}
+	if _, err := v.Type().NameByIndex(0); err != nil {
+		t.Fatalf("NameByIndex(0) failed: %v", err)
+	}
+
+	events := collector.Events()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d: %+v", len(events), events)
+	}
+
+	if e := events[0]; e.Op != "Field" || e.Kind.String() != "struct" || e.Index != 1 || e.Err != nil {
+		t.Errorf("unexpected success event: %+v", e)
+	}
+	if e := events[1]; e.Op != "Field" || e.Index != 5 || !errors.Is(e.Err, tinyreflect.ErrOutOfRange) {
+		t.Errorf("unexpected error event: %+v", e)
+	}
+	if e := events[2]; e.Op != "Type.NameByIndex" || e.Index != 0 || e.Err != nil {
+		t.Errorf("unexpected NameByIndex event: %+v", e)
+	}
+
+	collector.Reset()
+	if n := len(collector.Events()); n != 0 {
+		t.Errorf("Reset: expected no events, got %d", n)
+	}
+}
+
+func TestTraceDisabled(t *testing.T) {
+	collector := &tinyreflect.TraceCollector{}
+	prev := tinyreflect.SetTracer(collector)
+	tinyreflect.SetTracer(nil)
+	defer tinyreflect.SetTracer(prev)
+
+	v := tinyreflect.ValueOf(struct{ A int }{1})
+	v.Field(0)
+	if n := len(collector.Events()); n != 0 {
+		t.Errorf("uninstalled tracer received %d events", n)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		v.Field(0)
+	})
+	if allocs != 0 {
+		t.Errorf("Field allocated %v times with tracing disabled", allocs)
+	}
+}
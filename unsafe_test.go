@@ -0,0 +1,132 @@
+package tinyreflect_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/cdvelop/tinyreflect"
+)
+
+func TestUnsafePointer(t *testing.T) {
+	x := 42
+	buf := []byte{1, 2, 3}
+	m := map[string]int{"a": 1}
+
+	testCases := []struct {
+		name    string
+		value   any
+		want    unsafe.Pointer
+		wantErr bool
+	}{
+		{"Pointer", &x, unsafe.Pointer(&x), false},
+		{"Nil Pointer", (*int)(nil), nil, false},
+		{"Slice", buf, unsafe.Pointer(&buf[0]), false},
+		{"Nil Slice", []byte(nil), nil, false},
+		{"Map", m, *(*unsafe.Pointer)(unsafe.Pointer(&m)), false},
+		{"Int", 123, nil, true},
+		{"String", "hello", nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := tinyreflect.ValueOf(tc.value)
+			p, err := v.UnsafePointer()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("UnsafePointer() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if p != tc.want {
+				t.Errorf("UnsafePointer() = %p, want %p", p, tc.want)
+			}
+
+			u, err := v.Pointer()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Pointer() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if u != uintptr(tc.want) {
+				t.Errorf("Pointer() = %x, want %x", u, uintptr(tc.want))
+			}
+		})
+	}
+}
+
+func TestUnsafePointerOfField(t *testing.T) {
+	type Holder struct {
+		P    *int
+		Data []byte
+	}
+	x := 7
+	h := Holder{P: &x, Data: []byte("abc")}
+	v, _ := tinyreflect.ValueOf(&h).Elem()
+
+	p, _ := v.Field(0)
+	if got, err := p.UnsafePointer(); err != nil || got != unsafe.Pointer(&x) {
+		t.Errorf("UnsafePointer() of pointer field = %p, %v, want %p", got, err, &x)
+	}
+	data, _ := v.Field(1)
+	if got, err := data.UnsafePointer(); err != nil || got != unsafe.Pointer(&h.Data[0]) {
+		t.Errorf("UnsafePointer() of slice field = %p, %v, want %p", got, err, &h.Data[0])
+	}
+}
+
+func TestUnsafeAddr(t *testing.T) {
+	type Point struct {
+		X, Y int32
+	}
+	p := Point{1, 2}
+	v, _ := tinyreflect.ValueOf(&p).Elem()
+
+	addr, err := v.UnsafeAddr()
+	if err != nil {
+		t.Fatalf("UnsafeAddr() failed: %v", err)
+	}
+	if addr != uintptr(unsafe.Pointer(&p)) {
+		t.Errorf("UnsafeAddr() = %x, want %x", addr, uintptr(unsafe.Pointer(&p)))
+	}
+
+	y, _ := v.Field(1)
+	addr, err = y.UnsafeAddr()
+	if err != nil || addr != uintptr(unsafe.Pointer(&p.Y)) {
+		t.Errorf("UnsafeAddr() of field = %x, %v, want %x", addr, err, uintptr(unsafe.Pointer(&p.Y)))
+	}
+
+	if _, err := tinyreflect.ValueOf(p).UnsafeAddr(); err == nil {
+		t.Error("UnsafeAddr() on unaddressable value: expected an error")
+	}
+}
+
+func TestNewAt(t *testing.T) {
+	type Vec struct {
+		X, Y, Z float32
+	}
+	backing := [2]Vec{}
+
+	typ := tinyreflect.TypeOf(Vec{})
+	ptr := tinyreflect.NewAt(typ, unsafe.Pointer(&backing[1]))
+	if ptr.Kind().String() != "ptr" {
+		t.Fatalf("NewAt should return a pointer, got %s", ptr.Kind())
+	}
+	if got, _ := ptr.UnsafePointer(); got != unsafe.Pointer(&backing[1]) {
+		t.Errorf("NewAt pointer = %p, want %p", got, &backing[1])
+	}
+
+	elem, err := ptr.Elem()
+	if err != nil {
+		t.Fatalf("Elem() failed: %v", err)
+	}
+	if !elem.CanAddr() {
+		t.Error("Elem() of NewAt should be addressable")
+	}
+
+	// Writes go straight to the wrapped memory, no copy is made
+	z, _ := elem.Field(2)
+	if err := z.SetFloat(3.5); err != nil {
+		t.Fatalf("SetFloat failed: %v", err)
+	}
+	if backing[1].Z != 3.5 {
+		t.Errorf("expected backing[1].Z = 3.5, got %v", backing[1].Z)
+	}
+
+	if v := tinyreflect.NewAt(nil, unsafe.Pointer(&backing[0])); v.Type() != nil {
+		t.Error("NewAt(nil, p) should return the zero Value")
+	}
+}
@@ -0,0 +1,299 @@
+package wire
+
+import (
+	"math"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	. "github.com/cdvelop/tinystring"
+)
+
+func decodeError(k Kind, err error) error {
+	return &tinyreflect.ValueError{Method: "wire.Unmarshal", Kind: k, Err: err}
+}
+
+// decoder consumes a payload from the front.
+type decoder struct {
+	data []byte
+}
+
+func (d *decoder) byte() (byte, bool) {
+	if len(d.data) == 0 {
+		return 0, false
+	}
+	b := d.data[0]
+	d.data = d.data[1:]
+	return b, true
+}
+
+func (d *decoder) bytes(n int) ([]byte, bool) {
+	if n > len(d.data) {
+		return nil, false
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b, true
+}
+
+func (d *decoder) uvarint() (uint64, bool) {
+	x, n := uvarint(d.data)
+	if n <= 0 {
+		return 0, false
+	}
+	d.data = d.data[n:]
+	return x, true
+}
+
+func (d *decoder) varint() (int64, bool) {
+	x, n := varint(d.data)
+	if n <= 0 {
+		return 0, false
+	}
+	d.data = d.data[n:]
+	return x, true
+}
+
+// length reads a uvarint length of elements of type elem and checks it
+// against the remaining payload before anything is allocated.
+func (d *decoder) length(elem *tinyreflect.Type) (int, bool) {
+	n, ok := d.uvarint()
+	if !ok || n > uint64(len(d.data)) && nonEmpty(elem) || n > math.MaxInt32 {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// value decodes into v.
+func (d *decoder) value(v tinyreflect.Value, depth int) error {
+	if depth > codec.MaxDepth {
+		return decodeError(v.Kind(), ErrMaxDepth)
+	}
+
+	switch k := v.Kind(); k {
+	case K.Bool:
+		b, ok := d.byte()
+		if !ok || b > 1 {
+			return decodeError(k, ErrInvalidData)
+		}
+		return v.SetBool(b == 1)
+
+	case K.Int8:
+		b, ok := d.byte()
+		if !ok {
+			return decodeError(k, ErrInvalidData)
+		}
+		return v.SetInt(int64(int8(b)))
+
+	case K.Uint8:
+		b, ok := d.byte()
+		if !ok {
+			return decodeError(k, ErrInvalidData)
+		}
+		return v.SetUint(uint64(b))
+
+	case K.Int, K.Int16, K.Int32, K.Int64:
+		i, ok := d.varint()
+		if !ok {
+			return decodeError(k, ErrInvalidData)
+		}
+		if v.OverflowInt(i) {
+			return decodeError(k, tinyreflect.ErrOverflow)
+		}
+		return v.SetInt(i)
+
+	case K.Uint, K.Uint16, K.Uint32, K.Uint64, K.Uintptr:
+		u, ok := d.uvarint()
+		if !ok {
+			return decodeError(k, ErrInvalidData)
+		}
+		if v.OverflowUint(u) {
+			return decodeError(k, tinyreflect.ErrOverflow)
+		}
+		return v.SetUint(u)
+
+	case K.Float32:
+		b, ok := d.bytes(4)
+		if !ok {
+			return decodeError(k, ErrInvalidData)
+		}
+		bits := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+		return v.SetFloat(float64(math.Float32frombits(bits)))
+
+	case K.Float64:
+		b, ok := d.bytes(8)
+		if !ok {
+			return decodeError(k, ErrInvalidData)
+		}
+		var bits uint64
+		for i := 7; i >= 0; i-- {
+			bits = bits<<8 | uint64(b[i])
+		}
+		return v.SetFloat(math.Float64frombits(bits))
+
+	case K.String:
+		n, ok := d.uvarint()
+		if !ok || n > uint64(len(d.data)) {
+			return decodeError(k, ErrInvalidData)
+		}
+		b, _ := d.bytes(int(n))
+		return v.SetString(string(b))
+
+	case K.Slice:
+		return d.slice(v, depth)
+
+	case K.Array:
+		n, _ := v.Len()
+		return d.elems(v, n, depth)
+
+	case K.Map:
+		return d.mapValue(v, depth)
+
+	case K.Struct:
+		return d.structValue(v, depth)
+
+	case K.Pointer:
+		b, ok := d.byte()
+		if !ok || b > 1 {
+			return decodeError(k, ErrInvalidData)
+		}
+		if b == 0 {
+			return v.SetZero()
+		}
+		return d.pointee(v, depth)
+
+	default:
+		return decodeError(k, tinyreflect.ErrUnsupportedKind)
+	}
+}
+
+// pointee decodes into the value v points to, allocating it if v is nil.
+func (d *decoder) pointee(v tinyreflect.Value, depth int) error {
+	if v.IsZero() {
+		if err := v.Set(tinyreflect.NewValue(v.Type().Elem())); err != nil {
+			return err
+		}
+	}
+	elem, err := v.Elem()
+	if err != nil {
+		return err
+	}
+	return d.value(elem, depth+1)
+}
+
+// slice decodes a length-prefixed slice, reusing v's backing array when
+// it is large enough.
+func (d *decoder) slice(v tinyreflect.Value, depth int) error {
+	typ := v.Type()
+	elemType := typ.Elem()
+	n, ok := d.length(elemType)
+	if !ok {
+		return decodeError(K.Slice, ErrInvalidData)
+	}
+	if n == 0 {
+		return v.SetZero()
+	}
+	if elemType.Kind() == K.Uint8 {
+		b, _ := d.bytes(n)
+		return v.SetBytes(append([]byte(nil), b...))
+	}
+
+	if c, _ := v.Cap(); c >= n {
+		if err := v.SetLen(n); err != nil {
+			return err
+		}
+	} else {
+		s, err := tinyreflect.MakeSlice(typ, n, n)
+		if err != nil {
+			return err
+		}
+		if err := v.Set(s); err != nil {
+			return err
+		}
+	}
+	return d.elems(v, n, depth)
+}
+
+// elems decodes n elements into the slice or array v.
+func (d *decoder) elems(v tinyreflect.Value, n, depth int) error {
+	for i := 0; i < n; i++ {
+		elem, err := v.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := d.value(elem, depth+1); err != nil {
+			return codec.WithPath(err, codec.IndexSegment(i))
+		}
+	}
+	return nil
+}
+
+// mapValue decodes the entries of a map into a new map stored in v.
+func (d *decoder) mapValue(v tinyreflect.Value, depth int) error {
+	typ := v.Type()
+	keyType, elemType := typ.Key(), typ.Elem()
+	n, ok := d.length(keyType)
+	if !ok {
+		return decodeError(K.Map, ErrInvalidData)
+	}
+	if n == 0 {
+		return v.SetZero()
+	}
+	m, err := tinyreflect.MakeMapWithSize(typ, n)
+	if err != nil {
+		return err
+	}
+	if err := v.Set(m); err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		key, err := tinyreflect.NewValue(keyType).Elem()
+		if err != nil {
+			return err
+		}
+		if err := d.value(key, depth+1); err != nil {
+			return codec.WithPath(err, codec.IndexSegment(i))
+		}
+		elem, err := tinyreflect.NewValue(elemType).Elem()
+		if err != nil {
+			return err
+		}
+		if err := d.value(elem, depth+1); err != nil {
+			return codec.WithPath(err, codec.IndexSegment(i))
+		}
+		if err := v.SetMapIndex(key, elem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structValue reads the presence bitmap and decodes the fields of v.
+func (d *decoder) structValue(v tinyreflect.Value, depth int) error {
+	p, err := plan(v.Type())
+	if err != nil {
+		return err
+	}
+	bitmap, ok := d.bytes((p.nptr + 7) / 8)
+	if !ok {
+		return decodeError(K.Struct, ErrInvalidData)
+	}
+	for _, f := range p.fields {
+		fv, err := v.Field(f.index)
+		if err != nil {
+			return codec.WithPath(err, f.name)
+		}
+		if f.bit >= 0 {
+			if bitmap[f.bit/8]&(1<<(f.bit%8)) == 0 {
+				err = fv.SetZero()
+			} else {
+				err = d.pointee(fv, depth)
+			}
+		} else {
+			err = d.value(fv, depth+1)
+		}
+		if err != nil {
+			return codec.WithPath(err, f.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package wire
+
+import (
+	"math"
+	"slices"
+	"unsafe"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	. "github.com/cdvelop/tinystring"
+)
+
+func encodeError(k Kind, err error) error {
+	return &tinyreflect.ValueError{Method: "wire.Marshal", Kind: k, Err: err}
+}
+
+// encodeValue appends the encoding of v to dst.
+func encodeValue(dst []byte, v tinyreflect.Value, depth int) ([]byte, error) {
+	if depth > codec.MaxDepth {
+		return dst, encodeError(v.Kind(), ErrMaxDepth)
+	}
+
+	switch k := v.Kind(); k {
+	case K.Bool:
+		b, _ := v.Bool()
+		if b {
+			return append(dst, 1), nil
+		}
+		return append(dst, 0), nil
+
+	case K.Int8:
+		i, _ := v.Int()
+		return append(dst, byte(i)), nil
+
+	case K.Uint8:
+		u, _ := v.Uint()
+		return append(dst, byte(u)), nil
+
+	case K.Int, K.Int16, K.Int32, K.Int64:
+		i, _ := v.Int()
+		return appendVarint(dst, i), nil
+
+	case K.Uint, K.Uint16, K.Uint32, K.Uint64, K.Uintptr:
+		u, _ := v.Uint()
+		return appendUvarint(dst, u), nil
+
+	case K.Float32:
+		f, _ := v.Float()
+		b := math.Float32bits(float32(f))
+		return append(dst, byte(b), byte(b>>8), byte(b>>16), byte(b>>24)), nil
+
+	case K.Float64:
+		f, _ := v.Float()
+		b := math.Float64bits(f)
+		return append(dst, byte(b), byte(b>>8), byte(b>>16), byte(b>>24),
+			byte(b>>32), byte(b>>40), byte(b>>48), byte(b>>56)), nil
+
+	case K.String:
+		s := v.String()
+		dst = appendUvarint(dst, uint64(len(s)))
+		return append(dst, s...), nil
+
+	case K.Slice:
+		n, _ := v.Len()
+		dst = appendUvarint(dst, uint64(n))
+		if n > 0 && v.Type().Elem().Kind() == K.Uint8 {
+			// Byte slices are copied as is
+			p, _ := v.UnsafePointer()
+			return append(dst, unsafe.Slice((*byte)(p), n)...), nil
+		}
+		return encodeElems(dst, v, n, depth)
+
+	case K.Array:
+		n, _ := v.Len()
+		return encodeElems(dst, v, n, depth)
+
+	case K.Map:
+		return encodeMap(dst, v, depth)
+
+	case K.Struct:
+		return encodeStruct(dst, v, depth)
+
+	case K.Pointer:
+		if v.IsZero() {
+			return append(dst, 0), nil
+		}
+		elem, err := v.Elem()
+		if err != nil {
+			return dst, err
+		}
+		return encodeValue(append(dst, 1), elem, depth+1)
+
+	default:
+		return dst, encodeError(k, tinyreflect.ErrUnsupportedKind)
+	}
+}
+
+// encodeElems appends the first n elements of the slice or array v.
+func encodeElems(dst []byte, v tinyreflect.Value, n, depth int) ([]byte, error) {
+	for i := 0; i < n; i++ {
+		elem, err := v.Index(i)
+		if err != nil {
+			return dst, err
+		}
+		if dst, err = encodeValue(dst, elem, depth+1); err != nil {
+			return dst, codec.WithPath(err, codec.IndexSegment(i))
+		}
+	}
+	return dst, nil
+}
+
+// encodeStruct appends the presence bitmap of v's pointer fields, then
+// its fields in order.
+func encodeStruct(dst []byte, v tinyreflect.Value, depth int) ([]byte, error) {
+	p, err := plan(v.Type())
+	if err != nil {
+		return dst, err
+	}
+	bitmap := len(dst)
+	for i := 0; i < (p.nptr+7)/8; i++ {
+		dst = append(dst, 0)
+	}
+	for _, f := range p.fields {
+		fv, err := v.Field(f.index)
+		if err != nil {
+			return dst, codec.WithPath(err, f.name)
+		}
+		if f.bit >= 0 {
+			if fv.IsZero() {
+				continue
+			}
+			dst[bitmap+f.bit/8] |= 1 << (f.bit % 8)
+			if fv, err = fv.Elem(); err != nil {
+				return dst, codec.WithPath(err, f.name)
+			}
+		}
+		if dst, err = encodeValue(dst, fv, depth+1); err != nil {
+			return dst, codec.WithPath(err, f.name)
+		}
+	}
+	return dst, nil
+}
+
+// mapEntry is a map entry with its key already encoded.
+type mapEntry struct {
+	key []byte
+	val tinyreflect.Value
+}
+
+// encodeMap appends the entry count and the entries sorted by their
+// encoded keys, so equal maps always give the same bytes.
+func encodeMap(dst []byte, v tinyreflect.Value, depth int) ([]byte, error) {
+	n, _ := v.Len()
+	dst = appendUvarint(dst, uint64(n))
+	if n == 0 {
+		return dst, nil
+	}
+	iter, err := v.MapRange()
+	if err != nil {
+		return dst, err
+	}
+	entries := make([]mapEntry, 0, n)
+	for iter.Next() {
+		key, err := encodeValue(nil, iter.Key(), depth+1)
+		if err != nil {
+			return dst, err
+		}
+		entries = append(entries, mapEntry{key, iter.Value()})
+	}
+	slices.SortFunc(entries, func(a, b mapEntry) int {
+		return slices.Compare(a.key, b.key)
+	})
+	for i, e := range entries {
+		dst = append(dst, e.key...)
+		if dst, err = encodeValue(dst, e.val, depth+1); err != nil {
+			return dst, codec.WithPath(err, codec.IndexSegment(i))
+		}
+	}
+	return dst, nil
+}
@@ -0,0 +1,216 @@
+package wire
+
+import (
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/cache"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	. "github.com/cdvelop/tinystring"
+)
+
+// field is one encoded struct field.
+type field struct {
+	index int    // index for Value.Field
+	name  string // Go field name, used in the fingerprint and error paths
+	typ   *tinyreflect.Type
+	bit   int // presence bit for pointer fields, -1 otherwise
+}
+
+// structPlan lists the encoded fields of a struct type in field order.
+type structPlan struct {
+	fields  []field
+	nptr    int  // pointer fields, i.e. bits in the presence bitmap
+	minSize bool // every value encodes to at least one byte
+}
+
+// plans and prints keep struct plans and fingerprints per type, so tags
+// are read and fingerprints hashed once per type instead of once per value.
+var (
+	plans  cache.Cache[*tinyreflect.Type, *structPlan]
+	prints cache.Cache[*tinyreflect.Type, uint32]
+)
+
+// plan returns the encoded fields of the struct type t.
+func plan(t *tinyreflect.Type) (*structPlan, error) {
+	if p, ok := plans.Load(t); ok {
+		return p, nil
+	}
+
+	n, err := t.NumField()
+	if err != nil {
+		return nil, err
+	}
+	p := &structPlan{}
+	for i := 0; i < n; i++ {
+		sf, err := t.Field(i)
+		if err != nil {
+			return nil, err
+		}
+		if !sf.IsExported() || sf.Tag().Get("wire") == "-" {
+			continue
+		}
+		name, err := t.NameByIndex(i)
+		if err != nil {
+			return nil, err
+		}
+		f := field{index: i, name: name, typ: sf.Typ, bit: -1}
+		if sf.Typ.Kind() == K.Pointer {
+			f.bit = p.nptr
+			p.nptr++
+		}
+		p.fields = append(p.fields, f)
+	}
+
+	p.minSize = p.nptr > 0
+	for _, f := range p.fields {
+		p.minSize = p.minSize || nonEmpty(f.typ)
+	}
+
+	plans.Store(t, p)
+	return p, nil
+}
+
+// nonEmpty reports whether every value of t encodes to at least one byte.
+// Decoders use it to reject lengths larger than the remaining payload
+// before allocating.
+func nonEmpty(t *tinyreflect.Type) bool {
+	switch t.Kind() {
+	case K.Array:
+		at := t.ArrayType()
+		return at.Length() > 0 && nonEmpty(at.Element())
+	case K.Struct:
+		p, err := plan(t)
+		return err == nil && p.minSize
+	}
+	return true
+}
+
+// Fingerprint returns a 32-bit hash of the structure of t: the kinds of
+// its values, the names and order of encoded struct fields, array
+// lengths, and the element, key and field types reached from them.
+// Type names and the size of int, uint and uintptr are not included, so
+// the same declaration gives the same fingerprint on every build. Types that
+// cannot be encoded, such as interfaces, return ErrUnsupportedKind.
+func Fingerprint(t *tinyreflect.Type) (uint32, error) {
+	if t == nil {
+		return 0, &tinyreflect.ValueError{Method: "wire.Fingerprint", Err: tinyreflect.ErrNilValue}
+	}
+	if fp, ok := prints.Load(t); ok {
+		return fp, nil
+	}
+
+	desc, err := describe(nil, t, nil)
+	if err != nil {
+		return 0, err
+	}
+	// FNV-1a
+	fp := uint32(2166136261)
+	for _, b := range desc {
+		fp ^= uint32(b)
+		fp *= 16777619
+	}
+
+	prints.Store(t, fp)
+	return fp, nil
+}
+
+// backRef marks a reference to a struct type already being described,
+// followed by how many levels up it is, so recursive types terminate.
+const backRef = 0xFF
+
+// kindCode returns a fixed code for k. Kind values follow each runtime's
+// own numbering, which differs between Go and TinyGo, so they are not
+// hashed directly.
+func kindCode(k Kind) byte {
+	switch k {
+	case K.Bool:
+		return 1
+	case K.Int:
+		return 2
+	case K.Int8:
+		return 3
+	case K.Int16:
+		return 4
+	case K.Int32:
+		return 5
+	case K.Int64:
+		return 6
+	case K.Uint:
+		return 7
+	case K.Uint8:
+		return 8
+	case K.Uint16:
+		return 9
+	case K.Uint32:
+		return 10
+	case K.Uint64:
+		return 11
+	case K.Uintptr:
+		return 12
+	case K.Float32:
+		return 13
+	case K.Float64:
+		return 14
+	case K.String:
+		return 15
+	case K.Pointer:
+		return 16
+	case K.Slice:
+		return 17
+	case K.Array:
+		return 18
+	case K.Map:
+		return 19
+	case K.Struct:
+		return 20
+	}
+	return 0
+}
+
+// describe appends a canonical description of t to dst. stack holds the
+// struct types being described, outermost first.
+func describe(dst []byte, t *tinyreflect.Type, stack []*tinyreflect.Type) ([]byte, error) {
+	k := t.Kind()
+	switch k {
+	case K.Bool, K.String, K.Float32, K.Float64,
+		K.Int, K.Int8, K.Int16, K.Int32, K.Int64,
+		K.Uint, K.Uint8, K.Uint16, K.Uint32, K.Uint64, K.Uintptr:
+		return append(dst, kindCode(k)), nil
+
+	case K.Pointer, K.Slice:
+		return describe(append(dst, kindCode(k)), t.Elem(), stack)
+
+	case K.Array:
+		at := t.ArrayType()
+		dst = appendUvarint(append(dst, kindCode(k)), uint64(at.Length()))
+		return describe(dst, at.Element(), stack)
+
+	case K.Map:
+		dst, err := describe(append(dst, kindCode(k)), t.Key(), stack)
+		if err != nil {
+			return nil, err
+		}
+		return describe(dst, t.Elem(), stack)
+
+	case K.Struct:
+		for i, s := range stack {
+			if s == t {
+				return appendUvarint(append(dst, backRef), uint64(len(stack)-i)), nil
+			}
+		}
+		p, err := plan(t)
+		if err != nil {
+			return nil, err
+		}
+		stack = append(stack, t)
+		dst = appendUvarint(append(dst, kindCode(k)), uint64(len(p.fields)))
+		for _, f := range p.fields {
+			dst = appendUvarint(dst, uint64(len(f.name)))
+			dst = append(dst, f.name...)
+			if dst, err = describe(dst, f.typ, stack); err != nil {
+				return nil, codec.WithPath(err, f.name)
+			}
+		}
+		return dst, nil
+	}
+	return nil, &tinyreflect.ValueError{Method: "wire.Fingerprint", Kind: k, Err: tinyreflect.ErrUnsupportedKind}
+}
@@ -0,0 +1,47 @@
+package wire
+
+// appendUvarint appends x in the base-128 varint format used by
+// encoding/binary and TinyGo's struct field data.
+func appendUvarint(dst []byte, x uint64) []byte {
+	for x >= 0x80 {
+		dst = append(dst, byte(x)|0x80)
+		x >>= 7
+	}
+	return append(dst, byte(x))
+}
+
+// appendVarint appends x zigzag-encoded, so small negative numbers
+// stay short.
+func appendVarint(dst []byte, x int64) []byte {
+	return appendUvarint(dst, uint64(x<<1)^uint64(x>>63))
+}
+
+// uvarint decodes a uint64 from buf and returns that value and the
+// number of bytes read (> 0). If buf is too short or the value overflows
+// 64 bits, n is <= 0.
+func uvarint(buf []byte) (uint64, int) {
+	var x uint64
+	var s uint
+	for i, b := range buf {
+		if i == maxVarintLen64 {
+			return 0, -(i + 1) // overflow
+		}
+		if b < 0x80 {
+			if i == maxVarintLen64-1 && b > 1 {
+				return 0, -(i + 1) // overflow
+			}
+			return x | uint64(b)<<s, i + 1
+		}
+		x |= uint64(b&0x7f) << s
+		s += 7
+	}
+	return 0, 0
+}
+
+// varint decodes a zigzag-encoded int64 like uvarint.
+func varint(buf []byte) (int64, int) {
+	ux, n := uvarint(buf)
+	return int64(ux>>1) ^ -int64(ux&1), n
+}
+
+const maxVarintLen64 = 10 // maximum length of a varint64
@@ -0,0 +1,104 @@
+// Package wire is a compact, schema-driven binary codec built on
+// tinyreflect, meant for chatty traffic between a Go server and a TinyGo
+// or WebAssembly client.
+//
+// A payload starts with the 4-byte structural fingerprint of the encoded
+// type (see Fingerprint), so a receiver built from a different schema
+// rejects it with ErrSchemaMismatch instead of misreading it. The value
+// follows with no field names or type tags:
+//
+//   - bool, int8 and uint8 take one byte; other integers are varints,
+//     zigzag-encoded when signed, so int and uint mean the same on 32
+//     and 64-bit targets
+//   - float32 and float64 are 4 and 8 little-endian bytes
+//   - strings, slices and maps are a uvarint length followed by the
+//     bytes or elements; map entries are sorted by their encoded key
+//   - arrays are their elements, the length comes from the schema
+//   - structs are a presence bitmap with one bit per pointer field,
+//     followed by the exported fields in Type.Field order, skipping nil
+//     pointers; fields tagged `wire:"-"` are left out
+//   - other pointers are a presence byte followed by the element
+//
+// Nil and empty slices and maps are both encoded as length 0 and decoded
+// as nil. The encoding does not depend on the build, so the stdlib and
+// TinyGo builds produce and accept the same bytes.
+package wire
+
+import (
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/internal/codec"
+	. "github.com/cdvelop/tinystring"
+)
+
+// Errors returned by the codec. Decoding errors are wrapped in a
+// *tinyreflect.ValueError with the path of the value that failed.
+var (
+	// ErrSchemaMismatch is returned when the payload's fingerprint differs from the target type's.
+	ErrSchemaMismatch = tinyreflect.NewError(D.Binary, D.Format, D.Mismatch)
+	// ErrInvalidData is returned for truncated payloads, malformed varints and trailing bytes.
+	ErrInvalidData = codec.ErrInvalidData
+	// ErrMaxDepth is returned for values nested too deeply, usually through
+	// a pointer cycle. All the codec packages share this value.
+	ErrMaxDepth = codec.ErrMaxDepth
+)
+
+// Marshal returns the wire encoding of v. If v is a pointer, the value
+// it points to is encoded, so Marshal(&x) and Marshal(x) are the same.
+func Marshal(v any) ([]byte, error) {
+	return Append(nil, v)
+}
+
+// Append appends the wire encoding of v to dst and returns the extended
+// buffer.
+func Append(dst []byte, v any) ([]byte, error) {
+	rv := tinyreflect.ValueOf(v)
+	if rv.Kind() == K.Pointer {
+		if rv.IsZero() {
+			return dst, &tinyreflect.ValueError{Method: "wire.Marshal", Kind: K.Pointer, Err: tinyreflect.ErrNilValue}
+		}
+		var err error
+		if rv, err = rv.Elem(); err != nil {
+			return dst, err
+		}
+	}
+	if rv.Kind() == K.Invalid {
+		return dst, &tinyreflect.ValueError{Method: "wire.Marshal", Err: tinyreflect.ErrNilValue}
+	}
+	fp, err := Fingerprint(rv.Type())
+	if err != nil {
+		return dst, err
+	}
+	dst = append(dst, byte(fp), byte(fp>>8), byte(fp>>16), byte(fp>>24))
+	return encodeValue(dst, rv, 0)
+}
+
+// Unmarshal decodes a payload produced by Marshal into the value pointed
+// to by v, which must be a non-nil pointer to a value of the same schema.
+func Unmarshal(data []byte, v any) error {
+	rv := tinyreflect.ValueOf(v)
+	if rv.Kind() != K.Pointer || rv.IsZero() {
+		return &tinyreflect.ValueError{Method: "wire.Unmarshal", Kind: rv.Kind(), Err: tinyreflect.ErrInvalidArgument}
+	}
+	elem, err := rv.Elem()
+	if err != nil {
+		return err
+	}
+	fp, err := Fingerprint(elem.Type())
+	if err != nil {
+		return err
+	}
+	if len(data) < 4 {
+		return decodeError(elem.Kind(), ErrInvalidData)
+	}
+	if got := uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16 | uint32(data[3])<<24; got != fp {
+		return decodeError(elem.Kind(), ErrSchemaMismatch)
+	}
+	d := decoder{data: data[4:]}
+	if err := d.value(elem, 0); err != nil {
+		return err
+	}
+	if len(d.data) != 0 {
+		return decodeError(elem.Kind(), ErrInvalidData)
+	}
+	return nil
+}
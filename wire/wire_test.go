@@ -0,0 +1,235 @@
+package wire_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/cdvelop/tinyreflect"
+	"github.com/cdvelop/tinyreflect/wire"
+)
+
+type Point struct {
+	X, Y int32
+}
+
+type Node struct {
+	Name     string
+	Children []*Node
+	Parent   *Node `wire:"-"`
+}
+
+type Sync struct {
+	ID      uint64
+	Seq     int
+	Delta   int8
+	Flags   uint8
+	Ok      bool
+	Ratio   float32
+	Score   float64
+	Label   string
+	Data    []byte
+	Points  []Point
+	Corners [2]Point
+	Tags    map[string]uint16
+	Owner   *Point
+	Backup  *Point
+	Note    *string
+	Tree    *Node
+	Nested  [][]int16
+	local   int
+}
+
+func TestRoundTrip(t *testing.T) {
+	note := "héllo"
+	in := Sync{
+		ID:      math.MaxUint64,
+		Seq:     -300,
+		Delta:   -128,
+		Flags:   255,
+		Ok:      true,
+		Ratio:   0.1,
+		Score:   -math.MaxFloat64,
+		Label:   "sync",
+		Data:    []byte{0, 1, 2, 255},
+		Points:  []Point{{1, -1}, {math.MaxInt32, math.MinInt32}},
+		Corners: [2]Point{{3, 4}, {5, 6}},
+		Tags:    map[string]uint16{"b": 2, "a": 1, "c": 65535},
+		Owner:   &Point{7, 8},
+		Note:    &note,
+		Tree:    &Node{Name: "root", Children: []*Node{{Name: "leaf"}, nil}},
+		Nested:  [][]int16{{1}, nil, {-2, 3}},
+		local:   9,
+	}
+
+	data, err := wire.Marshal(&in)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var out Sync
+	out.Backup = &Point{1, 1} // absent pointers are cleared
+	out.local = 9             // unexported fields are not touched
+	if err := wire.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	want := in
+	want.Nested = [][]int16{{1}, nil, {-2, 3}}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("got  %+v\nwant %+v", out, want)
+	}
+
+	again, err := wire.Marshal(out)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("re-encoding differs:\n%v\n%v", again, data)
+	}
+}
+
+// TestGoldenBytes pins the encoding, which must not depend on the build.
+func TestGoldenBytes(t *testing.T) {
+	type Msg struct {
+		A int
+		B string
+		C *uint8
+		D []bool
+		E map[int8]float32
+	}
+	c := uint8(200)
+	data, err := wire.Marshal(Msg{A: -2, B: "hi", C: &c, D: []bool{true, false}, E: map[int8]float32{-1: 1, 1: 0}})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	fp, err := wire.Fingerprint(tinyreflect.TypeOf(Msg{}))
+	if err != nil {
+		t.Fatalf("Fingerprint: %v", err)
+	}
+	want := []byte{
+		byte(fp), byte(fp >> 8), byte(fp >> 16), byte(fp >> 24),
+		0x01,           // presence bitmap: C
+		0x03,           // A: zigzag(-2)
+		0x02, 'h', 'i', // B
+		200,              // C
+		0x02, 0x01, 0x00, // D
+		0x02,             // E: 2 entries sorted by key bytes
+		0x01, 0, 0, 0, 0, // 1: 0.0
+		0xff, 0, 0, 0x80, 0x3f, // -1: 1.0
+	}
+	if string(data) != string(want) {
+		t.Errorf("got  % x\nwant % x", data, want)
+	}
+	if fp != 0x9ed15b98 {
+		t.Errorf("fingerprint = %#x, want 0x9ed15b98", fp)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	type V1 struct {
+		Name string
+		Age  int
+	}
+	type Renamed struct {
+		Name string
+		Age  int
+	}
+	type V2 struct {
+		Name string
+		Age  int64
+	}
+	type V3 struct {
+		Age  int
+		Name string
+	}
+	type Hidden struct {
+		Name  string
+		Age   int
+		cache []byte
+		Skip  chan int `wire:"-"`
+	}
+
+	fp := func(v any) uint32 {
+		t.Helper()
+		f, err := wire.Fingerprint(tinyreflect.TypeOf(v))
+		if err != nil {
+			t.Fatalf("Fingerprint(%T): %v", v, err)
+		}
+		return f
+	}
+
+	base := fp(V1{})
+	if fp(Renamed{}) != base {
+		t.Error("type names should not change the fingerprint")
+	}
+	if fp(Hidden{}) != base {
+		t.Error("unexported and skipped fields should not change the fingerprint")
+	}
+	if fp(V2{}) == base {
+		t.Error("field kinds should change the fingerprint")
+	}
+	if fp(V3{}) == base {
+		t.Error("field order should change the fingerprint")
+	}
+	if fp([3]int{}) == fp([4]int{}) {
+		t.Error("array lengths should change the fingerprint")
+	}
+	if fp(Node{}) == 0 {
+		t.Error("recursive types should have a fingerprint")
+	}
+
+	type Bad struct{ F func() }
+	_, err := wire.Fingerprint(tinyreflect.TypeOf(Bad{}))
+	var ve *tinyreflect.ValueError
+	if !errors.Is(err, tinyreflect.ErrUnsupportedKind) || !errors.As(err, &ve) || ve.Path != "F" {
+		t.Errorf("got %v, want ErrUnsupportedKind at F", err)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	data, err := wire.Marshal(Sync{Label: "x", Points: []Point{{1, 2}}})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		data    []byte
+		target  any
+		wantErr error
+	}{
+		{"Schema mismatch", data, &Point{}, wire.ErrSchemaMismatch},
+		{"Short header", data[:3], &Sync{}, wire.ErrInvalidData},
+		{"Truncated", data[:len(data)-1], &Sync{}, wire.ErrInvalidData},
+		{"Trailing bytes", append(append([]byte(nil), data...), 0), &Sync{}, wire.ErrInvalidData},
+		{"Non-pointer", data, Sync{}, tinyreflect.ErrInvalidArgument},
+		{"Nil pointer", data, (*Sync)(nil), tinyreflect.ErrInvalidArgument},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := wire.Unmarshal(tc.data, tc.target); !errors.Is(err, tc.wantErr) {
+				t.Errorf("got %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+
+	// A huge slice length is rejected before allocating
+	hdr, _ := wire.Marshal([]string{})
+	huge := append(hdr[:4:4], 0xff, 0xff, 0xff, 0xff, 0x0f)
+	var list []string
+	err = wire.Unmarshal(huge, &list)
+	if !errors.Is(err, wire.ErrInvalidData) {
+		t.Errorf("huge length: got %v, want ErrInvalidData", err)
+	}
+
+	// Bad bool byte reports the path
+	pts, _ := wire.Marshal(struct{ Ok []bool }{[]bool{true, true}})
+	pts[len(pts)-1] = 2
+	var target struct{ Ok []bool }
+	err = wire.Unmarshal(pts, &target)
+	var ve *tinyreflect.ValueError
+	if !errors.As(err, &ve) || ve.Path != "Ok[1]" {
+		t.Errorf("got %v, want ErrInvalidData at Ok[1]", err)
+	}
+}